@@ -0,0 +1,161 @@
+package gockle
+
+import (
+	"context"
+)
+
+// DefaultAsyncLimit is the most asynchronous calls that run at once through a
+// Session. Later calls wait to start.
+const DefaultAsyncLimit = 128
+
+// Future is the result of an asynchronous call. It is done when the call
+// returns.
+type Future struct {
+	done     chan struct{}
+	err      error
+	iterator Iterator
+}
+
+func newFuture() *Future {
+	return &Future{done: make(chan struct{})}
+}
+
+// complete makes f done with err.
+func (f *Future) complete(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel that is closed when f is done.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits until f or ctx is done and returns the error of the call or ctx.
+func (f *Future) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Iterator returns the Iterator of Query.IterAsync once f is done, and nil
+// before, for other calls, or if the context was done before the query
+// started.
+func (f *Future) Iterator() Iterator {
+	select {
+	case <-f.done:
+		return f.iterator
+
+	default:
+		return nil
+	}
+}
+
+// All returns a Future that is done when all of fs are, with the first error
+// of fs in order.
+func All(fs ...*Future) *Future {
+	var a = newFuture()
+
+	go func() {
+		var err error
+
+		for _, f := range fs {
+			<-f.done
+
+			if err == nil {
+				err = f.err
+			}
+		}
+
+		a.complete(err)
+	}()
+
+	return a
+}
+
+// First returns a Future that is done when the first of fs is, with its
+// error. It is done at once if there are no fs.
+func First(fs ...*Future) *Future {
+	var a = newFuture()
+
+	if len(fs) == 0 {
+		a.complete(nil)
+
+		return a
+	}
+
+	var errs = make(chan error, len(fs))
+
+	for _, f := range fs {
+		go func() {
+			<-f.done
+			errs <- f.err
+		}()
+	}
+
+	go func() {
+		a.complete(<-errs)
+	}()
+
+	return a
+}
+
+// limiter limits the asynchronous calls that run at once.
+type limiter chan struct{}
+
+func newLimiter(n int) limiter {
+	return make(limiter, n)
+}
+
+// limiterOf returns the limiter of s, or a new one of DefaultAsyncLimit if s
+// is not a Session of this package.
+func limiterOf(s Session) limiter {
+	if l, ok := s.(interface{ asyncLimiter() limiter }); ok {
+		return l.asyncLimiter()
+	}
+
+	return newLimiter(DefaultAsyncLimit)
+}
+
+// run calls f in a new goroutine once there is room, or fails with the error
+// of ctx if it is done first.
+func (l limiter) run(ctx context.Context, f func(fu *Future) error) *Future {
+	var fu = newFuture()
+
+	go func() {
+		if err := ctx.Err(); err != nil {
+			fu.complete(err)
+
+			return
+		}
+
+		select {
+		case l <- struct{}{}:
+
+		case <-ctx.Done():
+			fu.complete(ctx.Err())
+
+			return
+		}
+
+		var err = f(fu)
+
+		<-l
+		fu.complete(err)
+	}()
+
+	return fu
+}
+
+// iter runs i with l and makes its Iterator that of the Future.
+func (l limiter) iter(ctx context.Context, i func() Iterator) *Future {
+	return l.run(ctx, func(f *Future) error {
+		f.iterator = i()
+
+		return nil
+	})
+}
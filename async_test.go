@@ -0,0 +1,231 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestFuture(t *testing.T) {
+	var errA, errB = errors.New("a"), errors.New("b")
+	var a, b, c = newFuture(), newFuture(), newFuture()
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+	if err := a.Wait(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Actual error %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	cancel()
+
+	var all, first = All(a, b, c), First(a, b, c)
+
+	b.complete(errB)
+
+	if err := first.Wait(context.Background()); err != errB {
+		t.Errorf("Actual error %v, expected %v", err, errB)
+	}
+
+	select {
+	case <-all.Done():
+		t.Error("Actual done, expected not done")
+
+	default:
+	}
+
+	c.complete(nil)
+	a.complete(errA)
+
+	if err := all.Wait(context.Background()); err != errA {
+		t.Errorf("Actual error %v, expected %v", err, errA)
+	}
+
+	if a.Iterator() != nil {
+		t.Error("Actual iterator, expected nil")
+	}
+
+	for _, f := range []*Future{All(), First()} {
+		if err := f.Wait(context.Background()); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		}
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	var l = newLimiter(2)
+	var release = make(chan struct{})
+	var mu sync.Mutex
+	var running, max int
+	var fs []*Future
+
+	for i := 0; i < 5; i++ {
+		fs = append(fs, l.run(context.Background(), func(*Future) error {
+			mu.Lock()
+			running++
+
+			if running > max {
+				max = running
+			}
+
+			mu.Unlock()
+
+			<-release
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return nil
+		}))
+	}
+
+	time.Sleep(10 * time.Millisecond)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+	var waiting = l.run(ctx, func(*Future) error {
+		return nil
+	})
+
+	cancel()
+
+	if err := waiting.Wait(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+
+	close(release)
+
+	if err := All(fs...).Wait(context.Background()); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if a, e := max, 2; a != e {
+		t.Errorf("Actual concurrency %v, expected %v", a, e)
+	}
+}
+
+func TestSessionAsync(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	for _, q := range []string{ksDropIf, ksCreate, tabCreate} {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	defer s.Exec(ksDrop)
+
+	testAsync(t, s)
+}
+
+func TestMemorySessionAsync(t *testing.T) {
+	var s = newMemorySession(t)
+
+	defer s.Close()
+
+	testAsync(t, s)
+}
+
+func TestChainAsync(t *testing.T) {
+	var kinds []OperationKind
+	var mu sync.Mutex
+
+	var s = Chain(newMemorySession(t), func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			mu.Lock()
+			kinds = append(kinds, o.Kind)
+			mu.Unlock()
+
+			return next(ctx, o)
+		}
+	})
+
+	defer s.Close()
+
+	testAsync(t, s)
+
+	var count = map[OperationKind]int{}
+
+	for _, k := range kinds {
+		count[k]++
+	}
+
+	if a, e := count, (map[OperationKind]int{OperationExec: 4, OperationScanMap: 1, OperationIter: 1}); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual operations %v, expected %v", a, e)
+	}
+}
+
+func testAsync(t *testing.T, s Session) {
+	var ctx = context.Background()
+
+	var fs []*Future
+
+	for i := 0; i < 3; i++ {
+		fs = append(fs, s.ExecAsync("insert into gockle_test.test (id, n) values (?, ?)", i, i*2))
+	}
+
+	if err := All(fs...).Wait(ctx); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var m = map[string]interface{}{}
+
+	if err := s.ScanMapAsync("select * from gockle_test.test where id = ?", m, 2).Wait(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := (map[string]interface{}{"id": 2, "n": 4}); !reflect.DeepEqual(m, e) {
+		t.Errorf("Actual row %v, expected %v", m, e)
+	}
+
+	var f = s.Query("select * from gockle_test.test").IterAsync()
+
+	if err := f.Wait(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if rows, err := f.Iterator().SliceMap(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if a, e := len(rows), 3; a != e {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	if err := s.ExecAsync("insert into gockle_test.invalid (id, n) values (1, 1)").Wait(ctx); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("Actual error %v, expected %v", err, ErrTableNotFound)
+	}
+
+	var cctx, cancel = context.WithCancel(ctx)
+
+	cancel()
+
+	if err := s.ExecAsyncContext(cctx, rowInsert).Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+
+	if err := s.ScanMapAsyncContext(cctx, "select * from gockle_test.test where id = 1", m).Wait(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestChainLimiter(t *testing.T) {
+	var s = newMemorySession(t)
+
+	defer s.Close()
+
+	var l = limiterOf(s)
+
+	for _, c := range []Session{
+		Chain(s),
+		Chain(Chain(s)),
+		Chain(NewFaultSession(s, 0)),
+		NewMetrics().Wrap(s),
+	} {
+		if limiterOf(c) != l {
+			t.Error("Actual own limiter, expected that of the wrapped Session")
+		}
+	}
+
+	if a, e := cap(limiterOf(Chain(&SessionMock{}))), DefaultAsyncLimit; a != e {
+		t.Errorf("Actual limit %v, expected %v", a, e)
+	}
+}
@@ -1,7 +1,12 @@
 package gockle
 
 import (
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
 )
 
 // ColumnApplied is the name of a special column that has a bool that indicates
@@ -13,9 +18,48 @@ type Batch interface {
 	// Add adds the query for statement and arguments.
 	Add(statement string, arguments ...interface{})
 
-	// Exec executes the queries in the order they were added.
+	// AddWithTimestamp is like Add but gives the statement, an INSERT, UPDATE,
+	// or DELETE without a timestamp, its own timestamp in microseconds since
+	// the epoch with USING TIMESTAMP. If the statement is not one, the Exec
+	// methods return the error.
+	AddWithTimestamp(timestamp int64, statement string, arguments ...interface{})
+
+	// WithContext sets the context of Exec and ExecTx and returns the Batch.
+	WithContext(ctx context.Context) Batch
+
+	// Consistency sets the consistency level of the batch and returns the
+	// Batch. If no consistency level have been set, the default consistency
+	// level of the cluster is used.
+	Consistency(c gocql.Consistency) Batch
+
+	// SerialConsistency sets the consistency level for the serial phase of
+	// conditional batches and returns the Batch.
+	SerialConsistency(c gocql.SerialConsistency) Batch
+
+	// WithTimestamp sets the default timestamp of the statements in
+	// microseconds since the epoch and returns the Batch. Statements with
+	// their own timestamp keep it.
+	WithTimestamp(timestamp int64) Batch
+
+	// Size returns the number of statements.
+	Size() int
+
+	// Statements returns the statements in the order they were added. Those
+	// added with AddWithTimestamp have their USING TIMESTAMP clause.
+	Statements() []BatchEntry
+
+	// Reset removes the statements, and the errors of AddWithTimestamp, so
+	// that the Batch can be reused with the same settings.
+	Reset()
+
+	// Exec executes the queries in the order they were added. If the batch is
+	// conditional and not applied, the error matches ErrNotApplied with a
+	// context from ReportNotApplied.
 	Exec() error
 
+	// ExecContext is like Exec but uses ctx for the queries.
+	ExecContext(ctx context.Context) error
+
 	// ExecTx executes the queries in the order they were added. It returns a slice
 	// of maps from columns to values, the maps corresponding to all the conditional
 	// queries, and ordered in the same relative order. The special column
@@ -23,11 +67,14 @@ type Batch interface {
 	// applied. If a conditional statement was not applied, the current values for
 	// the columns are put into the map.
 	ExecTx() ([]map[string]interface{}, error)
+
+	// ExecTxContext is like ExecTx but uses ctx for the queries.
+	ExecTxContext(ctx context.Context) ([]map[string]interface{}, error)
 }
 
 var (
 	_ Batch = &BatchMock{}
-	_ Batch = batch{}
+	_ Batch = &batch{}
 )
 
 // BatchKind is the kind of Batch. The choice of kind mostly affects performance.
@@ -46,35 +93,230 @@ const (
 	BatchCounter BatchKind = 2
 )
 
+// BatchEntry is a statement in a Batch.
+type BatchEntry struct {
+	Statement string
+	Arguments []interface{}
+}
+
+// usingTimestamp returns statement, an INSERT, UPDATE, or DELETE, with a USING
+// TIMESTAMP clause for timestamp. The clause is spliced in where the lexer
+// finds its place, so the rest of statement is kept as it is.
+func usingTimestamp(statement string, timestamp int64) (string, error) {
+	var ts, err = cql.Lex(statement)
+
+	if err != nil {
+		return "", fmt.Errorf("gockle: cannot set the timestamp of %q: %v", statement, err)
+	}
+
+	// The clause goes before the keyword, or at the end.
+	var keyword string
+
+	switch {
+	case ts[0].Is("insert"):
+
+	case ts[0].Is("update"):
+		keyword = "set"
+
+	case ts[0].Is("delete"):
+		keyword = "where"
+
+	default:
+		return "", fmt.Errorf("gockle: cannot set the timestamp of %q, which is not an INSERT, UPDATE, or DELETE", statement)
+	}
+
+	var pos = -1
+	var using, values bool
+
+	for _, i := range cql.TopLevel(ts) {
+		var t = ts[i]
+
+		switch {
+		case keyword != "" && t.Is(keyword), keyword == "" && (t.Kind == cql.EOF || t.Is(";") && ts[i+1].Kind == cql.EOF):
+			pos = t.Pos
+
+		case t.Is("using"):
+			using = true
+
+		case using && t.Is("timestamp"):
+			return "", fmt.Errorf("gockle: cannot set the timestamp of %q, which has one", statement)
+
+		case t.Is("values") || t.Is("json"):
+			values = true
+		}
+
+		if pos >= 0 {
+			break
+		}
+	}
+
+	if pos < 0 || keyword == "" && !values {
+		return "", fmt.Errorf("gockle: cannot set the timestamp of %q, which is incomplete", statement)
+	}
+
+	var clause = fmt.Sprintf("USING TIMESTAMP %v", timestamp)
+
+	if using {
+		clause = fmt.Sprintf("AND TIMESTAMP %v", timestamp)
+	}
+
+	var rest = statement[pos:]
+
+	if rest != "" {
+		rest = " " + rest
+	}
+
+	return strings.TrimRight(statement[:pos], " \t\n") + " " + clause + rest, nil
+}
+
 type batch struct {
 	b *gocql.Batch
 
 	s *gocql.Session
+
+	// err is the first error of AddWithTimestamp.
+	err error
 }
 
-func (b batch) Add(statement string, arguments ...interface{}) {
+func (b *batch) Add(statement string, arguments ...interface{}) {
 	b.b.Query(statement, arguments...)
 }
 
-func (b batch) Exec() error {
-	return b.s.ExecuteBatch(b.b)
+func (b *batch) AddWithTimestamp(timestamp int64, statement string, arguments ...interface{}) {
+	var s, err = usingTimestamp(statement, timestamp)
+
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+
+		return
+	}
+
+	b.Add(s, arguments...)
+}
+
+func (b *batch) WithContext(ctx context.Context) Batch {
+	b.b = b.b.WithContext(ctx)
+
+	return b
+}
+
+func (b *batch) Consistency(c gocql.Consistency) Batch {
+	b.b.SetConsistency(c)
+
+	return b
+}
+
+func (b *batch) SerialConsistency(c gocql.SerialConsistency) Batch {
+	b.b.SerialConsistency(c)
+
+	return b
+}
+
+func (b *batch) WithTimestamp(timestamp int64) Batch {
+	b.b.WithTimestamp(timestamp)
+
+	return b
+}
+
+func (b *batch) Size() int {
+	return b.b.Size()
+}
+
+func (b *batch) Statements() []BatchEntry {
+	var es = make([]BatchEntry, len(b.b.Entries))
+
+	for i, e := range b.b.Entries {
+		es[i] = BatchEntry{Statement: e.Stmt, Arguments: e.Args}
+	}
+
+	return es
+}
+
+func (b *batch) Reset() {
+	b.b.Entries, b.err = nil, nil
+}
+
+func (b *batch) Exec() error {
+	return b.ExecContext(b.b.Context())
+}
+
+// statement returns the first statement of b.
+func (b *batch) statement() string {
+	if len(b.b.Entries) == 0 {
+		return ""
+	}
+
+	return b.b.Entries[0].Stmt
+}
+
+// error returns err as an *Error for b, and observes the coordinator for ctx.
+func (b *batch) error(ctx context.Context, err error, o *batchObserver) error {
+	observeCoordinator(ctx, o.coordinator)
+
+	return newError(err, b.statement(), o.coordinator)
 }
 
-func (b batch) ExecTx() ([]map[string]interface{}, error) {
+// ExecContext returns an error of kind ErrNotApplied for a conditional batch
+// that is not applied, with a context from ReportNotApplied.
+func (b *batch) ExecContext(ctx context.Context) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	var o = &batchObserver{}
+
+	if !reportNotApplied(ctx) {
+		return b.error(ctx, b.s.ExecuteBatch(b.b.WithContext(ctx).Observer(o)), o)
+	}
+
+	var applied, i, err = b.s.MapExecuteBatchCAS(b.b.WithContext(ctx).Observer(o), map[string]interface{}{})
+
+	// A batch without conditions has no rows.
+	if err == gocql.ErrNotFound {
+		return b.error(ctx, nil, o)
+	}
+
+	if err != nil {
+		return b.error(ctx, err, o)
+	}
+
+	if err := b.error(ctx, i.Close(), o); err != nil {
+		return err
+	}
+
+	if !applied {
+		return notApplied(b.statement(), o.coordinator)
+	}
+
+	return nil
+}
+
+func (b *batch) ExecTx() ([]map[string]interface{}, error) {
+	return b.ExecTxContext(b.b.Context())
+}
+
+func (b *batch) ExecTxContext(ctx context.Context) ([]map[string]interface{}, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
 	var m = map[string]interface{}{}
-	var a, i, err = b.s.MapExecuteBatchCAS(b.b, m)
+	var o = &batchObserver{}
+	var a, i, err = b.s.MapExecuteBatchCAS(b.b.WithContext(ctx).Observer(o), m)
 
 	if err != nil {
-		return nil, err
+		return nil, b.error(ctx, err, o)
 	}
 
 	s, err := i.SliceMap()
 
 	if err != nil {
-		return nil, err
+		return nil, b.error(ctx, err, o)
 	}
 
-	if err := i.Close(); err != nil {
+	if err := b.error(ctx, i.Close(), o); err != nil {
 		return nil, err
 	}
 
@@ -1,6 +1,9 @@
 package gockle
 
 import (
+	"context"
+
+	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/mock"
 )
 
@@ -17,6 +20,30 @@ func (_m *BatchMock) Add(statement string, arguments ...interface{}) {
 	_m.Called(_ca...)
 }
 
+// AddWithTimestamp provides a mock function with given fields: timestamp, statement, arguments
+func (_m *BatchMock) AddWithTimestamp(timestamp int64, statement string, arguments ...interface{}) {
+	var _ca []interface{}
+	_ca = append(_ca, timestamp, statement)
+	_ca = append(_ca, arguments...)
+	_m.Called(_ca...)
+}
+
+// Consistency provides a mock function with given fields: c
+func (_m *BatchMock) Consistency(c gocql.Consistency) Batch {
+	ret := _m.Called(c)
+
+	var r0 Batch
+	if rf, ok := ret.Get(0).(func(gocql.Consistency) Batch); ok {
+		r0 = rf(c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Batch)
+		}
+	}
+
+	return r0
+}
+
 // Exec provides a mock function with given fields:
 func (_m *BatchMock) Exec() error {
 	ret := _m.Called()
@@ -31,6 +58,20 @@ func (_m *BatchMock) Exec() error {
 	return r0
 }
 
+// ExecContext provides a mock function with given fields: ctx
+func (_m *BatchMock) ExecContext(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ExecTx provides a mock function with given fields:
 func (_m *BatchMock) ExecTx() ([]map[string]interface{}, error) {
 	ret := _m.Called()
@@ -53,3 +94,109 @@ func (_m *BatchMock) ExecTx() ([]map[string]interface{}, error) {
 
 	return r0, r1
 }
+
+// ExecTxContext provides a mock function with given fields: ctx
+func (_m *BatchMock) ExecTxContext(ctx context.Context) ([]map[string]interface{}, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []map[string]interface{}
+	if rf, ok := ret.Get(0).(func(context.Context) []map[string]interface{}); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]map[string]interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Reset provides a mock function with given fields:
+func (_m *BatchMock) Reset() {
+	_m.Called()
+}
+
+// SerialConsistency provides a mock function with given fields: c
+func (_m *BatchMock) SerialConsistency(c gocql.SerialConsistency) Batch {
+	ret := _m.Called(c)
+
+	var r0 Batch
+	if rf, ok := ret.Get(0).(func(gocql.SerialConsistency) Batch); ok {
+		r0 = rf(c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Batch)
+		}
+	}
+
+	return r0
+}
+
+// Size provides a mock function with given fields:
+func (_m *BatchMock) Size() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// Statements provides a mock function with given fields:
+func (_m *BatchMock) Statements() []BatchEntry {
+	ret := _m.Called()
+
+	var r0 []BatchEntry
+	if rf, ok := ret.Get(0).(func() []BatchEntry); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]BatchEntry)
+		}
+	}
+
+	return r0
+}
+
+// WithContext provides a mock function with given fields: ctx
+func (_m *BatchMock) WithContext(ctx context.Context) Batch {
+	ret := _m.Called(ctx)
+
+	var r0 Batch
+	if rf, ok := ret.Get(0).(func(context.Context) Batch); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Batch)
+		}
+	}
+
+	return r0
+}
+
+// WithTimestamp provides a mock function with given fields: timestamp
+func (_m *BatchMock) WithTimestamp(timestamp int64) Batch {
+	ret := _m.Called(timestamp)
+
+	var r0 Batch
+	if rf, ok := ret.Get(0).(func(int64) Batch); ok {
+		r0 = rf(timestamp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Batch)
+		}
+	}
+
+	return r0
+}
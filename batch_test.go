@@ -1,8 +1,12 @@
 package gockle
 
 import (
+	"context"
+	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/gocql/gocql"
 )
 
 func TestBatch(t *testing.T) {
@@ -55,3 +59,187 @@ func TestBatch(t *testing.T) {
 		t.Error("Actual no error, expected error")
 	}
 }
+
+func TestBatchContext(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	var exec = func(q string) {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	exec(ksDropIf)
+	exec(ksCreate)
+
+	defer exec(ksDrop)
+
+	exec(tabCreate)
+
+	defer exec(tabDrop)
+
+	exec(rowInsert)
+
+	var b = s.Batch(BatchLogged)
+
+	b.Add("update gockle_test.test set n = 3 where id = 1 if n = 2")
+
+	if a, err := b.ExecTxContext(context.Background()); err == nil {
+		if e := ([]map[string]interface{}{{"[applied]": true}}); !reflect.DeepEqual(a, e) {
+			t.Errorf("Actual tx %v, expected %v", a, e)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := b.ExecContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+
+	if _, err := b.ExecTxContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestUsingTimestamp(t *testing.T) {
+	for _, c := range []struct {
+		statement, expected string
+	}{
+		{"insert into t (a) values (1)", "insert into t (a) values (1) USING TIMESTAMP 5"},
+		{"insert into t (a) values (1) if not exists;", "insert into t (a) values (1) if not exists USING TIMESTAMP 5 ;"},
+		{"insert into t (a) values (1) using ttl 3", "insert into t (a) values (1) using ttl 3 AND TIMESTAMP 5"},
+		{"update k.t set a = 1 where b = 2", "update k.t USING TIMESTAMP 5 set a = 1 where b = 2"},
+		{"UPDATE t USING TTL 3 SET a = 'set' WHERE b = ?", "UPDATE t USING TTL 3 AND TIMESTAMP 5 SET a = 'set' WHERE b = ?"},
+		{"delete a from t where b = 2", "delete a from t USING TIMESTAMP 5 where b = 2"},
+		{"insert into t JSON ?", "insert into t JSON ? USING TIMESTAMP 5"},
+		{"delete m['k'] from t where id = ?", "delete m['k'] from t USING TIMESTAMP 5 where id = ?"},
+		{"update t set u.f = ? where id = ?", "update t USING TIMESTAMP 5 set u.f = ? where id = ?"},
+	} {
+		if a, err := usingTimestamp(c.statement, 5); err != nil {
+			t.Errorf("Actual error %v, expected no error for %q", err, c.statement)
+		} else if a != c.expected {
+			t.Errorf("Actual statement %q, expected %q", a, c.expected)
+		}
+	}
+
+	for _, s := range []string{"select * from t", "insert into t (a) values (1) using timestamp 3", "insert into"} {
+		if _, err := usingTimestamp(s, 5); err == nil {
+			t.Errorf("Actual no error, expected error for %q", s)
+		}
+	}
+}
+
+func TestBatchOptions(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	for _, q := range []string{ksDropIf, ksCreate, tabCreate} {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	defer s.Exec(ksDrop)
+
+	testBatchOptions(t, s)
+}
+
+func TestMemoryBatchOptions(t *testing.T) {
+	var s = newMemorySession(t)
+
+	defer s.Close()
+
+	testBatchOptions(t, s)
+}
+
+func TestChainBatchOptions(t *testing.T) {
+	var o Operation
+
+	var s = Chain(newMemorySession(t), func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			o = *op
+
+			return next(ctx, op)
+		}
+	})
+
+	defer s.Close()
+
+	testBatchOptions(t, s)
+
+	if !o.HasConsistency || o.Consistency != gocql.One {
+		t.Errorf("Actual consistency %v %v, expected true %v", o.HasConsistency, o.Consistency, gocql.One)
+	}
+
+	if !o.HasTimestamp || o.Timestamp != 1000 {
+		t.Errorf("Actual timestamp %v %v, expected true 1000", o.HasTimestamp, o.Timestamp)
+	}
+}
+
+func testBatchOptions(t *testing.T, s Session) {
+	var b = s.Batch(BatchLogged).
+		WithContext(context.Background()).
+		Consistency(gocql.One).
+		SerialConsistency(gocql.LocalSerial).
+		WithTimestamp(1000)
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 1, 2)
+	b.AddWithTimestamp(2000, "insert into gockle_test.test (id, n) values (?, ?)", 2, 3)
+
+	if a, e := b.Size(), 2; a != e {
+		t.Errorf("Actual size %v, expected %v", a, e)
+	}
+
+	var e = []BatchEntry{
+		{Statement: "insert into gockle_test.test (id, n) values (?, ?)", Arguments: []interface{}{1, 2}},
+		{Statement: "insert into gockle_test.test (id, n) values (?, ?) USING TIMESTAMP 2000", Arguments: []interface{}{2, 3}},
+	}
+
+	if a := b.Statements(); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual statements %v, expected %v", a, e)
+	}
+
+	if err := b.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if rows, err := s.ScanMapSlice("select * from gockle_test.test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if a, e := len(rows), 2; a != e {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	b.Reset()
+	b.AddWithTimestamp(3000, "select * from gockle_test.test")
+
+	if err := b.Exec(); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	b.Reset()
+
+	if a, e := b.Size(), 0; a != e {
+		t.Errorf("Actual size %v, expected %v", a, e)
+	}
+
+	b.Add("update gockle_test.test set n = 4 where id = 1")
+
+	if err := b.Exec(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := b.WithContext(ctx).Exec(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+}
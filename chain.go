@@ -0,0 +1,1036 @@
+package gockle
+
+import (
+	"context"
+	"iter"
+	"math/big"
+	"strconv"
+
+	"github.com/gocql/gocql"
+)
+
+// OperationKind is the kind of an Operation.
+type OperationKind int
+
+// Kinds of operations. The comments name the calls that perform them.
+const (
+	// OperationColumns is Session.Columns.
+	OperationColumns OperationKind = iota
+
+	// OperationTables is Session.Tables.
+	OperationTables
+
+	// OperationKeyspace is Session.Keyspace.
+	OperationKeyspace
+
+	// OperationTable is Session.Table.
+	OperationTable
+
+	// OperationExec is Session.Exec and Query.Exec.
+	OperationExec
+
+	// OperationScan is Session.Scan and Query.Scan.
+	OperationScan
+
+	// OperationScanMap is Session.ScanMap and Query.MapScan.
+	OperationScanMap
+
+	// OperationScanMapSlice is Session.ScanMapSlice.
+	OperationScanMapSlice
+
+	// OperationScanMapTx is Session.ScanMapTx.
+	OperationScanMapTx
+
+	// OperationScanStruct is Session.ScanStruct and Query.ScanStruct.
+	OperationScanStruct
+
+	// OperationScanStructSlice is Session.ScanStructSlice and
+	// Query.ScanStructSlice.
+	OperationScanStructSlice
+
+	// OperationIter is Session.ScanIterator and Query.Iter.
+	OperationIter
+
+	// OperationIterClose is Iterator.Close for an Iterator returned by an
+	// OperationIter.
+	OperationIterClose
+
+	// OperationBatchExec is Batch.Exec.
+	OperationBatchExec
+
+	// OperationBatchExecTx is Batch.ExecTx.
+	OperationBatchExecTx
+)
+
+var operationKindNames = map[OperationKind]string{
+	OperationColumns:         "Columns",
+	OperationTables:          "Tables",
+	OperationKeyspace:        "Keyspace",
+	OperationTable:           "Table",
+	OperationExec:            "Exec",
+	OperationScan:            "Scan",
+	OperationScanMap:         "ScanMap",
+	OperationScanMapSlice:    "ScanMapSlice",
+	OperationScanMapTx:       "ScanMapTx",
+	OperationScanStruct:      "ScanStruct",
+	OperationScanStructSlice: "ScanStructSlice",
+	OperationIter:            "Iter",
+	OperationIterClose:       "IterClose",
+	OperationBatchExec:       "BatchExec",
+	OperationBatchExecTx:     "BatchExecTx",
+}
+
+func (k OperationKind) String() string {
+	if n, ok := operationKindNames[k]; ok {
+		return n
+	}
+
+	return "OperationKind(" + strconv.Itoa(int(k)) + ")"
+}
+
+// Operation describes a call through a Session made by Chain. The same
+// Operation describes a call whether it was made through Session, Query,
+// Batch, or Iterator.
+//
+// Middleware may change Statement, Arguments, Keyspace, Table, BatchKind, and
+// Batch before calling the next Handler. After it returns, the result fields
+// for Kind are set. Middleware that does not call the next Handler must set
+// them itself.
+type Operation struct {
+	Kind OperationKind
+
+	// Statement and Arguments are the query. For OperationIterClose they are
+	// those of the OperationIter that returned the Iterator.
+	Statement string
+	Arguments []interface{}
+
+	// Keyspace and Table are for OperationColumns, OperationTables,
+	// OperationKeyspace, and OperationTable.
+	Keyspace string
+	Table    string
+
+	// BatchKind and Batch are for OperationBatchExec and OperationBatchExecTx.
+	BatchKind BatchKind
+	Batch     []BatchEntry
+
+	// Consistency, SerialConsistency, Timestamp, PageSize, and PageState are
+	// the settings made through Query or Batch. Consistency, SerialConsistency, and
+	// Timestamp are only meaningful if HasConsistency, HasSerialConsistency,
+	// and HasTimestamp are true. Changing them has no effect.
+	Consistency          gocql.Consistency
+	HasConsistency       bool
+	SerialConsistency    gocql.SerialConsistency
+	HasSerialConsistency bool
+	Timestamp            int64
+	HasTimestamp         bool
+	PageSize             int
+	PageState            []byte
+
+	// Idempotent is set by Query.Idempotent. Middleware may set it to let a
+	// RetryPolicy retry other Operations, like batches.
+	Idempotent bool
+
+	// Results are the destinations for OperationScan.
+	Results []interface{}
+
+	// Map is the destination for OperationScanMap and OperationScanMapTx.
+	Map map[string]interface{}
+
+	// Struct is the destination for OperationScanStruct and
+	// OperationScanStructSlice.
+	Struct interface{}
+
+	// Applied is the result of OperationScanMapTx.
+	Applied bool
+
+	// Rows is the result of OperationScanMapSlice and OperationBatchExecTx.
+	Rows []map[string]interface{}
+
+	// Iterator is the result of OperationIter.
+	Iterator Iterator
+
+	// Columns is the result of OperationColumns.
+	Columns map[string]gocql.TypeInfo
+
+	// Tables is the result of OperationTables.
+	Tables []string
+
+	// KeyspaceInfo is the result of OperationKeyspace.
+	KeyspaceInfo KeyspaceInfo
+
+	// TableInfo is the result of OperationTable.
+	TableInfo TableInfo
+
+	call func(ctx context.Context, o *Operation) error
+}
+
+// Handler performs an Operation.
+type Handler func(ctx context.Context, o *Operation) error
+
+// Middleware intercepts Operations. It returns a Handler that does its work and
+// calls next, or returns without calling next to short-circuit the Operation.
+// Of the methods of an Iterator, only Close is intercepted, as
+// OperationIterClose: Scan, ScanMap, StructScan, SliceMap, WillSwitchPage, and
+// PageState go to the Iterator of the OperationIter, which middleware may
+// wrap.
+type Middleware func(next Handler) Handler
+
+// changed returns whether middleware changed ctx from context.Background(),
+// which the calls that take no context give to the Handlers. Those calls then
+// use the Context methods, so that ctx has effect.
+func changed(ctx context.Context) bool {
+	return ctx != context.Background()
+}
+
+// Chain returns a Session that performs its calls through middleware and then
+// s. The first middleware is the outermost. Calls through the Query, Batch, and
+// Iterator values of the returned Session go through middleware as well.
+//
+// The context given to a Handler is the one given to the call, or
+// context.Background() for calls that take none. Changing it has effect for
+// the calls that run statements: those that take no context then use the
+// Context methods, and a Query without one from WithContext is given it.
+//
+// Asynchronous calls count against the limit of s if it is a Session of this
+// package, or else against a limit of DefaultAsyncLimit of their own.
+func Chain(s Session, middleware ...Middleware) Session {
+	var h = Handler(func(ctx context.Context, o *Operation) error {
+		return o.call(ctx, o)
+	})
+
+	for i := len(middleware) - 1; i >= 0; i-- {
+		h = middleware[i](h)
+	}
+
+	return chain{s: s, h: h, limit: limiterOf(s)}
+}
+
+var (
+	_ Batch    = &chainBatch{}
+	_ Iterator = chainIterator{}
+	_ Query    = chainQuery{}
+	_ Session  = chain{}
+)
+
+type chain struct {
+	s     Session
+	h     Handler
+	limit limiter
+}
+
+// do handles o and makes the handler's error the error of Iterator for
+// OperationIter.
+func (c chain) do(ctx context.Context, o *Operation) error {
+	var err = c.h(ctx, o)
+
+	if o.Kind == OperationIter {
+		if err != nil {
+			o.Iterator = errorIterator{err: err}
+		} else if o.Iterator != nil {
+			o.Iterator = chainIterator{c: c, o: *o, i: o.Iterator}
+		}
+	}
+
+	return err
+}
+
+func (c chain) asyncLimiter() limiter {
+	return c.limit
+}
+
+// AwaitSchemaAgreement calls s without middleware.
+func (c chain) AwaitSchemaAgreement(ctx context.Context) error {
+	return c.s.AwaitSchemaAgreement(ctx)
+}
+
+func (c chain) Batch(kind BatchKind) Batch {
+	return &chainBatch{c: c, kind: kind}
+}
+
+func (c chain) Close() {
+	c.s.Close()
+}
+
+func (c chain) Columns(keyspace, table string) (map[string]gocql.TypeInfo, error) {
+	var o = &Operation{Kind: OperationColumns, Keyspace: keyspace, Table: table, call: func(ctx context.Context, o *Operation) (err error) {
+		o.Columns, err = c.s.Columns(o.Keyspace, o.Table)
+
+		return err
+	}}
+	var err = c.do(context.Background(), o)
+
+	return o.Columns, err
+}
+
+func (c chain) ColumnsContext(ctx context.Context, keyspace, table string) (map[string]gocql.TypeInfo, error) {
+	var o = &Operation{Kind: OperationColumns, Keyspace: keyspace, Table: table, call: func(ctx context.Context, o *Operation) (err error) {
+		o.Columns, err = c.s.ColumnsContext(ctx, o.Keyspace, o.Table)
+
+		return err
+	}}
+	var err = c.do(ctx, o)
+
+	return o.Columns, err
+}
+
+func (c chain) Exec(statement string, arguments ...interface{}) error {
+	return c.do(context.Background(), &Operation{Kind: OperationExec, Statement: statement, Arguments: arguments, call: func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			return c.s.ExecContext(ctx, o.Statement, o.Arguments...)
+		}
+
+		return c.s.Exec(o.Statement, o.Arguments...)
+	}})
+}
+
+func (c chain) ExecContext(ctx context.Context, statement string, arguments ...interface{}) error {
+	return c.do(ctx, &Operation{Kind: OperationExec, Statement: statement, Arguments: arguments, call: func(ctx context.Context, o *Operation) error {
+		return c.s.ExecContext(ctx, o.Statement, o.Arguments...)
+	}})
+}
+
+func (c chain) ExecAsync(statement string, arguments ...interface{}) *Future {
+	return c.ExecAsyncContext(context.Background(), statement, arguments...)
+}
+
+// ExecAsyncContext runs ExecContext in the background, so middleware sees an
+// OperationExec.
+func (c chain) ExecAsyncContext(ctx context.Context, statement string, arguments ...interface{}) *Future {
+	return c.limit.run(ctx, func(*Future) error {
+		return c.ExecContext(ctx, statement, arguments...)
+	})
+}
+
+func (c chain) Scan(statement string, results []interface{}, arguments ...interface{}) error {
+	return c.do(context.Background(), &Operation{Kind: OperationScan, Statement: statement, Arguments: arguments, Results: results, call: func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			return c.s.ScanContext(ctx, o.Statement, o.Results, o.Arguments...)
+		}
+
+		return c.s.Scan(o.Statement, o.Results, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanContext(ctx context.Context, statement string, results []interface{}, arguments ...interface{}) error {
+	return c.do(ctx, &Operation{Kind: OperationScan, Statement: statement, Arguments: arguments, Results: results, call: func(ctx context.Context, o *Operation) error {
+		return c.s.ScanContext(ctx, o.Statement, o.Results, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanIterator(statement string, arguments ...interface{}) Iterator {
+	var o = &Operation{Kind: OperationIter, Statement: statement, Arguments: arguments, call: func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			o.Iterator = c.s.ScanIteratorContext(ctx, o.Statement, o.Arguments...)
+		} else {
+			o.Iterator = c.s.ScanIterator(o.Statement, o.Arguments...)
+		}
+
+		return nil
+	}}
+
+	c.do(context.Background(), o)
+
+	return o.Iterator
+}
+
+func (c chain) ScanIteratorContext(ctx context.Context, statement string, arguments ...interface{}) Iterator {
+	var o = &Operation{Kind: OperationIter, Statement: statement, Arguments: arguments, call: func(ctx context.Context, o *Operation) error {
+		o.Iterator = c.s.ScanIteratorContext(ctx, o.Statement, o.Arguments...)
+
+		return nil
+	}}
+
+	c.do(ctx, o)
+
+	return o.Iterator
+}
+
+func (c chain) ScanMap(statement string, results map[string]interface{}, arguments ...interface{}) error {
+	return c.do(context.Background(), &Operation{Kind: OperationScanMap, Statement: statement, Arguments: arguments, Map: results, call: func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			return c.s.ScanMapContext(ctx, o.Statement, o.Map, o.Arguments...)
+		}
+
+		return c.s.ScanMap(o.Statement, o.Map, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanMapContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) error {
+	return c.do(ctx, &Operation{Kind: OperationScanMap, Statement: statement, Arguments: arguments, Map: results, call: func(ctx context.Context, o *Operation) error {
+		return c.s.ScanMapContext(ctx, o.Statement, o.Map, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanMapAsync(statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	return c.ScanMapAsyncContext(context.Background(), statement, results, arguments...)
+}
+
+// ScanMapAsyncContext runs ScanMapContext in the background, so middleware
+// sees an OperationScanMap.
+func (c chain) ScanMapAsyncContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	return c.limit.run(ctx, func(*Future) error {
+		return c.ScanMapContext(ctx, statement, results, arguments...)
+	})
+}
+
+func (c chain) ScanMapSlice(statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
+	var o = &Operation{Kind: OperationScanMapSlice, Statement: statement, Arguments: arguments, call: func(ctx context.Context, o *Operation) (err error) {
+		if changed(ctx) {
+			o.Rows, err = c.s.ScanMapSliceContext(ctx, o.Statement, o.Arguments...)
+		} else {
+			o.Rows, err = c.s.ScanMapSlice(o.Statement, o.Arguments...)
+		}
+
+		return err
+	}}
+	var err = c.do(context.Background(), o)
+
+	return o.Rows, err
+}
+
+func (c chain) ScanMapSliceContext(ctx context.Context, statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
+	var o = &Operation{Kind: OperationScanMapSlice, Statement: statement, Arguments: arguments, call: func(ctx context.Context, o *Operation) (err error) {
+		o.Rows, err = c.s.ScanMapSliceContext(ctx, o.Statement, o.Arguments...)
+
+		return err
+	}}
+	var err = c.do(ctx, o)
+
+	return o.Rows, err
+}
+
+func (c chain) ScanMapTx(statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
+	var o = &Operation{Kind: OperationScanMapTx, Statement: statement, Arguments: arguments, Map: results, call: func(ctx context.Context, o *Operation) (err error) {
+		if changed(ctx) {
+			o.Applied, err = c.s.ScanMapTxContext(ctx, o.Statement, o.Map, o.Arguments...)
+		} else {
+			o.Applied, err = c.s.ScanMapTx(o.Statement, o.Map, o.Arguments...)
+		}
+
+		return err
+	}}
+	var err = c.do(context.Background(), o)
+
+	return o.Applied, err
+}
+
+func (c chain) ScanMapTxContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
+	var o = &Operation{Kind: OperationScanMapTx, Statement: statement, Arguments: arguments, Map: results, call: func(ctx context.Context, o *Operation) (err error) {
+		o.Applied, err = c.s.ScanMapTxContext(ctx, o.Statement, o.Map, o.Arguments...)
+
+		return err
+	}}
+	var err = c.do(ctx, o)
+
+	return o.Applied, err
+}
+
+func (c chain) ScanStruct(statement string, dest interface{}, arguments ...interface{}) error {
+	return c.do(context.Background(), &Operation{Kind: OperationScanStruct, Statement: statement, Arguments: arguments, Struct: dest, call: func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			return c.s.ScanStructContext(ctx, o.Statement, o.Struct, o.Arguments...)
+		}
+
+		return c.s.ScanStruct(o.Statement, o.Struct, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanStructContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	return c.do(ctx, &Operation{Kind: OperationScanStruct, Statement: statement, Arguments: arguments, Struct: dest, call: func(ctx context.Context, o *Operation) error {
+		return c.s.ScanStructContext(ctx, o.Statement, o.Struct, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanStructSlice(statement string, dest interface{}, arguments ...interface{}) error {
+	return c.do(context.Background(), &Operation{Kind: OperationScanStructSlice, Statement: statement, Arguments: arguments, Struct: dest, call: func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			return c.s.ScanStructSliceContext(ctx, o.Statement, o.Struct, o.Arguments...)
+		}
+
+		return c.s.ScanStructSlice(o.Statement, o.Struct, o.Arguments...)
+	}})
+}
+
+func (c chain) ScanStructSliceContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	return c.do(ctx, &Operation{Kind: OperationScanStructSlice, Statement: statement, Arguments: arguments, Struct: dest, call: func(ctx context.Context, o *Operation) error {
+		return c.s.ScanStructSliceContext(ctx, o.Statement, o.Struct, o.Arguments...)
+	}})
+}
+
+func (c chain) Tables(keyspace string) ([]string, error) {
+	var o = &Operation{Kind: OperationTables, Keyspace: keyspace, call: func(ctx context.Context, o *Operation) (err error) {
+		o.Tables, err = c.s.Tables(o.Keyspace)
+
+		return err
+	}}
+	var err = c.do(context.Background(), o)
+
+	return o.Tables, err
+}
+
+func (c chain) TablesContext(ctx context.Context, keyspace string) ([]string, error) {
+	var o = &Operation{Kind: OperationTables, Keyspace: keyspace, call: func(ctx context.Context, o *Operation) (err error) {
+		o.Tables, err = c.s.TablesContext(ctx, o.Keyspace)
+
+		return err
+	}}
+	var err = c.do(ctx, o)
+
+	return o.Tables, err
+}
+
+func (c chain) Keyspace(keyspace string) (KeyspaceInfo, error) {
+	var o = &Operation{Kind: OperationKeyspace, Keyspace: keyspace, call: func(ctx context.Context, o *Operation) (err error) {
+		o.KeyspaceInfo, err = c.s.Keyspace(o.Keyspace)
+
+		return err
+	}}
+	var err = c.do(context.Background(), o)
+
+	return o.KeyspaceInfo, err
+}
+
+func (c chain) KeyspaceContext(ctx context.Context, keyspace string) (KeyspaceInfo, error) {
+	var o = &Operation{Kind: OperationKeyspace, Keyspace: keyspace, call: func(ctx context.Context, o *Operation) (err error) {
+		o.KeyspaceInfo, err = c.s.KeyspaceContext(ctx, o.Keyspace)
+
+		return err
+	}}
+	var err = c.do(ctx, o)
+
+	return o.KeyspaceInfo, err
+}
+
+func (c chain) Table(keyspace, table string) (TableInfo, error) {
+	var o = &Operation{Kind: OperationTable, Keyspace: keyspace, Table: table, call: func(ctx context.Context, o *Operation) (err error) {
+		o.TableInfo, err = c.s.Table(o.Keyspace, o.Table)
+
+		return err
+	}}
+	var err = c.do(context.Background(), o)
+
+	return o.TableInfo, err
+}
+
+func (c chain) TableContext(ctx context.Context, keyspace, table string) (TableInfo, error) {
+	var o = &Operation{Kind: OperationTable, Keyspace: keyspace, Table: table, call: func(ctx context.Context, o *Operation) (err error) {
+		o.TableInfo, err = c.s.TableContext(ctx, o.Keyspace, o.Table)
+
+		return err
+	}}
+	var err = c.do(ctx, o)
+
+	return o.TableInfo, err
+}
+
+// RefreshSchema calls s without middleware.
+func (c chain) RefreshSchema(keyspace string) error {
+	return c.s.RefreshSchema(keyspace)
+}
+
+// RefreshSchemaContext calls s without middleware.
+func (c chain) RefreshSchemaContext(ctx context.Context, keyspace string) error {
+	return c.s.RefreshSchemaContext(ctx, keyspace)
+}
+
+// SchemaChanges calls s without middleware.
+func (c chain) SchemaChanges() <-chan SchemaChange {
+	return c.s.SchemaChanges()
+}
+
+// Token calls s without middleware.
+func (c chain) Token(keyspace, table string, keyValues ...interface{}) (*big.Int, error) {
+	return c.s.Token(keyspace, table, keyValues...)
+}
+
+// TokenContext calls s without middleware.
+func (c chain) TokenContext(ctx context.Context, keyspace, table string, keyValues ...interface{}) (*big.Int, error) {
+	return c.s.TokenContext(ctx, keyspace, table, keyValues...)
+}
+
+func (c chain) Query(statement string, arguments ...interface{}) Query {
+	return chainQuery{c: c, statement: statement, arguments: arguments}
+}
+
+// chainQuery records the settings made through Query and makes the Query of
+// the wrapped Session when it is run.
+type chainQuery struct {
+	c chain
+
+	statement string
+	arguments []interface{}
+	options   []func(Query) Query
+	ctx       context.Context
+
+	consistency          gocql.Consistency
+	hasConsistency       bool
+	serialConsistency    gocql.SerialConsistency
+	hasSerialConsistency bool
+	timestamp            int64
+	hasTimestamp         bool
+	idempotent           bool
+	pageSize             int
+	pageState            []byte
+}
+
+func (q chainQuery) with(option func(Query) Query) chainQuery {
+	q.options = append(q.options[:len(q.options):len(q.options)], option)
+
+	return q
+}
+
+func (q chainQuery) Consistency(c gocql.Consistency) Query {
+	q = q.with(func(q Query) Query {
+		return q.Consistency(c)
+	})
+
+	q.consistency, q.hasConsistency = c, true
+
+	return q
+}
+
+func (q chainQuery) PageSize(n int) Query {
+	q = q.with(func(q Query) Query {
+		return q.PageSize(n)
+	})
+
+	q.pageSize = n
+
+	return q
+}
+
+func (q chainQuery) WithContext(ctx context.Context) Query {
+	q.ctx = ctx
+
+	return q
+}
+
+func (q chainQuery) PageState(state []byte) Query {
+	q = q.with(func(q Query) Query {
+		return q.PageState(state)
+	})
+
+	q.pageState = state
+
+	return q
+}
+
+func (q chainQuery) Idempotent(value bool) Query {
+	q = q.with(func(q Query) Query {
+		return q.Idempotent(value)
+	})
+
+	q.idempotent = value
+
+	return q
+}
+
+func (q chainQuery) SerialConsistency(c gocql.SerialConsistency) Query {
+	q = q.with(func(q Query) Query {
+		return q.SerialConsistency(c)
+	})
+
+	q.serialConsistency, q.hasSerialConsistency = c, true
+
+	return q
+}
+
+func (q chainQuery) WithTimestamp(timestamp int64) Query {
+	q = q.with(func(q Query) Query {
+		return q.WithTimestamp(timestamp)
+	})
+
+	q.timestamp, q.hasTimestamp = timestamp, true
+
+	return q
+}
+
+func (q chainQuery) DefaultTimestamp(enable bool) Query {
+	return q.with(func(q Query) Query {
+		return q.DefaultTimestamp(enable)
+	})
+}
+
+func (q chainQuery) RetryPolicy(r gocql.RetryPolicy) Query {
+	return q.with(func(q Query) Query {
+		return q.RetryPolicy(r)
+	})
+}
+
+func (q chainQuery) Trace(t gocql.Tracer) Query {
+	return q.with(func(q Query) Query {
+		return q.Trace(t)
+	})
+}
+
+func (q chainQuery) Observer(o gocql.QueryObserver) Query {
+	return q.with(func(q Query) Query {
+		return q.Observer(o)
+	})
+}
+
+func (q chainQuery) NoSkipMetadata() Query {
+	return q.with(func(q Query) Query {
+		return q.NoSkipMetadata()
+	})
+}
+
+func (q chainQuery) RoutingKey(key []byte) Query {
+	return q.with(func(q Query) Query {
+		return q.RoutingKey(key)
+	})
+}
+
+// Bind replaces the arguments, which middleware sees as those of the Operation.
+func (q chainQuery) Bind(values ...interface{}) Query {
+	q.arguments = values
+
+	return q
+}
+
+func (q chainQuery) do(kind OperationKind, o *Operation, call func(Query, *Operation) error) error {
+	var ctx = q.ctx
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	o.Kind, o.Statement, o.Arguments = kind, q.statement, q.arguments
+	o.Consistency, o.HasConsistency, o.PageSize, o.PageState = q.consistency, q.hasConsistency, q.pageSize, q.pageState
+	o.SerialConsistency, o.HasSerialConsistency, o.Timestamp, o.HasTimestamp = q.serialConsistency, q.hasSerialConsistency, q.timestamp, q.hasTimestamp
+	o.Idempotent = q.idempotent
+	o.call = func(ctx context.Context, o *Operation) error {
+		var qq = q.c.s.Query(o.Statement, o.Arguments...)
+
+		for _, option := range q.options {
+			qq = option(qq)
+		}
+
+		if q.ctx != nil || changed(ctx) {
+			qq = qq.WithContext(ctx)
+		}
+
+		return call(qq, o)
+	}
+
+	return q.c.do(ctx, o)
+}
+
+func (q chainQuery) Exec() error {
+	return q.do(OperationExec, &Operation{}, func(qq Query, o *Operation) error {
+		return qq.Exec()
+	})
+}
+
+func (q chainQuery) Iter() Iterator {
+	var o = &Operation{}
+
+	q.do(OperationIter, o, func(qq Query, o *Operation) error {
+		o.Iterator = qq.Iter()
+
+		return nil
+	})
+
+	return o.Iterator
+}
+
+// IterAsync runs Iter in the background, so middleware sees an OperationIter.
+func (q chainQuery) IterAsync() *Future {
+	var ctx = q.ctx
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	return q.c.limit.iter(ctx, q.Iter)
+}
+
+func (q chainQuery) MapScan(m map[string]interface{}) error {
+	return q.do(OperationScanMap, &Operation{Map: m}, func(qq Query, o *Operation) error {
+		return qq.MapScan(o.Map)
+	})
+}
+
+func (q chainQuery) Scan(dest ...interface{}) error {
+	return q.do(OperationScan, &Operation{Results: dest}, func(qq Query, o *Operation) error {
+		return qq.Scan(o.Results...)
+	})
+}
+
+func (q chainQuery) ScanStruct(dest interface{}) error {
+	return q.do(OperationScanStruct, &Operation{Struct: dest}, func(qq Query, o *Operation) error {
+		return qq.ScanStruct(o.Struct)
+	})
+}
+
+func (q chainQuery) ScanStructSlice(dest interface{}) error {
+	return q.do(OperationScanStructSlice, &Operation{Struct: dest}, func(qq Query, o *Operation) error {
+		return qq.ScanStructSlice(o.Struct)
+	})
+}
+
+// Release does nothing. The Query of the wrapped Session is made and released
+// for each run.
+func (q chainQuery) Release() {}
+
+// chainBatch records the statements and settings made through Batch and makes
+// the Batch of the wrapped Session when it is run.
+type chainBatch struct {
+	c chain
+
+	kind    BatchKind
+	entries []BatchEntry
+	ctx     context.Context
+	err     error
+
+	consistency          gocql.Consistency
+	hasConsistency       bool
+	serialConsistency    gocql.SerialConsistency
+	hasSerialConsistency bool
+	timestamp            int64
+	hasTimestamp         bool
+}
+
+func (b *chainBatch) Add(statement string, arguments ...interface{}) {
+	b.entries = append(b.entries, BatchEntry{Statement: statement, Arguments: arguments})
+}
+
+func (b *chainBatch) AddWithTimestamp(timestamp int64, statement string, arguments ...interface{}) {
+	var s, err = usingTimestamp(statement, timestamp)
+
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+
+		return
+	}
+
+	b.Add(s, arguments...)
+}
+
+func (b *chainBatch) WithContext(ctx context.Context) Batch {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *chainBatch) Consistency(c gocql.Consistency) Batch {
+	b.consistency, b.hasConsistency = c, true
+
+	return b
+}
+
+func (b *chainBatch) SerialConsistency(c gocql.SerialConsistency) Batch {
+	b.serialConsistency, b.hasSerialConsistency = c, true
+
+	return b
+}
+
+func (b *chainBatch) WithTimestamp(timestamp int64) Batch {
+	b.timestamp, b.hasTimestamp = timestamp, true
+
+	return b
+}
+
+func (b *chainBatch) Size() int {
+	return len(b.entries)
+}
+
+func (b *chainBatch) Statements() []BatchEntry {
+	return append([]BatchEntry(nil), b.entries...)
+}
+
+func (b *chainBatch) Reset() {
+	b.entries, b.err = nil, nil
+}
+
+// batch makes the Batch of the wrapped Session for o.
+func (b *chainBatch) batch(o *Operation) Batch {
+	var bb = b.c.s.Batch(o.BatchKind)
+
+	for _, e := range o.Batch {
+		bb.Add(e.Statement, e.Arguments...)
+	}
+
+	if b.hasConsistency {
+		bb = bb.Consistency(b.consistency)
+	}
+
+	if b.hasSerialConsistency {
+		bb = bb.SerialConsistency(b.serialConsistency)
+	}
+
+	if b.hasTimestamp {
+		bb = bb.WithTimestamp(b.timestamp)
+	}
+
+	return bb
+}
+
+func (b *chainBatch) operation(kind OperationKind, call func(ctx context.Context, o *Operation) error) *Operation {
+	return &Operation{
+		Kind:                 kind,
+		BatchKind:            b.kind,
+		Batch:                append([]BatchEntry(nil), b.entries...),
+		Consistency:          b.consistency,
+		HasConsistency:       b.hasConsistency,
+		SerialConsistency:    b.serialConsistency,
+		HasSerialConsistency: b.hasSerialConsistency,
+		Timestamp:            b.timestamp,
+		HasTimestamp:         b.hasTimestamp,
+		call:                 call,
+	}
+}
+
+// Exec runs Exec of the wrapped Batch, or ExecContext if WithContext was
+// called.
+func (b *chainBatch) Exec() error {
+	if b.ctx != nil {
+		return b.ExecContext(b.ctx)
+	}
+
+	if b.err != nil {
+		return b.err
+	}
+
+	return b.c.do(context.Background(), b.operation(OperationBatchExec, func(ctx context.Context, o *Operation) error {
+		if changed(ctx) {
+			return b.batch(o).ExecContext(ctx)
+		}
+
+		return b.batch(o).Exec()
+	}))
+}
+
+func (b *chainBatch) ExecContext(ctx context.Context) error {
+	if b.err != nil {
+		return b.err
+	}
+
+	return b.c.do(ctx, b.operation(OperationBatchExec, func(ctx context.Context, o *Operation) error {
+		return b.batch(o).ExecContext(ctx)
+	}))
+}
+
+// ExecTx is like Exec for ExecTx.
+func (b *chainBatch) ExecTx() ([]map[string]interface{}, error) {
+	if b.ctx != nil {
+		return b.ExecTxContext(b.ctx)
+	}
+
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var o = b.operation(OperationBatchExecTx, func(ctx context.Context, o *Operation) (err error) {
+		if changed(ctx) {
+			o.Rows, err = b.batch(o).ExecTxContext(ctx)
+		} else {
+			o.Rows, err = b.batch(o).ExecTx()
+		}
+
+		return err
+	})
+	var err = b.c.do(context.Background(), o)
+
+	return o.Rows, err
+}
+
+func (b *chainBatch) ExecTxContext(ctx context.Context) ([]map[string]interface{}, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	var o = b.operation(OperationBatchExecTx, func(ctx context.Context, o *Operation) (err error) {
+		o.Rows, err = b.batch(o).ExecTxContext(ctx)
+
+		return err
+	})
+	var err = b.c.do(ctx, o)
+
+	return o.Rows, err
+}
+
+// chainIterator performs Close as an OperationIterClose like o.
+type chainIterator struct {
+	c chain
+	o Operation
+	i Iterator
+}
+
+func (i chainIterator) All() iter.Seq2[map[string]interface{}, error] {
+	return all(i)
+}
+
+func (i chainIterator) Close() error {
+	var o = i.o
+
+	o.Kind, o.Iterator = OperationIterClose, i.i
+	o.call = func(ctx context.Context, o *Operation) error {
+		return i.i.Close()
+	}
+
+	return i.c.do(context.Background(), &o)
+}
+
+func (i chainIterator) Scan(results ...interface{}) bool {
+	return i.i.Scan(results...)
+}
+
+func (i chainIterator) ScanMap(results map[string]interface{}) bool {
+	return i.i.ScanMap(results)
+}
+
+func (i chainIterator) StructScan(dest interface{}) bool {
+	return i.i.StructScan(dest)
+}
+
+func (i chainIterator) WillSwitchPage() bool {
+	return i.i.WillSwitchPage()
+}
+
+func (i chainIterator) PageState() []byte {
+	return i.i.PageState()
+}
+
+func (i chainIterator) SliceMap() ([]map[string]interface{}, error) {
+	return i.i.SliceMap()
+}
+
+// errorIterator is an Iterator without rows whose Close returns err.
+type errorIterator struct {
+	err error
+}
+
+func (i errorIterator) All() iter.Seq2[map[string]interface{}, error] {
+	return all(i)
+}
+
+func (i errorIterator) Close() error {
+	return i.err
+}
+
+func (i errorIterator) Scan(results ...interface{}) bool {
+	return false
+}
+
+func (i errorIterator) ScanMap(results map[string]interface{}) bool {
+	return false
+}
+
+func (i errorIterator) StructScan(dest interface{}) bool {
+	return false
+}
+
+func (i errorIterator) WillSwitchPage() bool {
+	return false
+}
+
+func (i errorIterator) PageState() []byte {
+	return nil
+}
+
+func (i errorIterator) SliceMap() ([]map[string]interface{}, error) {
+	return nil, i.err
+}
@@ -0,0 +1,207 @@
+package gockle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestChain(t *testing.T) {
+	var log []string
+
+	var record = func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, o *Operation) error {
+				log = append(log, fmt.Sprintf("%v %v %v", name, o.Kind, o.Statement))
+
+				var err = next(ctx, o)
+
+				log = append(log, fmt.Sprintf("%v %v %v", name, o.Kind, err))
+
+				return err
+			}
+		}
+	}
+
+	var s = Chain(newMemorySession(t, rowInsert), record("a"), record("b"))
+
+	defer s.Close()
+
+	var i = s.Query("select * from gockle_test.test").PageSize(1).Iter()
+	var id, n int
+
+	if !i.Scan(&id, &n) {
+		t.Error("Actual more false, expected true")
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var e = []string{
+		"a Iter select * from gockle_test.test",
+		"b Iter select * from gockle_test.test",
+		"b Iter <nil>",
+		"a Iter <nil>",
+		"a IterClose select * from gockle_test.test",
+		"b IterClose select * from gockle_test.test",
+		"b IterClose <nil>",
+		"a IterClose <nil>",
+	}
+
+	if !reflect.DeepEqual(log, e) {
+		t.Errorf("Actual log %v, expected %v", log, e)
+	}
+
+	log = nil
+
+	var b = s.Batch(BatchLogged)
+
+	b.Add("update gockle_test.test set n = 3 where id = 1 if n = 2")
+
+	if a, err := b.ExecTx(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := ([]map[string]interface{}{{"[applied]": true}}); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual tx %v, expected %v", a, e)
+	}
+
+	if a, e := log[0], "a BatchExecTx "; a != e {
+		t.Errorf("Actual log %v, expected %v", a, e)
+	}
+
+	if a, err := s.Tables("gockle_test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []string{"test"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual tables %v, expected %v", a, e)
+	}
+}
+
+func TestChainShortCircuit(t *testing.T) {
+	var rows = []map[string]interface{}{{"id": 1}}
+	var cached = func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			switch o.Kind {
+			case OperationScanMapSlice:
+				o.Rows = rows
+
+				return nil
+
+			case OperationIter:
+				return fmt.Errorf("iter")
+			}
+
+			return next(ctx, o)
+		}
+	}
+
+	var m = &SessionMock{}
+	var s = Chain(m, cached)
+
+	if a, err := s.ScanMapSlice("select * from t"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if !reflect.DeepEqual(a, rows) {
+		t.Errorf("Actual rows %v, expected %v", a, rows)
+	}
+
+	if err := s.ScanIterator("select * from t").Close(); err == nil || err.Error() != "iter" {
+		t.Errorf("Actual error %v, expected iter", err)
+	}
+
+	m.AssertExpectations(t)
+}
+
+func TestChainMutate(t *testing.T) {
+	var rewrite = func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			o.Statement += " where id = ?"
+			o.Arguments = append(o.Arguments, 1)
+
+			for i := range o.Batch {
+				o.Batch[i].Arguments = []interface{}{2}
+			}
+
+			return next(ctx, o)
+		}
+	}
+
+	var q, b, m = &QueryMock{}, &BatchMock{}, &SessionMock{}
+
+	m.On("Exec", "delete from t where id = ?", 1).Return(nil)
+	m.On("Query", "select * from t where id = ?", 1).Return(q)
+	q.On("Consistency", gocql.One).Return(q)
+	q.On("MapScan", mock.Anything).Return(nil)
+	m.On("Batch", BatchUnlogged).Return(b)
+	b.On("Add", "delete from t", 2).Return()
+	b.On("ExecContext", context.Background()).Return(nil)
+
+	var s = Chain(m, rewrite)
+
+	if err := s.Exec("delete from t"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Query("select * from t").Consistency(gocql.One).MapScan(map[string]interface{}{}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var bb = s.Batch(BatchUnlogged)
+
+	bb.Add("delete from t", 3)
+
+	if err := bb.ExecContext(context.Background()); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	m.AssertExpectations(t)
+	q.AssertExpectations(t)
+	b.AssertExpectations(t)
+}
+
+func TestChainQueryOptions(t *testing.T) {
+	var o Operation
+
+	var s = Chain(newMemorySession(t), func(next Handler) Handler {
+		return func(ctx context.Context, op *Operation) error {
+			o = *op
+
+			return next(ctx, op)
+		}
+	})
+
+	defer s.Close()
+
+	var q = s.Query("insert into gockle_test.test (id, n) values (?, ?)", 1, 2).
+		SerialConsistency(gocql.Serial).
+		WithTimestamp(1000).
+		Bind(3, 4)
+
+	if err := q.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if !o.HasSerialConsistency || o.SerialConsistency != gocql.Serial {
+		t.Errorf("Actual serial consistency %v %v, expected true %v", o.HasSerialConsistency, o.SerialConsistency, gocql.Serial)
+	}
+
+	if !o.HasTimestamp || o.Timestamp != 1000 {
+		t.Errorf("Actual timestamp %v %v, expected true 1000", o.HasTimestamp, o.Timestamp)
+	}
+
+	if a, e := o.Arguments, []interface{}{3, 4}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual arguments %v, expected %v", a, e)
+	}
+}
+
+func TestOperationKindString(t *testing.T) {
+	if a, e := OperationBatchExecTx.String(), "BatchExecTx"; a != e {
+		t.Errorf("Actual string %v, expected %v", a, e)
+	}
+
+	if a, e := OperationKind(100).String(), "OperationKind(100)"; a != e {
+		t.Errorf("Actual string %v, expected %v", a, e)
+	}
+}
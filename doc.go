@@ -2,12 +2,18 @@
 // simple interfaces to insert, query, and mutate Cassandra data, as well as get
 // basic keyspace and table metadata.
 //
-// The entry points are NewSession and NewSimpleSession. Call them to get a
-// Session. Session interacts with the database. It executes queries and batched
-// queries and iterates result rows. Closing the Session closes the underlying
-// gocql.Session, including the one passed to NewSimpleSession.
+// The entry points are Open, NewSession, and NewSimpleSession. Call them to get
+// a Session. Open configures the connection with Options. Session interacts
+// with the database. It executes queries and batched queries and iterates
+// result rows. Closing the Session closes the underlying gocql.Session,
+// including the one passed to NewSession.
+//
+// Chain wraps a Session with Middleware, which intercepts every operation made
+// through the Session and its Batch, Iterator, and Query values.
 //
 // Mocks are provided for testing use of Batch, Iterator, and Session.
+// NewMemorySession returns a Session backed by an in-process store for tests
+// that run real statements without a Cassandra cluster.
 //
 // Tx is short for transaction.
 //
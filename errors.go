@@ -0,0 +1,335 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// Errors that the errors of Sessions, Queries, Batches, and Iterators match with
+// errors.Is. Errors from Cassandra and the driver are *Error, and match the
+// underlying gocql errors too. ErrNotFound and context errors are returned as
+// they are.
+var (
+	// ErrNotFound is gocql.ErrNotFound, returned when a query for one row
+	// selects none.
+	ErrNotFound = gocql.ErrNotFound
+
+	// ErrKeyspaceNotFound is for a keyspace that does not exist.
+	ErrKeyspaceNotFound = errors.New("gockle: keyspace not found")
+
+	// ErrTableNotFound is for a table that does not exist.
+	ErrTableNotFound = errors.New("gockle: table not found")
+
+	// ErrTimeout is for read and write timeouts of the coordinator and
+	// timeouts of the driver. The statement may have been applied.
+	ErrTimeout = errors.New("gockle: timeout")
+
+	// ErrUnavailable is for too few live replicas to meet the consistency
+	// level. The statement was not applied.
+	ErrUnavailable = errors.New("gockle: unavailable")
+
+	// ErrOverloaded is for an overloaded or bootstrapping coordinator. The
+	// statement was not applied.
+	ErrOverloaded = errors.New("gockle: overloaded")
+
+	// ErrSyntax is for a statement that does not parse.
+	ErrSyntax = errors.New("gockle: syntax error")
+
+	// ErrInvalid is for a statement that parses but is invalid, other than
+	// for a missing keyspace or table.
+	ErrInvalid = errors.New("gockle: invalid")
+
+	// ErrUnauthorized is for bad credentials or missing permissions.
+	ErrUnauthorized = errors.New("gockle: unauthorized")
+
+	// ErrNotApplied is for a conditional statement or batch that is not
+	// applied, run with Exec and a context from ReportNotApplied. Otherwise
+	// Exec ignores it, and ScanMapTx and ExecTx report it in their results.
+	ErrNotApplied = errors.New("gockle: not applied")
+)
+
+// Error is an error for a statement.
+type Error struct {
+	// Kind is the error of this file that Error matches, or nil.
+	Kind error
+
+	// Statement is the statement, or the first statement of a batch. It is
+	// empty for metadata.
+	Statement string
+
+	// Coordinator is the host and port of the node that coordinated the
+	// statement, or empty if there was none or it is unknown.
+	Coordinator string
+
+	// Err is the underlying error.
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Is returns whether target is e.Kind.
+func (e *Error) Is(target error) bool {
+	return e.Kind != nil && target == e.Kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// errorKind returns the Kind for err, or nil.
+func errorKind(err error) error {
+	var r gocql.RequestError
+
+	switch {
+	case errors.Is(err, gocql.ErrTimeoutNoResponse):
+		return ErrTimeout
+
+	case !errors.As(err, &r):
+		return nil
+	}
+
+	switch r.Code() {
+	case gocql.ErrCodeReadTimeout, gocql.ErrCodeWriteTimeout:
+		return ErrTimeout
+
+	case gocql.ErrCodeUnavailable:
+		return ErrUnavailable
+
+	case gocql.ErrCodeOverloaded, gocql.ErrCodeBootstrapping:
+		return ErrOverloaded
+
+	case gocql.ErrCodeSyntax:
+		return ErrSyntax
+
+	case gocql.ErrCodeUnauthorized, gocql.ErrCodeCredentials:
+		return ErrUnauthorized
+
+	case gocql.ErrCodeInvalid:
+		var m = strings.ToLower(r.Message())
+
+		switch {
+		case strings.HasPrefix(m, "keyspace ") && strings.HasSuffix(m, " does not exist"):
+			return ErrKeyspaceNotFound
+
+		case strings.HasPrefix(m, "unconfigured table "):
+			return ErrTableNotFound
+		}
+
+		return ErrInvalid
+	}
+
+	return nil
+}
+
+// newError returns err as an *Error for statement and coordinator if it is from
+// Cassandra or the driver, and err otherwise.
+func newError(err error, statement, coordinator string) error {
+	var e *Error
+	var r gocql.RequestError
+
+	switch {
+	case err == nil, errors.As(err, &e), errors.Is(err, ErrNotFound), errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+		return err
+	}
+
+	var kind = errorKind(err)
+
+	if kind == nil && !errors.As(err, &r) {
+		return err
+	}
+
+	return &Error{Kind: kind, Statement: statement, Coordinator: coordinator, Err: err}
+}
+
+type reportNotAppliedKey struct{}
+
+// ReportNotApplied returns a copy of ctx with which Exec, of a Session, Query,
+// or Batch, fails with ErrNotApplied for a conditional statement or batch that
+// is not applied.
+func ReportNotApplied(ctx context.Context) context.Context {
+	return context.WithValue(ctx, reportNotAppliedKey{}, true)
+}
+
+// reportNotApplied returns whether ctx is from ReportNotApplied.
+func reportNotApplied(ctx context.Context) bool {
+	var report, _ = ctx.Value(reportNotAppliedKey{}).(bool)
+
+	return report
+}
+
+// operationConditional returns whether the statement of o, or any statement of
+// its batch, is conditional.
+func operationConditional(o *Operation) bool {
+	var c = conditional(o.Statement)
+
+	for _, e := range o.Batch {
+		c = c || conditional(e.Statement)
+	}
+
+	return c
+}
+
+// conditionalCacheSize is the most statements conditional remembers.
+const conditionalCacheSize = 4096
+
+// conditionals maps statements to whether they are conditional, and
+// conditionalCount counts them.
+var (
+	conditionals     sync.Map
+	conditionalCount atomic.Int64
+)
+
+// conditional returns whether statement, an INSERT, UPDATE, or DELETE, has an
+// IF clause, which is an IF outside parentheses, brackets, and braces. It
+// remembers the answer for the first conditionalCacheSize statements.
+func conditional(statement string) bool {
+	if c, ok := conditionals.Load(statement); ok {
+		return c.(bool)
+	}
+
+	var c = lexConditional(statement)
+
+	if conditionalCount.Load() < conditionalCacheSize {
+		if _, loaded := conditionals.LoadOrStore(statement, c); !loaded {
+			conditionalCount.Add(1)
+		}
+	}
+
+	return c
+}
+
+func lexConditional(statement string) bool {
+	var ts, err = cql.Lex(statement)
+
+	if err != nil || !ts[0].Is("insert") && !ts[0].Is("update") && !ts[0].Is("delete") {
+		return false
+	}
+
+	for _, i := range cql.TopLevel(ts) {
+		if ts[i].Is("if") {
+			return true
+		}
+	}
+
+	return false
+}
+
+func notApplied(statement, coordinator string) error {
+	return &Error{Kind: ErrNotApplied, Statement: statement, Coordinator: coordinator, Err: ErrNotApplied}
+}
+
+func keyspaceNotFound(keyspace string) error {
+	return &Error{Kind: ErrKeyspaceNotFound, Err: fmt.Errorf("gockle: keyspace %v invalid", keyspace)}
+}
+
+func tableNotFound(keyspace, table string) error {
+	return &Error{Kind: ErrTableNotFound, Err: fmt.Errorf("gockle: table %v.%v invalid", keyspace, table)}
+}
+
+// wrappedError is an error with message that wraps err.
+type wrappedError struct {
+	message string
+	err     error
+}
+
+func (e wrappedError) Error() string {
+	return e.message
+}
+
+func (e wrappedError) Unwrap() error {
+	return e.err
+}
+
+// errorType returns a short name for the kind of err, like "timeout", for
+// tracing and metrics.
+func errorType(err error) string {
+	var e *Error
+
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return "not_found"
+
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+
+	case errors.Is(err, context.DeadlineExceeded):
+		return "deadline_exceeded"
+
+	case !errors.As(err, &e):
+		return "other"
+
+	case e.Kind == nil:
+		return "cassandra"
+	}
+
+	return strings.ReplaceAll(strings.TrimPrefix(e.Kind.Error(), "gockle: "), " ", "_")
+}
+
+// errorCoordinator returns the coordinator of err, or empty if it has none.
+func errorCoordinator(err error) string {
+	var e *Error
+
+	if errors.As(err, &e) {
+		return e.Coordinator
+	}
+
+	return ""
+}
+
+// coordinator returns the host and port of h, or empty if h is nil.
+func coordinator(h *gocql.HostInfo) string {
+	if h == nil {
+		return ""
+	}
+
+	return h.ConnectAddressAndPort()
+}
+
+type coordinatorKey struct{}
+
+// coordinatorSink gets the coordinator of the queries and batches made with a
+// context from withCoordinatorSink, failed or not.
+type coordinatorSink struct {
+	mu          sync.Mutex
+	coordinator string
+}
+
+func withCoordinatorSink(ctx context.Context) (context.Context, *coordinatorSink) {
+	var s = &coordinatorSink{}
+
+	return context.WithValue(ctx, coordinatorKey{}, s), s
+}
+
+func (s *coordinatorSink) get() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.coordinator
+}
+
+// observeCoordinator gives coordinator, if known, to the sink of ctx, if any.
+func observeCoordinator(ctx context.Context, coordinator string) {
+	if s, ok := ctx.Value(coordinatorKey{}).(*coordinatorSink); ok && coordinator != "" {
+		s.mu.Lock()
+		s.coordinator = coordinator
+		s.mu.Unlock()
+	}
+}
+
+// batchObserver records the coordinator of a batch.
+type batchObserver struct {
+	coordinator string
+}
+
+func (o *batchObserver) ObserveBatch(ctx context.Context, b gocql.ObservedBatch) {
+	o.coordinator = coordinator(b.Host)
+}
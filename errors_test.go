@@ -0,0 +1,176 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/memdb"
+)
+
+func TestErrorKind(t *testing.T) {
+	for _, c := range []struct {
+		err  error
+		kind error
+	}{
+		{errors.New("other"), nil},
+		{gocql.ErrTimeoutNoResponse, ErrTimeout},
+		{requestError(gocql.ErrCodeReadTimeout), ErrTimeout},
+		{requestError(gocql.ErrCodeWriteTimeout), ErrTimeout},
+		{requestError(gocql.ErrCodeUnavailable), ErrUnavailable},
+		{requestError(gocql.ErrCodeOverloaded), ErrOverloaded},
+		{requestError(gocql.ErrCodeSyntax), ErrSyntax},
+		{requestError(gocql.ErrCodeUnauthorized), ErrUnauthorized},
+		{requestError(gocql.ErrCodeAlreadyExists), nil},
+		{memdb.Errorf(gocql.ErrCodeInvalid, "Keyspace k does not exist"), ErrKeyspaceNotFound},
+		{memdb.Errorf(gocql.ErrCodeInvalid, "unconfigured table t"), ErrTableNotFound},
+		{memdb.Errorf(gocql.ErrCodeInvalid, "invalid"), ErrInvalid},
+	} {
+		if a, e := errorKind(c.err), c.kind; a != e {
+			t.Errorf("Actual kind %v for %v, expected %v", a, c.err, e)
+		}
+	}
+
+	for _, err := range []error{nil, ErrNotFound, errors.New("other")} {
+		if a := newError(err, "s", "c"); a != err {
+			t.Errorf("Actual error %#v, expected %#v", a, err)
+		}
+	}
+
+	var err = newError(requestError(gocql.ErrCodeAlreadyExists), "s", "c")
+
+	if e := (&Error{Statement: "s", Coordinator: "c", Err: requestError(gocql.ErrCodeAlreadyExists)}); *err.(*Error) != *e {
+		t.Errorf("Actual error %#v, expected %#v", err, e)
+	}
+
+	if errors.Is(err, ErrInvalid) {
+		t.Error("Actual match, expected no match")
+	}
+}
+
+func TestSessionErrors(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	var exec = func(q string) {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	exec(ksDropIf)
+	exec(ksCreate)
+
+	defer exec(ksDrop)
+
+	exec(tabCreate)
+	exec(rowInsert)
+	testErrors(t, s, true)
+}
+
+func TestMemorySessionErrors(t *testing.T) {
+	testErrors(t, newMemorySession(t, rowInsert), false)
+}
+
+func testErrors(t *testing.T, s Session, coordinator bool) {
+	var check = func(err, kind error, statement string) {
+		t.Helper()
+
+		if !errors.Is(err, kind) {
+			t.Errorf("Actual error %v, expected %v", err, kind)
+		}
+
+		var e *Error
+
+		if !errors.As(err, &e) {
+			t.Fatalf("Actual error %T, expected *Error", err)
+		}
+
+		if a := e.Statement; a != statement {
+			t.Errorf("Actual statement %q, expected %q", a, statement)
+		}
+
+		if a := e.Coordinator != ""; a != coordinator && statement != "" {
+			t.Errorf("Actual coordinator %q, expected one: %v", e.Coordinator, coordinator)
+		}
+	}
+
+	const (
+		syntax     = "select from"
+		table      = "select * from gockle_test.missing"
+		keyspace   = "select * from gockle_missing.test"
+		notApplied = "update gockle_test.test set n = 3 where id = 1 if n = 9"
+	)
+
+	check(s.Exec(syntax), ErrSyntax, syntax)
+	check(s.Query(syntax).Scan(new(int)), ErrSyntax, syntax)
+	check(s.ScanIterator(syntax).Close(), ErrSyntax, syntax)
+	check(s.Exec(table), ErrTableNotFound, table)
+	check(s.ScanMap(keyspace, map[string]interface{}{}), ErrKeyspaceNotFound, keyspace)
+	var report = ReportNotApplied(context.Background())
+
+	check(s.ExecContext(report, notApplied), ErrNotApplied, notApplied)
+	check(s.Query(notApplied).WithContext(report).Exec(), ErrNotApplied, notApplied)
+
+	if err := s.Exec(notApplied); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var b = s.Batch(BatchLogged)
+
+	b.Add(notApplied)
+	check(b.ExecContext(report), ErrNotApplied, notApplied)
+
+	if err := b.Exec(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	const insert = "insert into gockle_test.missing (id) values (1)"
+
+	b = s.Batch(BatchLogged)
+	b.Add(insert)
+
+	var _, err = b.ExecTx()
+
+	check(err, ErrTableNotFound, insert)
+
+	_, err = s.Table("gockle_test", "missing")
+	check(err, ErrTableNotFound, "")
+
+	_, err = s.Keyspace("gockle_missing")
+	check(err, ErrKeyspaceNotFound, "")
+
+	if err := s.Exec("update gockle_test.test set n = 3 where id = 1 if n = 2"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.ScanMap("select * from gockle_test.test where id = 9", map[string]interface{}{}); err != ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, ErrNotFound)
+	}
+}
+
+func TestConditional(t *testing.T) {
+	for _, c := range []struct {
+		statement string
+		expected  bool
+	}{
+		{"insert into t (id) values (?) if not exists", true},
+		{"insert into t json ? if not exists", true},
+		{"update t set n = ? where id = ? if exists", true},
+		{"update t set n = ? where id = ? if m['k'] = ?", true},
+		{"delete m['k'] from t where id = ? if exists", true},
+		{"insert into t (id, \"if\") values (?, 'if')", false},
+		{"update t set n = ? where id in (?, ?)", false},
+		{"create table if not exists t (id int primary key)", false},
+		{"delete from t where id = 'unterminated", false},
+	} {
+		// The second answer is remembered.
+		for i := 0; i < 2; i++ {
+			if a := conditional(c.statement); a != c.expected {
+				t.Errorf("Actual conditional %v for %q, expected %v", a, c.statement, c.expected)
+			}
+		}
+	}
+}
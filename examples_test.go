@@ -45,6 +45,28 @@ func ExampleIterator_ScanMap() {
 	// Output: id = 1, name = alex
 }
 
+func ExampleIterator_All() {
+	var session = NewMemorySession()
+
+	session.Exec("create keyspace app with replication = {'class': 'SimpleStrategy', 'replication_factor': 1}")
+	session.Exec("create table app.users (id int primary key, name text)")
+	session.Exec("insert into app.users (id, name) values (1, 'alex')")
+
+	for row, err := range session.ScanIterator("select * from app.users").All() {
+		if err != nil {
+			fmt.Println(err)
+
+			break
+		}
+
+		fmt.Printf("id = %v, name = %v\n", row["id"], row["name"])
+	}
+
+	session.Close()
+
+	// Output: id = 1, name = alex
+}
+
 func ExampleSession_Batch() {
 	var batchMock = &BatchMock{}
 
@@ -0,0 +1,708 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+	"github.com/stretchr/testify/mock"
+)
+
+// ErrUnexpected is for a call to an ExpectSession that matches no Expectation.
+var ErrUnexpected = errors.New("gockle: unexpected call")
+
+// ExpectSession is a Session for tests whose statements are answered by
+// Expectations, which match statements regardless of case, whitespace, and
+// comments, and arguments by position or by the names of bind markers. A call
+// that matches none fails with ErrUnexpected and reports to the test the
+// closest Expectations with a word diff of their statements, like
+// "select * from users where [-id-]{+name+} = ?", and their arguments. The
+// first Expectation that matches answers a call. The calls without statements,
+// like Columns and Token, go to a SessionMock. Its methods are safe for
+// concurrent use.
+type ExpectSession struct {
+	Session
+
+	t mock.TestingT
+	m *SessionMock
+
+	mu           sync.Mutex
+	expectations []*Expectation
+}
+
+// NewExpectSession returns a new ExpectSession that reports to t.
+func NewExpectSession(t mock.TestingT) *ExpectSession {
+	var s = &ExpectSession{t: t, m: &SessionMock{}}
+
+	s.Session = Chain(s.m, s.match)
+
+	return s
+}
+
+// Mock returns the SessionMock for the calls without statements.
+func (s *ExpectSession) Mock() *SessionMock {
+	return s.m
+}
+
+func (s *ExpectSession) asyncLimiter() limiter {
+	return limiterOf(s.Session)
+}
+
+// Close does nothing.
+func (s *ExpectSession) Close() {}
+
+// Expect returns a new Expectation of an Operation of kind, like OperationIter
+// for ScanIterator, whose statement equals statement once both are
+// normalized: unquoted identifiers and keywords are lowercase, bind markers
+// are ?, and whitespace, comments, and a final semicolon are dropped. A
+// statement that does not lex is compared as it is. A batch matches if any of
+// its statements does.
+func (s *ExpectSession) Expect(kind OperationKind, statement string) *Expectation {
+	return s.add(&Expectation{kind: kind, statement: statement, want: normalize(statement), names: markerNames(statement)})
+}
+
+// ExpectRegexp is like Expect for statements that, normalized, match pattern.
+func (s *ExpectSession) ExpectRegexp(kind OperationKind, pattern string) *Expectation {
+	return s.add(&Expectation{kind: kind, statement: pattern, pattern: regexp.MustCompile(pattern)})
+}
+
+// ExpectFingerprint is like Expect for statements with the Fingerprint of
+// statement, whatever their literals.
+func (s *ExpectSession) ExpectFingerprint(kind OperationKind, statement string) *Expectation {
+	return s.add(&Expectation{kind: kind, statement: statement, want: fingerprint(statement), fingerprint: true, names: markerNames(statement)})
+}
+
+func (s *ExpectSession) add(e *Expectation) *Expectation {
+	s.mu.Lock()
+	s.expectations = append(s.expectations, e)
+	s.mu.Unlock()
+
+	return e
+}
+
+// AssertExpectations reports to t the Expectations that were not called, or
+// not as many times as they expect, and returns whether all were.
+func (s *ExpectSession) AssertExpectations(t mock.TestingT) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var ok = true
+
+	for _, e := range s.expectations {
+		if e.calls == 0 || e.times > 0 && e.calls < e.times {
+			t.Errorf("gockle: expected %v, called %v times", e, e.calls)
+			ok = false
+		}
+	}
+
+	return ok
+}
+
+func (s *ExpectSession) match(next Handler) Handler {
+	return func(ctx context.Context, o *Operation) error {
+		if o.Kind == OperationIterClose || o.Statement == "" && len(o.Batch) == 0 {
+			return next(ctx, o)
+		}
+
+		s.mu.Lock()
+
+		for _, e := range s.expectations {
+			if e.matches(o) == "" {
+				e.calls++
+				s.mu.Unlock()
+
+				return e.answer(o)
+			}
+		}
+
+		var err = s.unexpected(o)
+
+		s.mu.Unlock()
+
+		return err
+	}
+}
+
+// unexpected reports o to the test with the closest Expectations, and returns
+// an ErrUnexpected. s.mu is locked.
+func (s *ExpectSession) unexpected(o *Operation) error {
+	type candidate struct {
+		e     *Expectation
+		diff  string
+		score int
+	}
+
+	var cs []candidate
+
+	for _, e := range s.expectations {
+		var diff, score = e.diff(o)
+
+		cs = append(cs, candidate{e: e, diff: diff, score: score})
+	}
+
+	sort.SliceStable(cs, func(i, j int) bool {
+		return cs[i].score > cs[j].score
+	})
+
+	var b strings.Builder
+	var err = fmt.Errorf("%w: %v %v %v", ErrUnexpected, o.Kind, normalize(operationStatement(o)), operationArguments(o))
+
+	b.WriteString(err.Error())
+
+	if len(cs) == 0 {
+		b.WriteString("\nno expectations")
+	}
+
+	for i, c := range cs {
+		if i == 3 {
+			fmt.Fprintf(&b, "\n... and %v more expectations", len(cs)-i)
+
+			break
+		}
+
+		fmt.Fprintf(&b, "\n%v\n\t%v", c.e, c.diff)
+	}
+
+	s.t.Errorf("%s", b.String())
+
+	return err
+}
+
+// operationArguments returns the arguments of o, or of the first statement of
+// its batch.
+func operationArguments(o *Operation) []interface{} {
+	if len(o.Batch) > 0 {
+		return o.Batch[0].Arguments
+	}
+
+	return o.Arguments
+}
+
+// Expectation is a statement that an ExpectSession expects, with its answer.
+// Its methods return it to chain them.
+type Expectation struct {
+	kind        OperationKind
+	statement   string
+	want        string
+	pattern     *regexp.Regexp
+	fingerprint bool
+	names       []string
+
+	arguments    []interface{}
+	hasArguments bool
+	named        map[string]interface{}
+
+	columns []string
+	rows    [][]interface{}
+	applied *bool
+	err     error
+
+	times int
+	calls int
+}
+
+func (e *Expectation) String() string {
+	var s = fmt.Sprintf("%v %q", e.kind, e.statement)
+
+	switch {
+	case e.pattern != nil:
+		s = fmt.Sprintf("%v matching %q", e.kind, e.statement)
+
+	case e.fingerprint:
+		s = fmt.Sprintf("%v like %q", e.kind, e.want)
+	}
+
+	if e.hasArguments {
+		s += fmt.Sprintf(" with arguments %v", e.arguments)
+	}
+
+	if len(e.named) > 0 {
+		s += fmt.Sprintf(" with named arguments %v", e.named)
+	}
+
+	return s
+}
+
+// Args expects the arguments to be values, in order. A value may be a
+// testify matcher, like mock.Anything or mock.MatchedBy(f).
+func (e *Expectation) Args(values ...interface{}) *Expectation {
+	e.arguments, e.hasArguments = values, true
+
+	return e
+}
+
+// Named expects the argument of the bind marker :name, or of the bind marker
+// at the position of :name in the expected statement, to be value, which may
+// be a testify matcher.
+func (e *Expectation) Named(name string, value interface{}) *Expectation {
+	if e.named == nil {
+		e.named = map[string]interface{}{}
+	}
+
+	e.named[strings.ToLower(name)] = value
+
+	return e
+}
+
+// Rows answers with rows of columns, whose values are scanned as they are, or
+// converted between number types. Scan, ScanMap, and ScanStruct get the first
+// row, and fail with ErrNotFound if there is none; ScanMapSlice,
+// ScanStructSlice, and ExecTx get all of them; and the Iterator is an
+// IteratorMock that returns them in one page.
+func (e *Expectation) Rows(columns []string, rows ...[]interface{}) *Expectation {
+	e.columns, e.rows = columns, rows
+
+	return e
+}
+
+// Applied answers ScanMapTx and ExecTx with whether the conditional statement
+// or batch was applied. By default it was.
+func (e *Expectation) Applied(applied bool) *Expectation {
+	e.applied = &applied
+
+	return e
+}
+
+// ReturnError answers with err. The Iterator returns it from Close.
+func (e *Expectation) ReturnError(err error) *Expectation {
+	e.err = err
+
+	return e
+}
+
+// Times limits the Expectation to n calls and expects as many. Without it, the
+// Expectation answers any number of calls and expects one.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+
+	return e
+}
+
+// Once is Times(1).
+func (e *Expectation) Once() *Expectation {
+	return e.Times(1)
+}
+
+// matches returns why e does not match o, or empty if it does.
+func (e *Expectation) matches(o *Operation) string {
+	if e.kind != o.Kind {
+		return fmt.Sprintf("kind %v, not %v", o.Kind, e.kind)
+	}
+
+	var why = "statement differs"
+
+	if len(o.Batch) == 0 {
+		why = e.matchStatement(o.Statement, o.Arguments)
+	}
+
+	for _, b := range o.Batch {
+		if why = e.matchStatement(b.Statement, b.Arguments); why == "" {
+			break
+		}
+	}
+
+	if why == "" && e.times > 0 && e.calls >= e.times {
+		why = fmt.Sprintf("called %v times already", e.calls)
+	}
+
+	return why
+}
+
+// matchStatement returns why e does not match statement and arguments, or
+// empty if it does.
+func (e *Expectation) matchStatement(statement string, arguments []interface{}) string {
+	switch {
+	case e.pattern != nil:
+		if !e.pattern.MatchString(normalize(statement)) {
+			return "statement differs"
+		}
+
+	case e.fingerprint:
+		if fingerprint(statement) != e.want {
+			return "statement differs"
+		}
+
+	default:
+		if normalize(statement) != e.want {
+			return "statement differs"
+		}
+	}
+
+	if e.hasArguments {
+		if len(e.arguments) != len(arguments) {
+			return fmt.Sprintf("arguments %v, expected %v", arguments, e.arguments)
+		}
+
+		if _, n := mock.Arguments(e.arguments).Diff(arguments); n > 0 {
+			return fmt.Sprintf("arguments %v, expected %v", arguments, e.arguments)
+		}
+	}
+
+	var names = markerNames(statement)
+
+	for name, v := range e.named {
+		var i = indexOf(names, name)
+
+		if i < 0 {
+			i = indexOf(e.names, name)
+		}
+
+		if i < 0 || i >= len(arguments) {
+			return fmt.Sprintf("no argument named %v", name)
+		}
+
+		if _, n := (mock.Arguments{v}).Diff([]interface{}{arguments[i]}); n > 0 {
+			return fmt.Sprintf("argument %v is %v, expected %v", name, arguments[i], v)
+		}
+	}
+
+	return ""
+}
+
+// diff returns how o differs from e, and a score of how close it is: higher
+// for the same kind, and for more words in common.
+func (e *Expectation) diff(o *Operation) (string, int) {
+	var why = e.matches(o)
+	var statement = operationStatement(o)
+
+	if e.kind == o.Kind && why != "statement differs" {
+		return why, 1 << 20
+	}
+
+	var diff, score = fmt.Sprintf("%v does not match", normalize(statement)), 0
+
+	switch {
+	case e.pattern != nil:
+
+	case e.fingerprint:
+		diff, score = wordDiff(e.want, fingerprint(statement))
+
+	default:
+		diff, score = wordDiff(e.want, normalize(statement))
+	}
+
+	if e.kind != o.Kind {
+		return why + ": " + diff, score
+	}
+
+	return diff, score + 1<<10
+}
+
+// answer sets the results of o and returns the error of e.
+func (e *Expectation) answer(o *Operation) error {
+	var i = e.iterator()
+
+	switch o.Kind {
+	case OperationScan:
+		if !i.Scan(o.Results...) {
+			return closeNotFound(i, e.err)
+		}
+
+	case OperationScanMap:
+		if !i.ScanMap(o.Map) {
+			return closeNotFound(i, e.err)
+		}
+
+	case OperationScanStruct:
+		return scanStruct(i, o.Struct)
+
+	case OperationScanStructSlice:
+		return scanStructSlice(i, o.Struct)
+
+	case OperationScanMapSlice:
+		var err error
+
+		o.Rows, err = i.SliceMap()
+
+		return err
+
+	case OperationScanMapTx:
+		o.Applied = e.applied == nil || *e.applied
+		i.ScanMap(o.Map)
+
+	case OperationBatchExecTx:
+		o.Rows, _ = i.SliceMap()
+
+		if e.applied != nil && len(o.Rows) == 0 {
+			o.Rows = []map[string]interface{}{{ColumnApplied: *e.applied}}
+		}
+
+	case OperationIter:
+		o.Iterator = i
+
+		return nil
+	}
+
+	return i.Close()
+}
+
+// closeNotFound closes i and returns err, or ErrNotFound if there is none.
+func closeNotFound(i Iterator, err error) error {
+	if err := i.Close(); err != nil {
+		return err
+	}
+
+	return ErrNotFound
+}
+
+// iterator returns an IteratorMock for the rows of e that fails with the error
+// of e.
+func (e *Expectation) iterator() *expectIterator {
+	var i = &IteratorMock{}
+	var row = -1
+	var err = e.err
+
+	var next = func() bool {
+		if err != nil && err != e.err || row >= len(e.rows) {
+			return false
+		}
+
+		row++
+
+		return row < len(e.rows)
+	}
+
+	var columns = make([]gocql.ColumnInfo, len(e.columns))
+
+	for n, c := range e.columns {
+		columns[n] = gocql.ColumnInfo{Name: c}
+	}
+
+	var scan = func(dest ...interface{}) bool {
+		if !next() {
+			return false
+		}
+
+		if len(dest) != len(e.columns) {
+			err = fmt.Errorf("gocql: not enough columns to scan into: have %d want %d", len(dest), len(e.columns))
+
+			return false
+		}
+
+		for n, d := range dest {
+			if n < len(e.rows[row]) {
+				if err = assign(d, e.rows[row][n]); err != nil {
+					return false
+				}
+			}
+		}
+
+		return true
+	}
+
+	var scanMap = func(m map[string]interface{}) bool {
+		if !next() {
+			return false
+		}
+
+		for n, c := range e.columns {
+			if n < len(e.rows[row]) {
+				m[c] = e.rows[row][n]
+			}
+		}
+
+		return true
+	}
+
+	var arguments = make([]interface{}, len(e.columns))
+
+	for a := range arguments {
+		arguments[a] = mock.Anything
+	}
+
+	i.On("Scan", arguments...).Return(scan)
+
+	i.On("ScanMap", mock.Anything).Return(scanMap)
+	i.On("StructScan", mock.Anything).Return(func(dest interface{}) bool {
+		var more, serr = structScan(columns, dest, func(fields []interface{}) bool {
+			return scan(fields...)
+		})
+
+		if serr != nil {
+			err = serr
+		}
+
+		return more
+	})
+	i.On("SliceMap").Return(func() []map[string]interface{} {
+		var ms []map[string]interface{}
+
+		for {
+			var m = map[string]interface{}{}
+
+			if !scanMap(m) {
+				return ms
+			}
+
+			ms = append(ms, m)
+		}
+	}, func() error {
+		return err
+	})
+	i.On("WillSwitchPage").Return(false)
+	i.On("PageState").Return(nil)
+	i.On("All").Return(func() iter.Seq2[map[string]interface{}, error] {
+		return all(i)
+	})
+	i.On("Close").Return(func() error {
+		return err
+	})
+
+	return &expectIterator{IteratorMock: i, columns: len(e.columns), scan: scan}
+}
+
+// expectIterator is the IteratorMock of an Expectation. Scan into other than
+// as many results as there are columns fails, as in gocql, without the mock.
+type expectIterator struct {
+	*IteratorMock
+
+	columns int
+	scan    func(...interface{}) bool
+}
+
+func (i *expectIterator) Scan(results ...interface{}) bool {
+	if len(results) != i.columns {
+		return i.scan(results...)
+	}
+
+	return i.IteratorMock.Scan(results...)
+}
+
+// assign puts v in the value that dest points to, converting numbers. A nil
+// dest skips v, and a nil v is the zero value.
+func assign(dest, v interface{}) error {
+	if dest == nil {
+		return nil
+	}
+
+	var d = reflect.ValueOf(dest)
+
+	if d.Kind() != reflect.Pointer || d.IsNil() {
+		return fmt.Errorf("gockle: cannot scan into %T", dest)
+	}
+
+	var e = d.Elem()
+
+	if v == nil {
+		e.SetZero()
+
+		return nil
+	}
+
+	var rv = reflect.ValueOf(v)
+
+	switch {
+	case rv.Type().AssignableTo(e.Type()):
+		e.Set(rv)
+
+	case rv.Type().ConvertibleTo(e.Type()) && (rv.Kind() == e.Kind() || number(rv.Kind()) && number(e.Kind())):
+		e.Set(rv.Convert(e.Type()))
+
+	default:
+		return fmt.Errorf("gockle: cannot scan %T into %T", v, dest)
+	}
+
+	return nil
+}
+
+// number returns whether k is a kind of integer or floating point number.
+func number(k reflect.Kind) bool {
+	return k >= reflect.Int && k <= reflect.Float64
+}
+
+// normalize returns statement normalized by cql.Normalize with its literals,
+// or statement as it is if it does not lex.
+func normalize(statement string) string {
+	var n, err = cql.Normalize(statement, true)
+
+	if err != nil {
+		return statement
+	}
+
+	return n
+}
+
+// fingerprint returns the Fingerprint of statement, or statement as it is if
+// it does not lex.
+func fingerprint(statement string) string {
+	if f := Fingerprint(statement); f != "" {
+		return f
+	}
+
+	return statement
+}
+
+// markerNames returns the names of the bind markers of statement in order,
+// empty for ?.
+func markerNames(statement string) []string {
+	var ts, _ = cql.Lex(statement)
+	var names []string
+
+	for _, t := range ts {
+		if t.Kind == cql.Bind {
+			names = append(names, t.Text)
+		}
+	}
+
+	return names
+}
+
+func indexOf(ss []string, s string) int {
+	for i, x := range ss {
+		if x == s {
+			return i
+		}
+	}
+
+	return -1
+}
+
+// wordDiff returns the words of actual marked where they differ from expected,
+// like "a [-b-]{+c+} d", and the number of words in common.
+func wordDiff(expected, actual string) (string, int) {
+	var e, a = strings.Fields(expected), strings.Fields(actual)
+	var common = make([][]int, len(e)+1)
+
+	for i := range common {
+		common[i] = make([]int, len(a)+1)
+	}
+
+	for i := len(e) - 1; i >= 0; i-- {
+		for j := len(a) - 1; j >= 0; j-- {
+			if e[i] == a[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	var words []string
+	var i, j = 0, 0
+
+	for i < len(e) || j < len(a) {
+		switch {
+		case i < len(e) && j < len(a) && e[i] == a[j]:
+			words = append(words, e[i])
+			i, j = i+1, j+1
+
+		case j == len(a) || i < len(e) && common[i+1][j] >= common[i][j+1]:
+			words = append(words, "[-"+e[i]+"-]")
+			i++
+
+		default:
+			words = append(words, "{+"+a[j]+"+}")
+			j++
+		}
+	}
+
+	return strings.Join(words, " "), common[0][0]
+}
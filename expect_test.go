@@ -0,0 +1,222 @@
+package gockle
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+type expectT struct {
+	errors []string
+}
+
+func (t *expectT) Errorf(format string, args ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(format, args...))
+}
+
+func (t *expectT) FailNow() {}
+
+func (t *expectT) Logf(format string, args ...interface{}) {}
+
+func TestExpectSession(t *testing.T) {
+	var s = NewExpectSession(t)
+
+	s.Expect(OperationScan, "SELECT n FROM gockle_test.test WHERE id = :id").Named("id", 1).Rows([]string{"n"}, []interface{}{int64(2)})
+	s.Expect(OperationScan, "select n from gockle_test.test where id = ?").Args(mock.Anything).Rows([]string{"n"})
+	s.ExpectRegexp(OperationIter, "^select id, n from gockle_test\\.test").Rows([]string{"id", "n"}, []interface{}{1, 2}, []interface{}{3, nil})
+	s.Expect(OperationScanStructSlice, "select id, n from gockle_test.test").Rows([]string{"id", "n"}, []interface{}{1, 2}, []interface{}{3, nil})
+	s.ExpectFingerprint(OperationExec, "delete from gockle_test.test where id in (1, 2)").Once()
+	s.Expect(OperationScanMapTx, "insert into gockle_test.test (id, n) values (?, ?) if not exists").Applied(false).Rows([]string{"id", "n"}, []interface{}{1, 2})
+	s.Expect(OperationBatchExec, "update gockle_test.test set n = ? where id = ?").Args(3, 1).ReturnError(ErrTimeout)
+
+	var n int
+
+	if err := s.Scan("select  n\nfrom gockle_test.test -- one\nwhere id = ?;", []interface{}{&n}, 1); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if n != 2 {
+		t.Errorf("Actual n %v, expected 2", n)
+	}
+
+	if err := s.Scan("select n from gockle_test.test where id = ?", []interface{}{&n}, 5); err != ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, ErrNotFound)
+	}
+
+	var i = s.ScanIterator("select id, n from gockle_test.test where id > ?", 0)
+	var rows []map[string]interface{}
+
+	for m := range i.All() {
+		rows = append(rows, m)
+	}
+
+	if e := []map[string]interface{}{{"id": 1, "n": 2}, {"id": 3, "n": nil}}; !reflect.DeepEqual(rows, e) {
+		t.Errorf("Actual rows %v, expected %v", rows, e)
+	}
+
+	type row struct {
+		ID int64
+		N  int32
+	}
+
+	var structs []row
+
+	if err := s.ScanStructSlice("select id, n from gockle_test.test", &structs); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []row{{1, 2}, {3, 0}}; !reflect.DeepEqual(structs, e) {
+		t.Errorf("Actual rows %v, expected %v", structs, e)
+	}
+
+	if err := s.Exec("DELETE FROM gockle_test.test WHERE id IN (3, 4, 5)"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var m = map[string]interface{}{}
+
+	if applied, err := s.ScanMapTx("insert into gockle_test.test (id, n) values (?, ?) if not exists", m, 1, 3); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if applied {
+		t.Error("Actual applied, expected not applied")
+	} else if e := map[string]interface{}{"id": 1, "n": 2}; !reflect.DeepEqual(m, e) {
+		t.Errorf("Actual row %v, expected %v", m, e)
+	}
+
+	var b = s.Batch(BatchLogged)
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 1, 3)
+	b.Add("update gockle_test.test set n = ? where id = ?", 3, 1)
+
+	if err := b.Exec(); err != ErrTimeout {
+		t.Errorf("Actual error %v, expected %v", err, ErrTimeout)
+	}
+
+	s.AssertExpectations(t)
+}
+
+func TestExpectSessionUnexpected(t *testing.T) {
+	var et = &expectT{}
+	var s = NewExpectSession(et)
+
+	s.Expect(OperationScan, "select n from gockle_test.test where id = ?")
+	s.Expect(OperationExec, "delete from gockle_test.test where id = :id").Named("id", 1).Once()
+
+	var n int
+
+	if err := s.Scan("select n from gockle_test.test where name = ?", []interface{}{&n}, "a"); !errors.Is(err, ErrUnexpected) {
+		t.Errorf("Actual error %v, expected %v", err, ErrUnexpected)
+	}
+
+	if err := s.Exec("delete from gockle_test.test where id = ?", 2); !errors.Is(err, ErrUnexpected) {
+		t.Errorf("Actual error %v, expected %v", err, ErrUnexpected)
+	}
+
+	if err := s.Exec("delete from gockle_test.test where id = ?", 1); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Exec("delete from gockle_test.test where id = ?", 1); !errors.Is(err, ErrUnexpected) {
+		t.Errorf("Actual error %v, expected %v", err, ErrUnexpected)
+	}
+
+	if len(et.errors) != 3 {
+		t.Fatalf("Actual errors %v, expected 3", et.errors)
+	}
+
+	for n, e := range []string{
+		"select n from gockle_test.test where [-id-] {+name+} = ?",
+		"argument id is 2, expected 1",
+		"called 1 times already",
+	} {
+		if !strings.Contains(et.errors[n], e) {
+			t.Errorf("Actual error %q, expected %q in it", et.errors[n], e)
+		}
+	}
+
+	et.errors = nil
+
+	if s.AssertExpectations(et) {
+		t.Error("Actual expectations met, expected not")
+	}
+
+	if len(et.errors) != 1 || !strings.Contains(et.errors[0], "called 0 times") {
+		t.Errorf("Actual errors %v, expected one for the Scan", et.errors)
+	}
+}
+
+func TestExpectSessionScan(t *testing.T) {
+	var s = NewExpectSession(t)
+
+	s.Expect(OperationScan, "select a from gockle_test.test where id = ?").Rows([]string{"a"}, []interface{}{1})
+	s.Expect(OperationIter, "select a from gockle_test.test").Rows([]string{"a"}, []interface{}{1})
+
+	var a, b int
+
+	if err := s.Scan("select a from gockle_test.test where id = ?", []interface{}{&a, &b}, 1); err == nil || !strings.Contains(err.Error(), "not enough columns") {
+		t.Errorf("Actual error %v, expected not enough columns", err)
+	}
+
+	var i = s.ScanIterator("select a from gockle_test.test")
+
+	if i.Scan(&a, &b) {
+		t.Error("Actual scanned, expected not scanned")
+	}
+
+	if err := i.Close(); err == nil || !strings.Contains(err.Error(), "not enough columns") {
+		t.Errorf("Actual error %v, expected not enough columns", err)
+	}
+
+	i = s.ScanIterator("select a from gockle_test.test")
+
+	if !i.Scan(&a) || a != 1 {
+		t.Errorf("Actual a %v, expected 1", a)
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	s.AssertExpectations(t)
+}
+
+func TestExpectSessionLex(t *testing.T) {
+	var et = &expectT{}
+	var s = NewExpectSession(et)
+
+	s.Expect(OperationExec, "insert into gockle_test.test (id, s) values (1, 'a)")
+	s.ExpectFingerprint(OperationExec, "insert into gockle_test.test (id, s) values (2, 'b)")
+
+	if err := s.Exec("insert into gockle_test.test (id, s) values (3, 'c)"); !errors.Is(err, ErrUnexpected) {
+		t.Errorf("Actual error %v, expected %v", err, ErrUnexpected)
+	}
+
+	if err := s.Exec("insert into gockle_test.test (id, s) values (1, 'a)"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Exec("insert into gockle_test.test (id, s) values (2, 'b)"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if len(et.errors) != 1 {
+		t.Errorf("Actual errors %v, expected 1", et.errors)
+	}
+}
+
+func TestWordDiff(t *testing.T) {
+	for _, c := range []struct {
+		expected, actual, diff string
+		common                 int
+	}{
+		{"a b c", "a b c", "a b c", 3},
+		{"a b c", "a c", "a [-b-] c", 2},
+		{"a c", "a b c", "a {+b+} c", 2},
+		{"a b", "c d", "[-a-] [-b-] {+c+} {+d+}", 0},
+		{"", "a", "{+a+}", 0},
+	} {
+		if d, n := wordDiff(c.expected, c.actual); d != c.diff || n != c.common {
+			t.Errorf("Actual diff %q and %v common, expected %q and %v", d, n, c.diff, c.common)
+		}
+	}
+}
@@ -0,0 +1,449 @@
+package gockle
+
+import (
+	"context"
+	"iter"
+	"math/rand"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Fault is a kind of misbehavior that a FaultSession injects.
+type Fault int
+
+// Faults. The comments name the Operations they apply to; a FaultRule does not
+// match others.
+const (
+	// FaultLatency delays any Operation by Latency before it is performed, or
+	// fails it with the error of the context if the context is done first.
+	FaultLatency Fault = iota
+
+	// FaultTimeout fails any Operation with ErrTimeout without performing it.
+	// The error is a gocql.RequestErrWriteTimeout for Exec, ScanMapTx, and
+	// batches, of the write type Cassandra reports for them, and a
+	// gocql.RequestErrReadTimeout for the others, so ClassifyError and Retry
+	// treat it as they would one from Cassandra.
+	FaultTimeout
+
+	// FaultUnavailable fails any Operation with ErrUnavailable, a
+	// gocql.RequestErrUnavailable, without performing it.
+	FaultUnavailable
+
+	// FaultPartialPage cuts short the Iterator of an OperationIter: it returns
+	// only the first Rows rows, and its Close fails with a read timeout, as if
+	// fetching the next page timed out.
+	FaultPartialPage
+
+	// FaultNotApplied makes a conditional statement or batch not applied
+	// without performing it: ScanMapTx returns false, ExecTx returns a row with
+	// ColumnApplied false, and Exec fails with ErrNotApplied with a context from
+	// ReportNotApplied. A statement is conditional if it has an IF clause.
+	FaultNotApplied
+
+	// FaultBatch fails a batch with a write timeout after it is partly
+	// performed. A logged batch is performed in full and fails with the write
+	// type BATCH_LOG, as it would after Cassandra wrote the batch log. Only the
+	// first Statements statements of another batch are performed.
+	FaultBatch
+)
+
+var faultNames = map[Fault]string{
+	FaultLatency:     "Latency",
+	FaultTimeout:     "Timeout",
+	FaultUnavailable: "Unavailable",
+	FaultPartialPage: "PartialPage",
+	FaultNotApplied:  "NotApplied",
+	FaultBatch:       "Batch",
+}
+
+func (f Fault) String() string {
+	if n, ok := faultNames[f]; ok {
+		return n
+	}
+
+	return "Fault(" + strconv.Itoa(int(f)) + ")"
+}
+
+// FaultRule decides which Operations a FaultSession injects a Fault in.
+type FaultRule struct {
+	Fault Fault
+
+	// Statement matches the statements of the Operations, or any statement of
+	// a batch. If nil, every Operation that Fault applies to matches, and only
+	// then do metadata Operations match.
+	Statement *regexp.Regexp
+
+	// Nth, if positive, injects the Fault only in the nth matching Operation,
+	// counted from 1.
+	Nth int
+
+	// Probability, if positive, is the chance of injecting the Fault in a
+	// matching Operation, drawn from the seed of the FaultSession. If zero,
+	// the Fault is always injected.
+	Probability float64
+
+	// Latency is the delay of FaultLatency.
+	Latency time.Duration
+
+	// Rows is the number of rows of FaultPartialPage.
+	Rows int
+
+	// Statements is the number of statements of an unlogged or counter batch
+	// that FaultBatch performs.
+	Statements int
+
+	// Err, if not nil, is the error of FaultTimeout, FaultUnavailable,
+	// FaultPartialPage, and FaultBatch instead of theirs.
+	Err error
+}
+
+// matches returns whether r matches o, aside from Nth and Probability.
+func (r FaultRule) matches(o *Operation) bool {
+	switch r.Fault {
+	case FaultPartialPage:
+		if o.Kind != OperationIter {
+			return false
+		}
+
+	case FaultNotApplied:
+		switch o.Kind {
+		case OperationScanMapTx, OperationBatchExecTx:
+
+		case OperationExec, OperationBatchExec:
+			if !operationConditional(o) {
+				return false
+			}
+
+		default:
+			return false
+		}
+
+	case FaultBatch:
+		if o.Kind != OperationBatchExec && o.Kind != OperationBatchExecTx {
+			return false
+		}
+	}
+
+	if r.Statement == nil {
+		return true
+	}
+
+	if o.Statement != "" && r.Statement.MatchString(o.Statement) {
+		return true
+	}
+
+	for _, e := range o.Batch {
+		if r.Statement.MatchString(e.Statement) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// error returns r.Err, or err if it is nil.
+func (r FaultRule) error(err error) error {
+	if r.Err != nil {
+		return r.Err
+	}
+
+	return err
+}
+
+// FaultSession is a Session that injects Faults in the Operations of the
+// wrapped Session by FaultRules, to test how callers survive Cassandra
+// misbehaving. The rules are tried in order for each Operation, except
+// OperationIterClose: the latencies of all that fire add up, and of the other
+// Faults the first that fires is injected. The same seed, rules, and order of
+// Operations inject the same Faults. Its methods are safe for concurrent use.
+type FaultSession struct {
+	Session
+
+	mu       sync.Mutex
+	rules    []FaultRule
+	matched  []int
+	rand     *rand.Rand
+	injected []InjectedFault
+}
+
+// InjectedFault is a Fault that a FaultSession injected.
+type InjectedFault struct {
+	// Rule is the index of the FaultRule.
+	Rule int
+
+	Fault     Fault
+	Kind      OperationKind
+	Statement string
+}
+
+// NewFaultSession returns a new FaultSession that injects Faults in s by rules,
+// drawing probabilities from seed.
+func NewFaultSession(s Session, seed int64, rules ...FaultRule) *FaultSession {
+	var f = &FaultSession{rules: rules, matched: make([]int, len(rules)), rand: rand.New(rand.NewSource(seed))}
+
+	f.Session = Chain(s, f.inject)
+
+	return f
+}
+
+// Injected returns the Faults injected so far, in order.
+func (f *FaultSession) Injected() []InjectedFault {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]InjectedFault(nil), f.injected...)
+}
+
+func (f *FaultSession) asyncLimiter() limiter {
+	return limiterOf(f.Session)
+}
+
+// fire returns the total latency of the rules that fire for o and the first
+// other rule that fires, if any.
+func (f *FaultSession) fire(o *Operation) (time.Duration, *FaultRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var latency time.Duration
+	var fault *FaultRule
+
+	for n := range f.rules {
+		var r = &f.rules[n]
+
+		if !r.matches(o) {
+			continue
+		}
+
+		f.matched[n]++
+
+		if r.Nth > 0 && f.matched[n] != r.Nth {
+			continue
+		}
+
+		if r.Probability > 0 && f.rand.Float64() >= r.Probability {
+			continue
+		}
+
+		if r.Fault != FaultLatency && fault != nil {
+			continue
+		}
+
+		f.injected = append(f.injected, InjectedFault{Rule: n, Fault: r.Fault, Kind: o.Kind, Statement: operationStatement(o)})
+
+		if r.Fault == FaultLatency {
+			latency += r.Latency
+		} else {
+			fault = r
+		}
+	}
+
+	return latency, fault
+}
+
+func (f *FaultSession) inject(next Handler) Handler {
+	return func(ctx context.Context, o *Operation) error {
+		if o.Kind == OperationIterClose {
+			return next(ctx, o)
+		}
+
+		var latency, r = f.fire(o)
+
+		if latency > 0 {
+			var t = time.NewTimer(latency)
+
+			select {
+			case <-t.C:
+
+			case <-ctx.Done():
+				t.Stop()
+
+				return ctx.Err()
+			}
+		}
+
+		if r == nil {
+			return next(ctx, o)
+		}
+
+		switch r.Fault {
+		case FaultTimeout:
+			return r.error(faultTimeout(o, ""))
+
+		case FaultUnavailable:
+			return r.error(&Error{Kind: ErrUnavailable, Statement: operationStatement(o), Err: wrappedError{
+				message: "gockle: injected unavailable",
+				err:     &gocql.RequestErrUnavailable{Consistency: faultConsistency(o)},
+			}})
+
+		case FaultPartialPage:
+			var err = next(ctx, o)
+
+			if err == nil && o.Iterator != nil {
+				o.Iterator = &faultIterator{Iterator: o.Iterator, rows: r.Rows, err: r.error(faultTimeout(o, ""))}
+			}
+
+			return err
+
+		case FaultNotApplied:
+			switch o.Kind {
+			case OperationScanMapTx:
+				o.Applied = false
+
+				return nil
+
+			case OperationBatchExecTx:
+				o.Rows = []map[string]interface{}{{ColumnApplied: false}}
+
+				return nil
+			}
+
+			if !reportNotApplied(ctx) {
+				return nil
+			}
+
+			return notApplied(operationStatement(o), "")
+
+		case FaultBatch:
+			if o.BatchKind == BatchLogged {
+				if err := next(ctx, o); err != nil {
+					return err
+				}
+
+				return r.error(faultTimeout(o, "BATCH_LOG"))
+			}
+
+			if n := min(max(r.Statements, 0), len(o.Batch)); n > 0 {
+				var batch = o.Batch
+
+				o.Batch = batch[:n]
+
+				var err = next(ctx, o)
+
+				o.Batch = batch
+
+				if err != nil {
+					return err
+				}
+			}
+
+			return r.error(faultTimeout(o, ""))
+		}
+
+		return next(ctx, o)
+	}
+}
+
+// faultConsistency returns the consistency level of o, or Quorum if it has
+// none.
+func faultConsistency(o *Operation) gocql.Consistency {
+	if o.HasConsistency {
+		return o.Consistency
+	}
+
+	return gocql.Quorum
+}
+
+// faultTimeout returns a timeout error for o. It is a write timeout of
+// writeType, or of that of o if empty, for writes.
+func faultTimeout(o *Operation, writeType string) error {
+	var err error = &gocql.RequestErrReadTimeout{Consistency: faultConsistency(o)}
+	var message = "gockle: injected read timeout"
+
+	switch {
+	case writeType != "":
+
+	case o.Kind == OperationExec:
+		writeType = "SIMPLE"
+
+		if operationConditional(o) {
+			writeType = "CAS"
+		}
+
+	case o.Kind == OperationScanMapTx:
+		writeType = "CAS"
+
+	case o.Kind == OperationBatchExec || o.Kind == OperationBatchExecTx:
+		writeType = map[BatchKind]string{BatchLogged: "BATCH", BatchUnlogged: "UNLOGGED_BATCH", BatchCounter: "COUNTER"}[o.BatchKind]
+	}
+
+	if writeType != "" {
+		err = &gocql.RequestErrWriteTimeout{Consistency: faultConsistency(o), WriteType: writeType}
+		message = "gockle: injected write timeout"
+	}
+
+	return &Error{Kind: ErrTimeout, Statement: operationStatement(o), Err: wrappedError{message: message, err: err}}
+}
+
+// faultIterator ends an Iterator after rows rows. If it had more, it is cut
+// and Close returns err.
+type faultIterator struct {
+	Iterator
+
+	rows int
+	err  error
+	cut  bool
+}
+
+// next returns whether the Iterator may return another row. If not, it reads
+// the next row to find whether the Iterator is cut.
+func (i *faultIterator) next() bool {
+	if i.rows > 0 {
+		i.rows--
+
+		return true
+	}
+
+	if !i.cut {
+		i.cut = i.Iterator.ScanMap(map[string]interface{}{})
+	}
+
+	return false
+}
+
+func (i *faultIterator) All() iter.Seq2[map[string]interface{}, error] {
+	return all(i)
+}
+
+func (i *faultIterator) Close() error {
+	var err = i.Iterator.Close()
+
+	if i.cut {
+		return i.err
+	}
+
+	return err
+}
+
+func (i *faultIterator) Scan(results ...interface{}) bool {
+	return i.next() && i.Iterator.Scan(results...)
+}
+
+func (i *faultIterator) ScanMap(results map[string]interface{}) bool {
+	return i.next() && i.Iterator.ScanMap(results)
+}
+
+func (i *faultIterator) StructScan(dest interface{}) bool {
+	return i.next() && i.Iterator.StructScan(dest)
+}
+
+func (i *faultIterator) WillSwitchPage() bool {
+	return i.rows > 0 && i.Iterator.WillSwitchPage()
+}
+
+func (i *faultIterator) SliceMap() ([]map[string]interface{}, error) {
+	var ms, err = i.Iterator.SliceMap()
+
+	if len(ms) > i.rows {
+		ms, i.rows, i.cut = ms[:i.rows], 0, true
+
+		return ms, i.err
+	}
+
+	i.rows -= len(ms)
+
+	return ms, err
+}
@@ -0,0 +1,250 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+func TestFaultSeed(t *testing.T) {
+	var run = func(seed int64) []InjectedFault {
+		var s = NewFaultSession(newMemorySession(t), seed, FaultRule{
+			Fault:       FaultTimeout,
+			Statement:   regexp.MustCompile("^insert"),
+			Probability: 0.5,
+		})
+
+		defer s.Close()
+
+		for n := 0; n < 20; n++ {
+			var err = s.Exec("insert into gockle_test.test (id, n) values (?, ?)", n, n)
+
+			if err != nil && !errors.Is(err, ErrTimeout) {
+				t.Errorf("Actual error %v, expected %v", err, ErrTimeout)
+			}
+		}
+
+		if err := s.Exec("delete from gockle_test.test where id = 1"); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		}
+
+		return s.Injected()
+	}
+
+	var a, e = run(1), run(1)
+
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual faults %v, expected %v", a, e)
+	}
+
+	if len(a) == 0 || len(a) == 20 {
+		t.Errorf("Actual %v faults, expected some of 20", len(a))
+	}
+
+	if reflect.DeepEqual(run(2), a) {
+		t.Error("Actual same faults for another seed, expected others")
+	}
+}
+
+func TestFaultSession(t *testing.T) {
+	var s = NewFaultSession(newMemorySession(t, rowInsert), 0,
+		FaultRule{Fault: FaultUnavailable, Statement: regexp.MustCompile("where id = 1$"), Nth: 2},
+		FaultRule{Fault: FaultTimeout, Statement: regexp.MustCompile("where id = 2$")},
+		FaultRule{Fault: FaultTimeout, Statement: regexp.MustCompile("^update")},
+		FaultRule{Fault: FaultLatency, Statement: regexp.MustCompile("where id = 3$"), Latency: time.Hour},
+	)
+
+	defer s.Close()
+
+	var n int
+	var one = "select n from gockle_test.test where id = 1"
+
+	if err := s.Scan(one, []interface{}{&n}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Scan(one, []interface{}{&n}); !errors.Is(err, ErrUnavailable) {
+		t.Errorf("Actual error %v, expected %v", err, ErrUnavailable)
+	} else if c := ClassifyError(err); c != ErrorClassUnavailable {
+		t.Errorf("Actual class %v, expected %v", c, ErrorClassUnavailable)
+	}
+
+	if err := s.Scan(one, []interface{}{&n}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Scan("select n from gockle_test.test where id = 2", []interface{}{&n}); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Actual error %v, expected %v", err, ErrTimeout)
+	} else if c := ClassifyError(err); c != ErrorClassReadTimeout {
+		t.Errorf("Actual class %v, expected %v", c, ErrorClassReadTimeout)
+	}
+
+	var w *gocql.RequestErrWriteTimeout
+
+	if err := s.Exec("update gockle_test.test set n = 3 where id = 1 if n = 2"); !errors.As(err, &w) {
+		t.Errorf("Actual error %v, expected write timeout", err)
+	} else if w.WriteType != "CAS" {
+		t.Errorf("Actual write type %v, expected CAS", w.WriteType)
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+
+	defer cancel()
+
+	if err := s.ScanContext(ctx, "select n from gockle_test.test where id = 3", []interface{}{&n}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Actual error %v, expected %v", err, context.DeadlineExceeded)
+	}
+
+	var retried = Chain(s, Retry(&ClassPolicy{MaxAttempts: 2, Unavailable: RetryAlways}))
+
+	s.rules[0].Nth = 4
+
+	if err := retried.Scan(one, []interface{}{&n}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var e = []Fault{FaultUnavailable, FaultTimeout, FaultTimeout, FaultLatency, FaultUnavailable}
+	var a []Fault
+
+	for _, f := range s.Injected() {
+		a = append(a, f.Fault)
+	}
+
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual faults %v, expected %v", a, e)
+	}
+}
+
+func TestFaultPartialPage(t *testing.T) {
+	var s = NewFaultSession(newMemorySession(t, rowInsert, rowInsert2), 0,
+		FaultRule{Fault: FaultPartialPage, Rows: 1, Nth: 1},
+		FaultRule{Fault: FaultPartialPage, Rows: 1, Nth: 2},
+		FaultRule{Fault: FaultPartialPage, Rows: 2, Nth: 3},
+	)
+
+	defer s.Close()
+
+	var all = "select * from gockle_test.test"
+	var i = s.Query(all).PageSize(1).Iter()
+	var rows int
+
+	for i.ScanMap(map[string]interface{}{}) {
+		rows++
+	}
+
+	if rows != 1 {
+		t.Errorf("Actual rows %v, expected 1", rows)
+	}
+
+	if err := i.Close(); !errors.Is(err, ErrTimeout) {
+		t.Errorf("Actual error %v, expected %v", err, ErrTimeout)
+	}
+
+	i = s.ScanIterator(all)
+
+	if ms, err := i.SliceMap(); len(ms) != 1 || !errors.Is(err, ErrTimeout) {
+		t.Errorf("Actual rows %v and error %v, expected 1 row and %v", ms, err, ErrTimeout)
+	}
+
+	i = s.ScanIterator(all)
+
+	if ms, err := i.SliceMap(); len(ms) != 2 || err != nil {
+		t.Errorf("Actual rows %v and error %v, expected 2 rows and no error", ms, err)
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+}
+
+func TestFaultNotApplied(t *testing.T) {
+	var s = NewFaultSession(newMemorySession(t), 0, FaultRule{Fault: FaultNotApplied})
+
+	defer s.Close()
+
+	var insert = "insert into gockle_test.test (id, n) values (?, ?)"
+
+	if applied, err := s.ScanMapTx(insert+" if not exists", map[string]interface{}{}, 1, 2); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if applied {
+		t.Error("Actual applied, expected not applied")
+	}
+
+	if err := s.ExecContext(ReportNotApplied(context.Background()), insert+" if not exists", 1, 2); !errors.Is(err, ErrNotApplied) {
+		t.Errorf("Actual error %v, expected %v", err, ErrNotApplied)
+	}
+
+	if err := s.ExecContext(ReportNotApplied(context.Background()), "delete m['k'] from gockle_test.test where id = ? if exists", 1); !errors.Is(err, ErrNotApplied) {
+		t.Errorf("Actual error %v, expected %v", err, ErrNotApplied)
+	}
+
+	if err := s.Exec(insert+" if not exists", 1, 2); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Exec(insert, 1, 2); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var b = s.Batch(BatchLogged)
+
+	b.Add(insert+" if not exists", 1, 3)
+
+	if rows, err := b.ExecTx(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []map[string]interface{}{{ColumnApplied: false}}; !reflect.DeepEqual(rows, e) {
+		t.Errorf("Actual rows %v, expected %v", rows, e)
+	}
+
+	var m = map[string]interface{}{}
+
+	if err := s.ScanMap("select * from gockle_test.test where id = 1", m); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := map[string]interface{}{"id": 1, "n": 2}; !reflect.DeepEqual(m, e) {
+		t.Errorf("Actual row %v, expected %v", m, e)
+	}
+}
+
+func TestFaultBatch(t *testing.T) {
+	var s = NewFaultSession(newMemorySession(t), 0, FaultRule{Fault: FaultBatch, Statements: 1})
+
+	defer s.Close()
+
+	for _, c := range []struct {
+		kind      BatchKind
+		writeType string
+		rows      int
+	}{
+		{BatchUnlogged, "UNLOGGED_BATCH", 1},
+		{BatchLogged, "BATCH_LOG", 3},
+	} {
+		if err := s.Exec("truncate gockle_test.test"); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		var b = s.Batch(c.kind)
+
+		for n := 1; n <= 3; n++ {
+			b.Add("insert into gockle_test.test (id, n) values (?, ?)", n, n)
+		}
+
+		var w *gocql.RequestErrWriteTimeout
+
+		if err := b.Exec(); !errors.Is(err, ErrTimeout) || !errors.As(err, &w) {
+			t.Errorf("Actual error %v, expected write timeout", err)
+		} else if w.WriteType != c.writeType {
+			t.Errorf("Actual write type %v, expected %v", w.WriteType, c.writeType)
+		}
+
+		if rows, err := s.ScanMapSlice("select * from gockle_test.test"); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		} else if len(rows) != c.rows {
+			t.Errorf("Actual rows %v, expected %v", len(rows), c.rows)
+		}
+	}
+}
@@ -0,0 +1,301 @@
+package gockletest
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/memdb"
+)
+
+const (
+	protoVersion = 4
+	headerSize   = 9
+	maxFrameSize = 256 << 20
+)
+
+// Opcodes.
+const (
+	opError        = 0x00
+	opStartup      = 0x01
+	opReady        = 0x02
+	opOptions      = 0x05
+	opSupported    = 0x06
+	opQuery        = 0x07
+	opResult       = 0x08
+	opPrepare      = 0x09
+	opExecute      = 0x0A
+	opRegister     = 0x0B
+	opEvent        = 0x0C
+	opBatch        = 0x0D
+	opAuthResponse = 0x0F
+)
+
+// Frame flags.
+const (
+	flagCompression   = 0x01
+	flagCustomPayload = 0x04
+)
+
+// Result kinds.
+const (
+	resultVoid         = 0x0001
+	resultRows         = 0x0002
+	resultSetKeyspace  = 0x0003
+	resultPrepared     = 0x0004
+	resultSchemaChange = 0x0005
+)
+
+// Query flags.
+const (
+	queryValues            = 0x01
+	querySkipMetadata      = 0x02
+	queryPageSize          = 0x04
+	queryPagingState       = 0x08
+	querySerialConsistency = 0x10
+	queryDefaultTimestamp  = 0x20
+	queryNames             = 0x40
+)
+
+// Rows metadata flags.
+const (
+	metadataMorePages  = 0x0002
+	metadataNoMetadata = 0x0004
+)
+
+// errMalformed is panicked by reader when a frame body ends early.
+type errMalformed struct {
+	message string
+}
+
+func (e errMalformed) Error() string {
+	return e.message
+}
+
+// reader decodes the notation of the native protocol from a frame body.
+type reader struct {
+	b []byte
+}
+
+func (r *reader) take(n int) []byte {
+	if n < 0 || n > len(r.b) {
+		panic(errMalformed{fmt.Sprintf("not enough bytes in frame body to read %v bytes", n)})
+	}
+
+	var b = r.b[:n]
+
+	r.b = r.b[n:]
+
+	return b
+}
+
+func (r *reader) byte() byte {
+	return r.take(1)[0]
+}
+
+func (r *reader) short() uint16 {
+	return binary.BigEndian.Uint16(r.take(2))
+}
+
+func (r *reader) int() int32 {
+	return int32(binary.BigEndian.Uint32(r.take(4)))
+}
+
+func (r *reader) long() int64 {
+	return int64(binary.BigEndian.Uint64(r.take(8)))
+}
+
+func (r *reader) string() string {
+	return string(r.take(int(r.short())))
+}
+
+func (r *reader) longString() string {
+	return string(r.take(int(r.int())))
+}
+
+func (r *reader) stringList() []string {
+	var ss = make([]string, r.short())
+
+	for i := range ss {
+		ss[i] = r.string()
+	}
+
+	return ss
+}
+
+func (r *reader) stringMap() map[string]string {
+	var n, m = int(r.short()), map[string]string{}
+
+	for i := 0; i < n; i++ {
+		var k = r.string()
+
+		m[k] = r.string()
+	}
+
+	return m
+}
+
+// bytes returns nil for null and unset values.
+func (r *reader) bytes() []byte {
+	var n = r.int()
+
+	if n < 0 {
+		return nil
+	}
+
+	return r.take(int(n))
+}
+
+func (r *reader) shortBytes() []byte {
+	return r.take(int(r.short()))
+}
+
+func (r *reader) bytesMap() map[string][]byte {
+	var n, m = int(r.short()), map[string][]byte{}
+
+	for i := 0; i < n; i++ {
+		var k = r.string()
+
+		m[k] = r.bytes()
+	}
+
+	return m
+}
+
+// writer encodes the notation of the native protocol into a frame body.
+type writer struct {
+	b []byte
+}
+
+func (w *writer) byte(b byte) {
+	w.b = append(w.b, b)
+}
+
+func (w *writer) short(n uint16) {
+	w.b = binary.BigEndian.AppendUint16(w.b, n)
+}
+
+func (w *writer) int(n int32) {
+	w.b = binary.BigEndian.AppendUint32(w.b, uint32(n))
+}
+
+func (w *writer) string(s string) {
+	w.short(uint16(len(s)))
+	w.b = append(w.b, s...)
+}
+
+func (w *writer) stringList(ss []string) {
+	w.short(uint16(len(ss)))
+
+	for _, s := range ss {
+		w.string(s)
+	}
+}
+
+func (w *writer) stringMultimap(m map[string][]string) {
+	w.short(uint16(len(m)))
+
+	for k, ss := range m {
+		w.string(k)
+		w.stringList(ss)
+	}
+}
+
+// bytes writes nil as null.
+func (w *writer) bytes(b []byte) {
+	if b == nil {
+		w.int(-1)
+
+		return
+	}
+
+	w.int(int32(len(b)))
+	w.b = append(w.b, b...)
+}
+
+func (w *writer) shortBytes(b []byte) {
+	w.short(uint16(len(b)))
+	w.b = append(w.b, b...)
+}
+
+func (w *writer) option(t gocql.TypeInfo) {
+	w.short(uint16(t.Type()))
+
+	if c, ok := t.(gocql.CollectionType); ok {
+		if t.Type() == gocql.TypeMap {
+			w.option(c.Key)
+		}
+
+		w.option(c.Elem)
+	}
+}
+
+func (w *writer) columns(cs []memdb.Column) {
+	for _, c := range cs {
+		w.string(c.Keyspace)
+		w.string(c.Table)
+		w.string(c.Name)
+		w.option(c.Type)
+	}
+}
+
+// rows writes a Rows result. A non-nil state means there are more pages.
+func (w *writer) rows(cs []memdb.Column, rows [][]interface{}, state []byte, skipMetadata bool) error {
+	var flags int32
+
+	if state != nil {
+		flags |= metadataMorePages
+	}
+
+	if skipMetadata {
+		flags |= metadataNoMetadata
+	}
+
+	w.int(resultRows)
+	w.int(flags)
+	w.int(int32(len(cs)))
+
+	if state != nil {
+		w.bytes(state)
+	}
+
+	if !skipMetadata {
+		w.columns(cs)
+	}
+
+	w.int(int32(len(rows)))
+
+	for _, row := range rows {
+		for i, c := range cs {
+			if row[i] == nil {
+				w.bytes(nil)
+
+				continue
+			}
+
+			var b, err = gocql.Marshal(c.Type, row[i])
+
+			if err != nil {
+				return err
+			}
+
+			if b == nil {
+				b = []byte{}
+			}
+
+			w.bytes(b)
+		}
+	}
+
+	return nil
+}
+
+func (w *writer) schemaChange(c *memdb.Change) {
+	w.string(c.Kind)
+	w.string(c.Target)
+	w.string(c.Keyspace)
+
+	if c.Target == "TABLE" {
+		w.string(c.Table)
+	}
+}
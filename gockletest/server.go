@@ -0,0 +1,648 @@
+// Package gockletest provides an in-process Cassandra node for tests.
+//
+// A Server speaks native protocol v4 over TCP on the loopback interface and
+// keeps its data in memory, so the real gocql-backed Session can be tested
+// end to end without a Cassandra cluster:
+//
+//	var s = gockletest.NewServer()
+//
+//	defer s.Close()
+//
+//	var session, err = gockle.NewSimpleSession(s.Addr)
+//
+// The server understands the same CQL subset as gockle.NewMemorySession, plus
+// the system and system_schema tables gocql reads to discover the node and
+// the schema. It supports prepared statements, batches, paging, and schema
+// change events. It does not support compression, authentication, or named
+// values.
+package gockletest
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/memdb"
+)
+
+// Server is an in-process Cassandra node.
+type Server struct {
+	// Addr is the host and port the server listens on, like 127.0.0.1:9042.
+	Addr string
+
+	db       *memdb.DB
+	listener net.Listener
+	wg       sync.WaitGroup
+
+	mu       sync.Mutex
+	closed   bool
+	conns    map[*conn]struct{}
+	prepared map[string]*prepared
+}
+
+// NewServer starts and returns a new Server on a random port of the loopback
+// interface. It panics if it cannot listen. The caller should call Close when
+// finished to shut it down.
+func NewServer() *Server {
+	var l, err = net.Listen("tcp", "127.0.0.1:0")
+
+	if err != nil {
+		panic(fmt.Sprintf("gockletest: failed to listen on a port: %v", err))
+	}
+
+	var s = &Server{
+		Addr:     l.Addr().String(),
+		db:       memdb.New(),
+		listener: l,
+		conns:    map[*conn]struct{}{},
+		prepared: map[string]*prepared{},
+	}
+
+	s.wg.Add(1)
+
+	go s.serve()
+
+	return s
+}
+
+// Close stops the server and closes its connections. It blocks until they
+// are closed.
+func (s *Server) Close() {
+	s.mu.Lock()
+
+	if s.closed {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.closed = true
+	s.listener.Close()
+
+	for c := range s.conns {
+		c.c.Close()
+	}
+
+	s.mu.Unlock()
+	s.wg.Wait()
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+
+	for {
+		var nc, err = s.listener.Accept()
+
+		if err != nil {
+			return
+		}
+
+		var c = &conn{s: s, c: nc}
+
+		s.mu.Lock()
+
+		if s.closed {
+			s.mu.Unlock()
+			nc.Close()
+
+			return
+		}
+
+		s.conns[c] = struct{}{}
+		s.wg.Add(1)
+		s.mu.Unlock()
+
+		go func() {
+			defer s.wg.Done()
+
+			c.serve()
+
+			s.mu.Lock()
+			delete(s.conns, c)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// event sends a schema change event to the connections that registered for
+// it.
+func (s *Server) event(change *memdb.Change) {
+	var w = &writer{}
+
+	w.string("SCHEMA_CHANGE")
+	w.schemaChange(change)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for c := range s.conns {
+		if c.registered() {
+			c.write(-1, opEvent, w.b)
+		}
+	}
+}
+
+type prepared struct {
+	id        []byte
+	keyspace  string
+	statement string
+	info      *memdb.Prepared
+}
+
+func (s *Server) prepare(keyspace, statement string) (*prepared, error) {
+	var info, err = s.db.Prepare(keyspace, statement)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var sum = md5.Sum([]byte(keyspace + "\x00" + statement))
+	var p = &prepared{id: sum[:], keyspace: keyspace, statement: statement, info: info}
+
+	s.mu.Lock()
+	s.prepared[string(p.id)] = p
+	s.mu.Unlock()
+
+	return p, nil
+}
+
+func (s *Server) lookup(id []byte) (*prepared, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var p, ok = s.prepared[string(id)]
+
+	if !ok {
+		return nil, errUnprepared{id: id}
+	}
+
+	return p, nil
+}
+
+// errUnprepared is the error for executing an unknown prepared statement.
+type errUnprepared struct {
+	id []byte
+}
+
+func (e errUnprepared) Error() string {
+	return fmt.Sprintf("Prepared query with ID %x not found", e.id)
+}
+
+// errProtocol is the error for a request that breaks the protocol.
+type errProtocol struct {
+	message string
+}
+
+func (e errProtocol) Error() string {
+	return e.message
+}
+
+type conn struct {
+	s *Server
+	c net.Conn
+
+	mu       sync.Mutex
+	events   bool
+	keyspace string
+}
+
+func (c *conn) registered() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.events
+}
+
+func (c *conn) write(stream int16, opcode byte, body []byte) {
+	var h = make([]byte, headerSize, headerSize+len(body))
+
+	h[0] = 0x80 | protoVersion
+	binary.BigEndian.PutUint16(h[2:], uint16(stream))
+	h[4] = opcode
+	binary.BigEndian.PutUint32(h[5:], uint32(len(body)))
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.c.Write(append(h, body...))
+}
+
+func (c *conn) serve() {
+	defer c.c.Close()
+
+	var h = make([]byte, headerSize)
+
+	for {
+		if _, err := io.ReadFull(c.c, h); err != nil {
+			return
+		}
+
+		var version, flags, opcode = h[0], h[1], h[4]
+		var stream = int16(binary.BigEndian.Uint16(h[2:]))
+		var n = binary.BigEndian.Uint32(h[5:])
+
+		if n > maxFrameSize {
+			return
+		}
+
+		var body = make([]byte, n)
+
+		if _, err := io.ReadFull(c.c, body); err != nil {
+			return
+		}
+
+		if version != protoVersion {
+			// Answer in the version of the request so that the driver can read
+			// the error and negotiate a lower version.
+			var w = &writer{}
+
+			w.int(gocql.ErrCodeProtocol)
+			w.string(fmt.Sprintf("Invalid or unsupported protocol version (%v); the lowest supported version is %v and the greatest is %v", version&0x7f, protoVersion, protoVersion))
+			h[0] = 0x80 | version&0x7f
+			h[1], h[4] = 0, opError
+			binary.BigEndian.PutUint32(h[5:], uint32(len(w.b)))
+
+			c.mu.Lock()
+			c.c.Write(append(h, w.b...))
+			c.mu.Unlock()
+
+			continue
+		}
+
+		var op, resp = c.handle(flags, opcode, body)
+
+		c.write(stream, op, resp)
+	}
+}
+
+// handle answers a request with an opcode and a body.
+func (c *conn) handle(flags, opcode byte, body []byte) (op byte, resp []byte) {
+	var w = &writer{}
+
+	defer func() {
+		if r := recover(); r != nil {
+			var m, ok = r.(errMalformed)
+
+			if !ok {
+				panic(r)
+			}
+
+			op, resp = opError, errorBody(errProtocol{m.message})
+		}
+	}()
+
+	if flags&flagCompression != 0 {
+		return opError, errorBody(errProtocol{"compression is not supported"})
+	}
+
+	var r = &reader{b: body}
+
+	if flags&flagCustomPayload != 0 {
+		r.bytesMap()
+	}
+
+	var err error
+
+	switch opcode {
+	case opStartup:
+		if _, ok := r.stringMap()["COMPRESSION"]; ok {
+			return opError, errorBody(errProtocol{"compression is not supported"})
+		}
+
+		return opReady, nil
+
+	case opOptions:
+		w.stringMultimap(map[string][]string{"CQL_VERSION": {"3.4.4"}, "COMPRESSION": {}})
+
+		return opSupported, w.b
+
+	case opRegister:
+		r.stringList()
+
+		c.mu.Lock()
+		c.events = true
+		c.mu.Unlock()
+
+		return opReady, nil
+
+	case opQuery:
+		var statement = r.longString()
+
+		err = c.query(w, r, statement, nil)
+
+	case opPrepare:
+		err = c.prepare(w, r.longString())
+
+	case opExecute:
+		var p *prepared
+
+		if p, err = c.s.lookup(r.shortBytes()); err == nil {
+			err = c.query(w, r, p.statement, p)
+		}
+
+	case opBatch:
+		err = c.batch(w, r)
+
+	default:
+		err = errProtocol{fmt.Sprintf("unsupported opcode %#x", opcode)}
+	}
+
+	if err != nil {
+		return opError, errorBody(err)
+	}
+
+	return opResult, w.b
+}
+
+func errorBody(err error) []byte {
+	var w = &writer{}
+	var re gocql.RequestError
+	var unprepared errUnprepared
+	var protocol errProtocol
+
+	switch {
+	case errors.As(err, &unprepared):
+		w.int(gocql.ErrCodeUnprepared)
+		w.string(err.Error())
+		w.shortBytes(unprepared.id)
+
+	case errors.As(err, &protocol):
+		w.int(gocql.ErrCodeProtocol)
+		w.string(err.Error())
+
+	case errors.As(err, &re):
+		w.int(int32(re.Code()))
+		w.string(re.Message())
+
+		if re.Code() == gocql.ErrCodeAlreadyExists {
+			var e, _ = re.(*memdb.Error)
+
+			if e == nil {
+				e = &memdb.Error{}
+			}
+
+			w.string(e.Keyspace)
+			w.string(e.Table)
+		}
+
+	default:
+		w.int(gocql.ErrCodeServer)
+		w.string(err.Error())
+	}
+
+	return w.b
+}
+
+type params struct {
+	values       [][]byte
+	skipMetadata bool
+	pageSize     int
+	pagingState  []byte
+}
+
+func readParams(r *reader) (*params, error) {
+	var p = &params{}
+
+	r.short()
+
+	var flags = r.byte()
+
+	if flags&queryNames != 0 {
+		return nil, errProtocol{"named values are not supported"}
+	}
+
+	if flags&queryValues != 0 {
+		p.values = make([][]byte, r.short())
+
+		for i := range p.values {
+			p.values[i] = r.bytes()
+		}
+	}
+
+	p.skipMetadata = flags&querySkipMetadata != 0
+
+	if flags&queryPageSize != 0 {
+		p.pageSize = int(r.int())
+	}
+
+	if flags&queryPagingState != 0 {
+		p.pagingState = r.bytes()
+	}
+
+	if flags&querySerialConsistency != 0 {
+		r.short()
+	}
+
+	if flags&queryDefaultTimestamp != 0 {
+		r.long()
+	}
+
+	return p, nil
+}
+
+// values decodes the bound values of statement. The markers of prepared
+// statements are known; those of other statements are found by preparing
+// them.
+func (c *conn) values(keyspace, statement string, p *prepared, bs [][]byte) ([]interface{}, error) {
+	if len(bs) == 0 {
+		return nil, nil
+	}
+
+	var info *memdb.Prepared
+
+	if p != nil {
+		info = p.info
+	} else {
+		var err error
+
+		if info, err = c.s.db.Prepare(keyspace, statement); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(bs) != len(info.Markers) {
+		return nil, memdb.Errorf(gocql.ErrCodeInvalid, "there were %v markers(?) in CQL but %v bound variables", len(info.Markers), len(bs))
+	}
+
+	var vs = make([]interface{}, len(bs))
+
+	for i, b := range bs {
+		var v, err = memdb.Decode(info.Markers[i].Type, b)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vs[i] = v
+	}
+
+	return vs, nil
+}
+
+func (c *conn) currentKeyspace() string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.keyspace
+}
+
+func (c *conn) query(w *writer, r *reader, statement string, p *prepared) error {
+	var ps, err = readParams(r)
+
+	if err != nil {
+		return err
+	}
+
+	var keyspace = c.currentKeyspace()
+
+	if p != nil {
+		keyspace = p.keyspace
+	}
+
+	vs, err := c.values(keyspace, statement, p, ps.values)
+
+	if err != nil {
+		return err
+	}
+
+	result, err := c.s.db.Exec(keyspace, statement, vs)
+
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case result.Keyspace != "":
+		c.mu.Lock()
+		c.keyspace = result.Keyspace
+		c.mu.Unlock()
+
+		w.int(resultSetKeyspace)
+		w.string(result.Keyspace)
+
+	case result.Change != nil:
+		w.int(resultSchemaChange)
+		w.schemaChange(result.Change)
+		c.s.event(result.Change)
+
+	case result.Columns == nil:
+		w.int(resultVoid)
+
+	default:
+		return page(w, result, ps)
+	}
+
+	return nil
+}
+
+// page writes the page of result that ps asks for. The paging state is the
+// offset of the page. Like Cassandra, a full page has a paging state even if
+// no rows follow it.
+func page(w *writer, result *memdb.Result, ps *params) error {
+	var rows, offset = result.Rows, 0
+
+	if len(ps.pagingState) == 8 {
+		offset = int(binary.BigEndian.Uint64(ps.pagingState))
+
+		if offset > len(rows) {
+			offset = len(rows)
+		}
+	}
+
+	rows = rows[offset:]
+
+	var state []byte
+
+	if ps.pageSize > 0 && len(rows) >= ps.pageSize {
+		rows = rows[:ps.pageSize]
+		state = make([]byte, 8)
+		binary.BigEndian.PutUint64(state, uint64(offset+ps.pageSize))
+	}
+
+	return w.rows(result.Columns, rows, state, ps.skipMetadata)
+}
+
+func (c *conn) prepare(w *writer, statement string) error {
+	var p, err = c.s.prepare(c.currentKeyspace(), statement)
+
+	if err != nil {
+		return err
+	}
+
+	w.int(resultPrepared)
+	w.shortBytes(p.id)
+	w.int(0)
+	w.int(int32(len(p.info.Markers)))
+	w.int(0)
+	w.columns(p.info.Markers)
+	w.int(0)
+	w.int(int32(len(p.info.Columns)))
+	w.columns(p.info.Columns)
+
+	return nil
+}
+
+func (c *conn) batch(w *writer, r *reader) error {
+	r.byte()
+
+	var keyspace = c.currentKeyspace()
+	var entries = make([]memdb.Entry, r.short())
+
+	for i := range entries {
+		var p *prepared
+		var statement string
+
+		if r.byte() == 0 {
+			statement = r.longString()
+		} else {
+			var err error
+
+			if p, err = c.s.lookup(r.shortBytes()); err != nil {
+				return err
+			}
+
+			statement = p.statement
+		}
+
+		var bs = make([][]byte, r.short())
+
+		for j := range bs {
+			bs[j] = r.bytes()
+		}
+
+		var vs, err = c.values(keyspace, statement, p, bs)
+
+		if err != nil {
+			return err
+		}
+
+		entries[i] = memdb.Entry{Statement: statement, Values: vs}
+	}
+
+	r.short()
+
+	var flags = r.byte()
+
+	if flags&queryNames != 0 {
+		return errProtocol{"named values are not supported"}
+	}
+
+	var result, err = c.s.db.Batch(keyspace, entries)
+
+	if err != nil {
+		return err
+	}
+
+	if result.Columns == nil {
+		w.int(resultVoid)
+
+		return nil
+	}
+
+	return w.rows(result.Columns, result.Rows, nil, false)
+}
@@ -0,0 +1,167 @@
+package gockletest
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+func newSession(t *testing.T, s *Server) *gocql.Session {
+	var c = gocql.NewCluster(s.Addr)
+
+	c.Timeout = 5 * time.Second
+
+	var session, err = c.CreateSession()
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	for _, q := range []string{
+		"create keyspace k with replication = {'class': 'SimpleStrategy', 'replication_factor': 1}",
+		"create table k.t (p int, c int, v text, primary key (p, c))",
+	} {
+		if err := session.Query(q).Exec(); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	return session
+}
+
+func TestServer(t *testing.T) {
+	var s = NewServer()
+
+	defer s.Close()
+
+	var session = newSession(t, s)
+
+	defer session.Close()
+
+	for i := 0; i < 5; i++ {
+		if err := session.Query("insert into k.t (p, c, v) values (?, ?, ?)", 1, i, "v").Exec(); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	var b = session.NewBatch(gocql.LoggedBatch)
+
+	b.Query("update k.t set v = ? where p = ? and c = ?", "w", 1, 0)
+	b.Query("delete from k.t where p = 1 and c = 4")
+
+	if err := session.ExecuteBatch(b); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var cs []int
+	var vs []string
+	var c int
+	var v string
+	var i = session.Query("select c, v from k.t where p = ?", 1).PageSize(2).Iter()
+
+	for i.Scan(&c, &v) {
+		cs = append(cs, c)
+		vs = append(vs, v)
+	}
+
+	if err := i.Close(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if e := []int{0, 1, 2, 3}; !reflect.DeepEqual(cs, e) {
+		t.Errorf("Actual clustering keys %v, expected %v", cs, e)
+	}
+
+	if e := []string{"w", "v", "v", "v"}; !reflect.DeepEqual(vs, e) {
+		t.Errorf("Actual values %v, expected %v", vs, e)
+	}
+
+	var m = map[string]interface{}{}
+
+	if applied, err := session.Query("update k.t set v = 'x' where p = 1 and c = 1 if v = ?", "y").MapScanCAS(m); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if applied {
+		t.Error("Actual applied true, expected false")
+	} else if e := (map[string]interface{}{"v": "v"}); !reflect.DeepEqual(m, e) {
+		t.Errorf("Actual row %v, expected %v", m, e)
+	}
+}
+
+func TestServerErrors(t *testing.T) {
+	var s = NewServer()
+
+	defer s.Close()
+
+	var session = newSession(t, s)
+
+	defer session.Close()
+
+	for _, c := range []struct {
+		statement string
+		code      int
+	}{
+		{"select from", gocql.ErrCodeSyntax},
+		{"select * from k.missing", gocql.ErrCodeInvalid},
+		{"create table k.t (a int primary key)", gocql.ErrCodeAlreadyExists},
+	} {
+		var err = session.Query(c.statement).Exec()
+		var re gocql.RequestError
+
+		if !errors.As(err, &re) {
+			t.Errorf("Actual error %v, expected request error for %v", err, c.statement)
+		} else if re.Code() != c.code {
+			t.Errorf("Actual code %#x, expected %#x for %v", re.Code(), c.code, c.statement)
+		}
+	}
+}
+
+func TestServerMetadata(t *testing.T) {
+	var s = NewServer()
+
+	defer s.Close()
+
+	var session = newSession(t, s)
+
+	defer session.Close()
+
+	var k, err = session.KeyspaceMetadata("k")
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var tm, ok = k.Tables["t"]
+
+	if !ok {
+		t.Fatalf("Actual tables %v, expected t", k.Tables)
+	}
+
+	if a, e := len(tm.PartitionKey), 1; a != e {
+		t.Errorf("Actual partition key length %v, expected %v", a, e)
+	}
+
+	if a, e := tm.Columns["v"].Type.Type(), gocql.TypeText; a != e {
+		t.Errorf("Actual type %v, expected %v", a, e)
+	}
+
+	if _, err := session.KeyspaceMetadata("missing"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestServerClose(t *testing.T) {
+	var s = NewServer()
+	var session = newSession(t, s)
+
+	defer session.Close()
+
+	s.Close()
+	s.Close()
+
+	if err := session.Query("select * from k.t").Exec(); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
@@ -0,0 +1,253 @@
+package cql
+
+// Statement is a parsed CQL statement.
+type Statement interface {
+	statement()
+}
+
+// Type is a CQL data type such as int or map<text, int>.
+type Type struct {
+	Name string
+	Args []Type
+}
+
+// String returns the CQL form of t.
+func (t Type) String() string {
+	if len(t.Args) == 0 {
+		return t.Name
+	}
+
+	var s = t.Name + "<"
+
+	for i, a := range t.Args {
+		if i > 0 {
+			s += ", "
+		}
+
+		s += a.String()
+	}
+
+	return s + ">"
+}
+
+// Term is a value in a statement: a literal, a bind marker, a collection
+// literal, or a function call.
+type Term interface {
+	term()
+}
+
+// Literal is a constant. Value is nil, bool, int64, float64, string, []byte,
+// or a UUID string.
+type Literal struct {
+	Value interface{}
+	Kind  Kind
+}
+
+// Marker is a bind marker. Index is its position among all the markers of the
+// statement.
+type Marker struct {
+	Index int
+	Name  string
+}
+
+// ListLiteral is a list literal such as [1, 2].
+type ListLiteral struct {
+	Elems []Term
+}
+
+// SetLiteral is a set literal such as {1, 2}.
+type SetLiteral struct {
+	Elems []Term
+}
+
+// MapLiteral is a map literal such as {'a': 1}.
+type MapLiteral struct {
+	Keys   []Term
+	Values []Term
+}
+
+// Call is a function call such as now().
+type Call struct {
+	Name string
+	Args []Term
+}
+
+func (Literal) term()     {}
+func (Marker) term()      {}
+func (ListLiteral) term() {}
+func (SetLiteral) term()  {}
+func (MapLiteral) term()  {}
+func (Call) term()        {}
+
+// Relation is a WHERE or IF predicate. Columns has more than one element for
+// token(a, b) relations.
+type Relation struct {
+	Columns []string
+	Token   bool
+	Op      string
+	Value   Term
+	Values  []Term
+}
+
+// Column returns the first column of r.
+func (r Relation) Column() string {
+	return r.Columns[0]
+}
+
+// Assignment is a SET clause element. Op is "=" for plain assignment, "+" or
+// "-" for c = c + v and c = c - v, "prepend" for c = v + c, and "index" for
+// c[k] = v.
+type Assignment struct {
+	Column string
+	Op     string
+	Key    Term
+	Value  Term
+}
+
+// Using holds the USING clause.
+type Using struct {
+	TTL       Term
+	Timestamp Term
+}
+
+// Selector is an element of a SELECT clause.
+type Selector struct {
+	Column   string
+	Function string
+	Alias    string
+}
+
+// Name returns the result column name of s.
+func (s Selector) Name() string {
+	switch {
+	case s.Alias != "":
+		return s.Alias
+	case s.Function != "" && s.Column == "*":
+		return s.Function
+	case s.Function != "":
+		return s.Function + "(" + s.Column + ")"
+	default:
+		return s.Column
+	}
+}
+
+// Ordering is an ORDER BY element.
+type Ordering struct {
+	Column string
+	Desc   bool
+}
+
+// ColumnDef is a column definition in CREATE TABLE.
+type ColumnDef struct {
+	Name   string
+	Type   Type
+	Static bool
+}
+
+// CreateKeyspace is CREATE KEYSPACE.
+type CreateKeyspace struct {
+	Name          string
+	IfNotExists   bool
+	Replication   map[string]string
+	DurableWrites bool
+}
+
+// DropKeyspace is DROP KEYSPACE.
+type DropKeyspace struct {
+	Name     string
+	IfExists bool
+}
+
+// CreateTable is CREATE TABLE.
+type CreateTable struct {
+	Keyspace     string
+	Name         string
+	IfNotExists  bool
+	Columns      []ColumnDef
+	PartitionKey []string
+	Clustering   []string
+	Descending   map[string]bool
+	Options      map[string]string
+}
+
+// DropTable is DROP TABLE.
+type DropTable struct {
+	Keyspace string
+	Name     string
+	IfExists bool
+}
+
+// Truncate is TRUNCATE.
+type Truncate struct {
+	Keyspace string
+	Name     string
+}
+
+// Use is USE.
+type Use struct {
+	Keyspace string
+}
+
+// Insert is INSERT.
+type Insert struct {
+	Keyspace    string
+	Table       string
+	Columns     []string
+	Values      []Term
+	IfNotExists bool
+	Using       Using
+}
+
+// Select is SELECT.
+type Select struct {
+	Keyspace          string
+	Table             string
+	Distinct          bool
+	Selectors         []Selector
+	Where             []Relation
+	OrderBy           []Ordering
+	PerPartitionLimit Term
+	Limit             Term
+	AllowFiltering    bool
+}
+
+// Update is UPDATE.
+type Update struct {
+	Keyspace string
+	Table    string
+	Using    Using
+	Set      []Assignment
+	Where    []Relation
+	IfExists bool
+	If       []Relation
+}
+
+// Delete is DELETE.
+type Delete struct {
+	Keyspace string
+	Table    string
+	Columns  []string
+	Using    Using
+	Where    []Relation
+	IfExists bool
+	If       []Relation
+}
+
+// Batch is BEGIN BATCH ... APPLY BATCH.
+type Batch struct {
+	Kind       string
+	Using      Using
+	Statements []Statement
+}
+
+func (CreateKeyspace) statement() {}
+func (DropKeyspace) statement()   {}
+func (CreateTable) statement()    {}
+func (DropTable) statement()      {}
+func (Truncate) statement()       {}
+func (Use) statement()            {}
+func (Insert) statement()         {}
+func (Select) statement()         {}
+func (Update) statement()         {}
+func (Delete) statement()         {}
+func (Batch) statement()          {}
@@ -0,0 +1,288 @@
+// Package cql parses the subset of CQL understood by the gockle fakes.
+package cql
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Kind is the kind of a Token.
+type Kind int
+
+// Kinds of tokens.
+const (
+	EOF Kind = iota
+	Ident
+	QuotedIdent
+	String
+	Integer
+	Float
+	Blob
+	UUID
+	Bind
+	Punct
+)
+
+// Token is a lexical token of a statement.
+type Token struct {
+	Kind Kind
+
+	// Text is the token text. Identifiers are lowercased, quoted identifiers and
+	// strings are unquoted, and named bind markers keep their name without the
+	// colon.
+	Text string
+
+	// Pos is the byte offset of the token in the statement.
+	Pos int
+
+	// End is the byte offset just past the token in the statement.
+	End int
+}
+
+// Is returns whether t is the keyword or punctuation s.
+func (t Token) Is(s string) bool {
+	return (t.Kind == Ident || t.Kind == Punct) && t.Text == s
+}
+
+var uuidPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}`)
+
+var puncts = []string{"<=", ">=", "!=", "+=", "-=", "(", ")", ",", ";", ".", "*", "=", "<", ">", "+", "-", "[", "]", "{", "}", ":", "?"}
+
+// Lex splits statement into tokens. The last token is always EOF.
+func Lex(statement string) ([]Token, error) {
+	var ts []Token
+	var i = 0
+
+	for i < len(statement) {
+		var c = statement[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case strings.HasPrefix(statement[i:], "--") || strings.HasPrefix(statement[i:], "//"):
+			for i < len(statement) && statement[i] != '\n' {
+				i++
+			}
+
+		case strings.HasPrefix(statement[i:], "/*"):
+			var end = strings.Index(statement[i+2:], "*/")
+
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment at %v", i)
+			}
+
+			i += end + 4
+
+		case c == '\'' || c == '"':
+			var text, end, err = quoted(statement, i)
+
+			if err != nil {
+				return nil, err
+			}
+
+			var k = String
+
+			if c == '"' {
+				k = QuotedIdent
+			}
+
+			ts = append(ts, Token{Kind: k, Text: text, Pos: i, End: end})
+			i = end
+
+		case c == '$' && strings.HasPrefix(statement[i:], "$$"):
+			var end = strings.Index(statement[i+2:], "$$")
+
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at %v", i)
+			}
+
+			ts = append(ts, Token{Kind: String, Text: statement[i+2 : i+2+end], Pos: i, End: i + end + 4})
+			i += end + 4
+
+		case uuidPattern.MatchString(statement[i:]):
+			var m = uuidPattern.FindString(statement[i:])
+
+			ts = append(ts, Token{Kind: UUID, Text: strings.ToLower(m), Pos: i, End: i + len(m)})
+			i += len(m)
+
+		case c == '0' && i+1 < len(statement) && (statement[i+1] == 'x' || statement[i+1] == 'X'):
+			var j = i + 2
+
+			for j < len(statement) && isHex(statement[j]) {
+				j++
+			}
+
+			ts = append(ts, Token{Kind: Blob, Text: strings.ToLower(statement[i:j]), Pos: i, End: j})
+			i = j
+
+		case isDigit(c) || (c == '-' && i+1 < len(statement) && isDigit(statement[i+1]) && negativeAllowed(ts)):
+			var j, k = number(statement, i)
+
+			ts = append(ts, Token{Kind: k, Text: statement[i:j], Pos: i, End: j})
+			i = j
+
+		case c == ':' && i+1 < len(statement) && isIdentStart(rune(statement[i+1])):
+			var j = i + 1
+
+			for j < len(statement) && isIdentPart(rune(statement[j])) {
+				j++
+			}
+
+			ts = append(ts, Token{Kind: Bind, Text: strings.ToLower(statement[i+1 : j]), Pos: i, End: j})
+			i = j
+
+		case c == '?':
+			ts = append(ts, Token{Kind: Bind, Pos: i, End: i + 1})
+			i++
+
+		case isIdentStart(rune(c)):
+			var j = i
+
+			for j < len(statement) && isIdentPart(rune(statement[j])) {
+				j++
+			}
+
+			ts = append(ts, Token{Kind: Ident, Text: strings.ToLower(statement[i:j]), Pos: i, End: j})
+			i = j
+
+		default:
+			var p = punct(statement[i:])
+
+			if p == "" {
+				return nil, fmt.Errorf("unexpected character %q at %v", c, i)
+			}
+
+			ts = append(ts, Token{Kind: Punct, Text: p, Pos: i, End: i + len(p)})
+			i += len(p)
+		}
+	}
+
+	return append(ts, Token{Kind: EOF, Pos: len(statement), End: len(statement)}), nil
+}
+
+// TopLevel returns the indexes of the tokens of ts outside parentheses,
+// brackets, and braces, which it leaves out too.
+func TopLevel(ts []Token) []int {
+	var is []int
+	var depth int
+
+	for i, t := range ts {
+		switch {
+		case t.Is("(") || t.Is("[") || t.Is("{"):
+			depth++
+
+		case t.Is(")") || t.Is("]") || t.Is("}"):
+			depth--
+
+		case depth == 0:
+			is = append(is, i)
+		}
+	}
+
+	return is
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHex(c byte) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || unicode.IsDigit(r)
+}
+
+// negativeAllowed returns whether a minus sign after ts starts a negative
+// number rather than being a binary operator.
+func negativeAllowed(ts []Token) bool {
+	if len(ts) == 0 {
+		return true
+	}
+
+	var t = ts[len(ts)-1]
+
+	return t.Kind == Punct && t.Text != ")" && t.Text != "]" && t.Text != "}"
+}
+
+func number(s string, i int) (int, Kind) {
+	var j, k = i, Integer
+
+	if s[j] == '-' {
+		j++
+	}
+
+	for j < len(s) && isDigit(s[j]) {
+		j++
+	}
+
+	if j+1 < len(s) && s[j] == '.' && isDigit(s[j+1]) {
+		k = Float
+		j++
+
+		for j < len(s) && isDigit(s[j]) {
+			j++
+		}
+	}
+
+	if j < len(s) && (s[j] == 'e' || s[j] == 'E') {
+		var e = j + 1
+
+		if e < len(s) && (s[e] == '+' || s[e] == '-') {
+			e++
+		}
+
+		if e < len(s) && isDigit(s[e]) {
+			k = Float
+			j = e
+
+			for j < len(s) && isDigit(s[j]) {
+				j++
+			}
+		}
+	}
+
+	return j, k
+}
+
+func quoted(s string, i int) (string, int, error) {
+	var q = s[i]
+	var b strings.Builder
+	var j = i + 1
+
+	for j < len(s) {
+		if s[j] == q {
+			if j+1 < len(s) && s[j+1] == q {
+				b.WriteByte(q)
+				j += 2
+
+				continue
+			}
+
+			return b.String(), j + 1, nil
+		}
+
+		b.WriteByte(s[j])
+		j++
+	}
+
+	return "", 0, fmt.Errorf("unterminated string at %v", i)
+}
+
+func punct(s string) string {
+	for _, p := range puncts {
+		if strings.HasPrefix(s, p) {
+			return p
+		}
+	}
+
+	return ""
+}
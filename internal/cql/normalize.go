@@ -0,0 +1,100 @@
+package cql
+
+import "strings"
+
+// Normalize returns statement with unquoted identifiers and keywords
+// lowercase, bind markers as ?, and whitespace, comments, and a final
+// semicolon dropped. Literals are kept as written if keepLiterals, and are ?
+// otherwise, with lists of them after IN as one ?.
+func Normalize(statement string, keepLiterals bool) (string, error) {
+	var ts, err = Lex(statement)
+
+	if err != nil {
+		return "", err
+	}
+
+	var b strings.Builder
+	var prev string
+
+	var write = func(text string) {
+		switch {
+		case b.Len() == 0, prev == "(", prev == ".", prev == "[", text == ")", text == ",", text == ".", text == "[", text == "]":
+
+		default:
+			b.WriteByte(' ')
+		}
+
+		b.WriteString(text)
+		prev = text
+	}
+
+	for i := 0; i < len(ts) && ts[i].Kind != EOF; i++ {
+		var t = ts[i]
+
+		switch t.Kind {
+		case String, Integer, Float, Blob, UUID:
+			if keepLiterals {
+				write(statement[t.Pos:t.End])
+			} else {
+				write("?")
+			}
+
+		case Bind:
+			write("?")
+
+		case QuotedIdent:
+			write(`"` + strings.ReplaceAll(t.Text, `"`, `""`) + `"`)
+
+		case Punct:
+			if t.Text == ";" && ts[i+1].Kind == EOF {
+				continue
+			}
+
+			write(t.Text)
+
+		default:
+			write(t.Text)
+
+			if keepLiterals || !t.Is("in") {
+				continue
+			}
+
+			if end, ok := valueList(ts, i+1); ok {
+				write("(")
+				write("?")
+				write(")")
+				i = end
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// valueList returns the index of the closing parenthesis of a list of literals
+// and bind markers that starts at ts[i], and whether there is one.
+func valueList(ts []Token, i int) (int, bool) {
+	if !ts[i].Is("(") {
+		return 0, false
+	}
+
+	for j := i + 1; j < len(ts); j++ {
+		switch ts[j].Kind {
+		case String, Integer, Float, Blob, UUID, Bind:
+
+		case Punct:
+			if ts[j].Is(")") {
+				return j, j > i+1
+			}
+
+			if !ts[j].Is(",") {
+				return 0, false
+			}
+
+		default:
+			return 0, false
+		}
+	}
+
+	return 0, false
+}
@@ -0,0 +1,914 @@
+package cql
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Error is a syntax error.
+type Error struct {
+	Statement string
+	Pos       int
+	Message   string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line 1:%v %v", e.Pos, e.Message)
+}
+
+// Parse parses statement. A trailing semicolon is allowed.
+func Parse(statement string) (Statement, error) {
+	var ts, err = Lex(statement)
+
+	if err != nil {
+		return nil, &Error{Statement: statement, Message: err.Error()}
+	}
+
+	var p = &parser{s: statement, ts: ts}
+
+	return p.parse()
+}
+
+// Markers returns the number of bind markers in statement.
+func Markers(statement string) (int, error) {
+	var ts, err = Lex(statement)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var n = 0
+
+	for _, t := range ts {
+		if t.Kind == Bind {
+			n++
+		}
+	}
+
+	return n, nil
+}
+
+type parser struct {
+	s  string
+	ts []Token
+	i  int
+	m  int
+}
+
+type bail struct {
+	err *Error
+}
+
+// ParseType parses the data type s, like map<text, frozen<list<int>>>. Frozen
+// types parse as the types they freeze.
+func ParseType(s string) (t Type, err error) {
+	var ts, lerr = Lex(s)
+
+	if lerr != nil {
+		return Type{}, &Error{Statement: s, Message: lerr.Error()}
+	}
+
+	var p = &parser{s: s, ts: ts}
+
+	defer catch(&err)
+
+	t = p.dataType()
+	p.end()
+
+	return t, nil
+}
+
+// catch recovers a bail and sets err to its error.
+func catch(err *error) {
+	if r := recover(); r != nil {
+		var b, ok = r.(bail)
+
+		if !ok {
+			panic(r)
+		}
+
+		*err = b.err
+	}
+}
+
+func (p *parser) parse() (s Statement, err error) {
+	defer catch(&err)
+
+	s = p.statement()
+	p.accept(";")
+	p.end()
+
+	return s, nil
+}
+
+func (p *parser) end() {
+	if p.peek().Kind != EOF {
+		p.fail("unexpected %v", p.describe(p.peek()))
+	}
+}
+
+func (p *parser) statement() Statement {
+	var t = p.next()
+
+	switch {
+	case t.Is("create"):
+		switch {
+		case p.accept("keyspace"):
+			return p.createKeyspace()
+		case p.accept("table") || p.accept("columnfamily"):
+			return p.createTable()
+		}
+
+	case t.Is("drop"):
+		switch {
+		case p.accept("keyspace"):
+			var d = &DropKeyspace{}
+
+			d.IfExists = p.acceptAll("if", "exists")
+			d.Name = p.name()
+
+			return d
+
+		case p.accept("table") || p.accept("columnfamily"):
+			var d = &DropTable{}
+
+			d.IfExists = p.acceptAll("if", "exists")
+			d.Keyspace, d.Name = p.qualified()
+
+			return d
+		}
+
+	case t.Is("truncate"):
+		p.accept("table")
+
+		var tr = &Truncate{}
+
+		tr.Keyspace, tr.Name = p.qualified()
+
+		return tr
+
+	case t.Is("use"):
+		return &Use{Keyspace: p.name()}
+
+	case t.Is("insert"):
+		return p.insert()
+
+	case t.Is("select"):
+		return p.selectStatement()
+
+	case t.Is("update"):
+		return p.update()
+
+	case t.Is("delete"):
+		return p.delete()
+
+	case t.Is("begin"):
+		return p.batch()
+	}
+
+	p.i--
+	p.fail("unsupported statement %v", p.describe(t))
+
+	return nil
+}
+
+func (p *parser) createKeyspace() Statement {
+	var c = &CreateKeyspace{DurableWrites: true}
+
+	c.IfNotExists = p.acceptAll("if", "not", "exists")
+	c.Name = p.name()
+
+	if p.accept("with") {
+		for {
+			var o = p.name()
+
+			p.expect("=")
+
+			switch o {
+			case "replication":
+				c.Replication = map[string]string{}
+
+				var m, ok = p.literal().(MapLiteral)
+
+				if !ok {
+					p.fail("replication must be a map")
+				}
+
+				for i, k := range m.Keys {
+					c.Replication[literalString(k)] = literalString(m.Values[i])
+				}
+
+			case "durable_writes":
+				c.DurableWrites = literalString(p.literal()) == "true"
+
+			default:
+				p.literal()
+			}
+
+			if !p.accept("and") {
+				break
+			}
+		}
+	}
+
+	return c
+}
+
+func (p *parser) createTable() Statement {
+	var c = &CreateTable{Descending: map[string]bool{}, Options: map[string]string{}}
+
+	c.IfNotExists = p.acceptAll("if", "not", "exists")
+	c.Keyspace, c.Name = p.qualified()
+	p.expect("(")
+
+	for {
+		if p.acceptAll("primary", "key") {
+			p.expect("(")
+
+			if p.accept("(") {
+				c.PartitionKey = p.names()
+				p.expect(")")
+			} else {
+				c.PartitionKey = []string{p.name()}
+			}
+
+			if p.accept(",") {
+				c.Clustering = p.names()
+			}
+
+			p.expect(")")
+		} else {
+			var d = ColumnDef{Name: p.name(), Type: p.dataType()}
+
+			d.Static = p.accept("static")
+
+			if p.acceptAll("primary", "key") {
+				c.PartitionKey = []string{d.Name}
+			}
+
+			c.Columns = append(c.Columns, d)
+		}
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	p.expect(")")
+
+	if len(c.PartitionKey) == 0 {
+		p.fail("no primary key specified for table %v", c.Name)
+	}
+
+	if p.accept("with") {
+		for {
+			switch {
+			case p.acceptAll("clustering", "order", "by"):
+				p.expect("(")
+
+				for {
+					var n = p.name()
+
+					if p.accept("desc") {
+						c.Descending[n] = true
+					} else {
+						p.accept("asc")
+					}
+
+					if !p.accept(",") {
+						break
+					}
+				}
+
+				p.expect(")")
+
+			case p.acceptAll("compact", "storage"):
+				c.Options["compact_storage"] = "true"
+
+			default:
+				var n = p.name()
+
+				p.expect("=")
+				c.Options[n] = termString(p.literal())
+			}
+
+			if !p.accept("and") {
+				break
+			}
+		}
+	}
+
+	return c
+}
+
+func (p *parser) insert() Statement {
+	var in = &Insert{}
+
+	p.expect("into")
+	in.Keyspace, in.Table = p.qualified()
+	p.expect("(")
+	in.Columns = p.names()
+	p.expect(")")
+	p.expect("values")
+	p.expect("(")
+
+	for {
+		in.Values = append(in.Values, p.term())
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	p.expect(")")
+
+	if len(in.Columns) != len(in.Values) {
+		p.fail("unmatched column names/values")
+	}
+
+	in.IfNotExists = p.acceptAll("if", "not", "exists")
+	in.Using = p.using()
+
+	if !in.IfNotExists {
+		in.IfNotExists = p.acceptAll("if", "not", "exists")
+	}
+
+	return in
+}
+
+func (p *parser) selectStatement() Statement {
+	var s = &Select{}
+
+	s.Distinct = p.accept("distinct")
+
+	if p.accept("*") {
+		s.Selectors = []Selector{{Column: "*"}}
+	} else {
+		for {
+			var sel Selector
+			var n = p.name()
+
+			if p.accept("(") {
+				sel.Function = n
+
+				if p.accept("*") {
+					sel.Column = "*"
+				} else if p.peek().Kind == Integer {
+					p.next()
+					sel.Column = "*"
+				} else {
+					sel.Column = p.name()
+				}
+
+				p.expect(")")
+			} else {
+				sel.Column = n
+			}
+
+			if p.accept("as") {
+				sel.Alias = p.name()
+			}
+
+			s.Selectors = append(s.Selectors, sel)
+
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	p.expect("from")
+	s.Keyspace, s.Table = p.qualified()
+
+	if p.accept("where") {
+		s.Where = p.relations()
+	}
+
+	if p.acceptAll("order", "by") {
+		for {
+			var o = Ordering{Column: p.name()}
+
+			if p.accept("desc") {
+				o.Desc = true
+			} else {
+				p.accept("asc")
+			}
+
+			s.OrderBy = append(s.OrderBy, o)
+
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.acceptAll("per", "partition", "limit") {
+		s.PerPartitionLimit = p.term()
+	}
+
+	if p.accept("limit") {
+		s.Limit = p.term()
+	}
+
+	s.AllowFiltering = p.acceptAll("allow", "filtering")
+
+	return s
+}
+
+func (p *parser) update() Statement {
+	var u = &Update{}
+
+	u.Keyspace, u.Table = p.qualified()
+	u.Using = p.using()
+	p.expect("set")
+
+	for {
+		var a = Assignment{Column: p.name(), Op: "="}
+
+		switch {
+		case p.accept("["):
+			a.Op = "index"
+			a.Key = p.term()
+			p.expect("]")
+			p.expect("=")
+			a.Value = p.term()
+
+		case p.accept("+="):
+			a.Op = "+"
+			a.Value = p.term()
+
+		case p.accept("-="):
+			a.Op = "-"
+			a.Value = p.term()
+
+		default:
+			p.expect("=")
+
+			if p.peek().Kind == Ident && p.peek().Text == a.Column && p.peekAt(1).Kind == Punct && (p.peekAt(1).Text == "+" || p.peekAt(1).Text == "-") {
+				p.next()
+				a.Op = p.next().Text
+				a.Value = p.term()
+			} else {
+				a.Value = p.term()
+
+				if p.accept("+") {
+					if n := p.name(); n != a.Column {
+						p.fail("only expressions of the form X = <value> + X are supported")
+					}
+
+					a.Op = "prepend"
+				}
+			}
+		}
+
+		u.Set = append(u.Set, a)
+
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	p.expect("where")
+	u.Where = p.relations()
+	u.IfExists, u.If = p.conditions()
+
+	return u
+}
+
+func (p *parser) delete() Statement {
+	var d = &Delete{}
+
+	if !p.accept("from") {
+		d.Columns = p.names()
+		p.expect("from")
+	}
+
+	d.Keyspace, d.Table = p.qualified()
+	d.Using = p.using()
+	p.expect("where")
+	d.Where = p.relations()
+	d.IfExists, d.If = p.conditions()
+
+	return d
+}
+
+func (p *parser) batch() Statement {
+	var b = &Batch{Kind: "logged"}
+
+	switch {
+	case p.accept("unlogged"):
+		b.Kind = "unlogged"
+	case p.accept("counter"):
+		b.Kind = "counter"
+	case p.accept("logged"):
+	}
+
+	p.expect("batch")
+	b.Using = p.using()
+
+	for !p.acceptAll("apply", "batch") {
+		var t = p.peek()
+
+		if !t.Is("insert") && !t.Is("update") && !t.Is("delete") {
+			p.fail("unexpected %v in batch", p.describe(t))
+		}
+
+		b.Statements = append(b.Statements, p.statement())
+		p.accept(";")
+	}
+
+	return b
+}
+
+func (p *parser) using() Using {
+	var u Using
+
+	if !p.accept("using") {
+		return u
+	}
+
+	for {
+		switch {
+		case p.accept("ttl"):
+			u.TTL = p.term()
+		case p.accept("timestamp"):
+			u.Timestamp = p.term()
+		default:
+			p.fail("unexpected %v in USING", p.describe(p.peek()))
+		}
+
+		if !p.accept("and") {
+			break
+		}
+	}
+
+	return u
+}
+
+func (p *parser) conditions() (bool, []Relation) {
+	if !p.accept("if") {
+		return false, nil
+	}
+
+	if p.accept("exists") {
+		return true, nil
+	}
+
+	return false, p.relations()
+}
+
+func (p *parser) relations() []Relation {
+	var rs []Relation
+
+	for {
+		var r Relation
+
+		switch {
+		case p.accept("token"):
+			r.Token = true
+			p.expect("(")
+			r.Columns = p.names()
+			p.expect(")")
+
+		default:
+			r.Columns = []string{p.name()}
+		}
+
+		var t = p.next()
+
+		switch {
+		case t.Is("in"):
+			r.Op = "in"
+
+			if p.accept("(") {
+				if !p.accept(")") {
+					for {
+						r.Values = append(r.Values, p.term())
+
+						if !p.accept(",") {
+							break
+						}
+					}
+
+					p.expect(")")
+				}
+			} else {
+				r.Value = p.term()
+			}
+
+		case t.Is("contains"):
+			r.Op = "contains"
+
+			if p.accept("key") {
+				r.Op = "contains key"
+			}
+
+			r.Value = p.term()
+
+		case t.Is("=") || t.Is("<") || t.Is("<=") || t.Is(">") || t.Is(">=") || t.Is("!="):
+			r.Op = t.Text
+			r.Value = p.term()
+
+		default:
+			p.i--
+			p.fail("unexpected %v in relation", p.describe(t))
+		}
+
+		rs = append(rs, r)
+
+		if !p.accept("and") {
+			return rs
+		}
+	}
+}
+
+func (p *parser) term() Term {
+	var t = p.peek()
+
+	if t.Kind == Bind {
+		p.next()
+
+		var m = Marker{Index: p.m, Name: t.Text}
+
+		p.m++
+
+		return m
+	}
+
+	if t.Kind == Ident && p.peekAt(1).Is("(") && !t.Is("null") && !t.Is("true") && !t.Is("false") {
+		var c = Call{Name: p.next().Text}
+
+		p.expect("(")
+
+		if !p.accept(")") {
+			for {
+				c.Args = append(c.Args, p.term())
+
+				if !p.accept(",") {
+					break
+				}
+			}
+
+			p.expect(")")
+		}
+
+		return c
+	}
+
+	return p.literal()
+}
+
+func (p *parser) literal() Term {
+	var t = p.next()
+
+	switch t.Kind {
+	case String:
+		return Literal{Value: t.Text, Kind: String}
+
+	case Integer:
+		var n, err = strconv.ParseInt(t.Text, 10, 64)
+
+		if err != nil {
+			var f, _ = strconv.ParseFloat(t.Text, 64)
+
+			return Literal{Value: f, Kind: Float}
+		}
+
+		return Literal{Value: n, Kind: Integer}
+
+	case Float:
+		var f, _ = strconv.ParseFloat(t.Text, 64)
+
+		return Literal{Value: f, Kind: Float}
+
+	case Blob:
+		var b, err = hex.DecodeString(t.Text[2:])
+
+		if err != nil {
+			p.i--
+			p.fail("invalid blob %v", t.Text)
+		}
+
+		return Literal{Value: b, Kind: Blob}
+
+	case UUID:
+		return Literal{Value: t.Text, Kind: UUID}
+
+	case Bind:
+		p.i--
+
+		return p.term()
+
+	case Ident:
+		switch t.Text {
+		case "null":
+			return Literal{Kind: Ident}
+		case "true":
+			return Literal{Value: true, Kind: Ident}
+		case "false":
+			return Literal{Value: false, Kind: Ident}
+		case "nan":
+			return Literal{Value: math.NaN(), Kind: Float}
+		case "infinity":
+			return Literal{Value: math.Inf(1), Kind: Float}
+		}
+
+	case Punct:
+		switch t.Text {
+		case "[":
+			var l = ListLiteral{}
+
+			if !p.accept("]") {
+				for {
+					l.Elems = append(l.Elems, p.term())
+
+					if !p.accept(",") {
+						break
+					}
+				}
+
+				p.expect("]")
+			}
+
+			return l
+
+		case "{":
+			if p.accept("}") {
+				return SetLiteral{}
+			}
+
+			var first = p.term()
+
+			if p.accept(":") {
+				var m = MapLiteral{Keys: []Term{first}, Values: []Term{p.term()}}
+
+				for p.accept(",") {
+					m.Keys = append(m.Keys, p.term())
+					p.expect(":")
+					m.Values = append(m.Values, p.term())
+				}
+
+				p.expect("}")
+
+				return m
+			}
+
+			var s = SetLiteral{Elems: []Term{first}}
+
+			for p.accept(",") {
+				s.Elems = append(s.Elems, p.term())
+			}
+
+			p.expect("}")
+
+			return s
+		}
+	}
+
+	p.i--
+	p.fail("unexpected %v, expected a value", p.describe(t))
+
+	return nil
+}
+
+func (p *parser) dataType() Type {
+	var t = Type{Name: p.name()}
+
+	if p.accept("<") {
+		for {
+			t.Args = append(t.Args, p.dataType())
+
+			if !p.accept(",") {
+				break
+			}
+		}
+
+		p.expect(">")
+	}
+
+	if t.Name == "frozen" && len(t.Args) == 1 {
+		return t.Args[0]
+	}
+
+	return t
+}
+
+func (p *parser) qualified() (string, string) {
+	var n = p.name()
+
+	if p.accept(".") {
+		return n, p.name()
+	}
+
+	return "", n
+}
+
+func (p *parser) names() []string {
+	var ns = []string{p.name()}
+
+	for p.accept(",") {
+		ns = append(ns, p.name())
+	}
+
+	return ns
+}
+
+func (p *parser) name() string {
+	var t = p.next()
+
+	if t.Kind != Ident && t.Kind != QuotedIdent {
+		p.i--
+		p.fail("unexpected %v, expected a name", p.describe(t))
+	}
+
+	return t.Text
+}
+
+func (p *parser) peek() Token {
+	return p.peekAt(0)
+}
+
+func (p *parser) peekAt(n int) Token {
+	if p.i+n >= len(p.ts) {
+		return p.ts[len(p.ts)-1]
+	}
+
+	return p.ts[p.i+n]
+}
+
+func (p *parser) next() Token {
+	var t = p.peek()
+
+	p.i++
+
+	return t
+}
+
+func (p *parser) accept(s string) bool {
+	if p.peek().Is(s) {
+		p.i++
+
+		return true
+	}
+
+	return false
+}
+
+func (p *parser) acceptAll(ss ...string) bool {
+	for i, s := range ss {
+		if !p.peekAt(i).Is(s) {
+			return false
+		}
+	}
+
+	p.i += len(ss)
+
+	return true
+}
+
+func (p *parser) expect(s string) {
+	if !p.accept(s) {
+		p.fail("unexpected %v, expected %v", p.describe(p.peek()), strings.ToUpper(s))
+	}
+}
+
+func (p *parser) describe(t Token) string {
+	if t.Kind == EOF {
+		return "end of statement"
+	}
+
+	return fmt.Sprintf("%q", p.s[t.Pos:t.End])
+}
+
+func (p *parser) fail(format string, args ...interface{}) {
+	panic(bail{err: &Error{Statement: p.s, Pos: p.peek().Pos, Message: fmt.Sprintf(format, args...)}})
+}
+
+func literalString(t Term) string {
+	if l, ok := t.(Literal); ok {
+		return fmt.Sprint(l.Value)
+	}
+
+	return ""
+}
+
+func termString(t Term) string {
+	switch t := t.(type) {
+	case Literal:
+		return fmt.Sprint(t.Value)
+
+	case MapLiteral:
+		var ss []string
+
+		for i, k := range t.Keys {
+			ss = append(ss, fmt.Sprintf("'%v': '%v'", literalString(k), literalString(t.Values[i])))
+		}
+
+		return "{" + strings.Join(ss, ", ") + "}"
+	}
+
+	return ""
+}
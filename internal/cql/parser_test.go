@@ -0,0 +1,174 @@
+package cql
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLex(t *testing.T) {
+	var ts, err = Lex(`SELECT "Name", 'it''s', -1.5e3, 0xCAFE, 123e4567-e89b-12d3-a456-426614174000 FROM t WHERE a >= ? -- comment`)
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var a []Kind
+
+	for _, tok := range ts {
+		a = append(a, tok.Kind)
+	}
+
+	var e = []Kind{Ident, QuotedIdent, Punct, String, Punct, Float, Punct, Blob, Punct, UUID, Ident, Ident, Ident, Ident, Punct, Bind, EOF}
+
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual kinds %v, expected %v", a, e)
+	}
+
+	if a, e := ts[3].Text, "it's"; a != e {
+		t.Errorf("Actual text %v, expected %v", a, e)
+	}
+
+	if _, err := Lex("select 'unterminated"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestTopLevel(t *testing.T) {
+	var ts, err = Lex("update t set m = m + {'a': [1]} where k in (1, 2) if c = 3")
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var a []string
+
+	for _, i := range TopLevel(ts) {
+		a = append(a, ts[i].Text)
+	}
+
+	var e = []string{"update", "t", "set", "m", "=", "m", "+", "where", "k", "in", "if", "c", "=", "3", ""}
+
+	if !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual tokens %v, expected %v", a, e)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	for _, c := range []struct {
+		statement    string
+		keepLiterals bool
+		expected     string
+	}{
+		{"SELECT * FROM ks.t WHERE id IN (1, :b) AND n = 'x';", true, "select * from ks.t where id in (1, ?) and n = 'x'"},
+		{"SELECT * FROM ks.t WHERE id IN (1, :b) AND n = 'x';", false, "select * from ks.t where id in (?) and n = ?"},
+	} {
+		var a, err = Normalize(c.statement, c.keepLiterals)
+
+		if err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		if a != c.expected {
+			t.Errorf("Actual normalized %q, expected %q", a, c.expected)
+		}
+	}
+
+	if _, err := Normalize("select 'unterminated", true); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestParse(t *testing.T) {
+	for _, c := range []struct {
+		statement string
+		expected  Statement
+	}{
+		{
+			"create keyspace if not exists k with replication = {'class': 'SimpleStrategy', 'replication_factor': 1} and durable_writes = false",
+			&CreateKeyspace{Name: "k", IfNotExists: true, Replication: map[string]string{"class": "SimpleStrategy", "replication_factor": "1"}},
+		},
+		{
+			"CREATE TABLE k.t (a int, b text, c map<text, frozen<list<int>>>, PRIMARY KEY ((a), b)) WITH CLUSTERING ORDER BY (b DESC) AND comment = 'x'",
+			&CreateTable{
+				Keyspace: "k",
+				Name:     "t",
+				Columns: []ColumnDef{
+					{Name: "a", Type: Type{Name: "int"}},
+					{Name: "b", Type: Type{Name: "text"}},
+					{Name: "c", Type: Type{Name: "map", Args: []Type{{Name: "text"}, {Name: "list", Args: []Type{{Name: "int"}}}}}},
+				},
+				PartitionKey: []string{"a"},
+				Clustering:   []string{"b"},
+				Descending:   map[string]bool{"b": true},
+				Options:      map[string]string{"comment": "x"},
+			},
+		},
+		{
+			"insert into t (a, b) values (?, 'x') if not exists using ttl 10",
+			&Insert{Table: "t", Columns: []string{"a", "b"}, Values: []Term{Marker{}, Literal{Value: "x", Kind: String}}, IfNotExists: true, Using: Using{TTL: Literal{Value: int64(10), Kind: Integer}}},
+		},
+		{
+			"select a, count(*) as n from k.t where a in (1, 2) and b > ? order by b desc limit 3 allow filtering;",
+			&Select{
+				Keyspace:       "k",
+				Table:          "t",
+				Selectors:      []Selector{{Column: "a"}, {Column: "*", Function: "count", Alias: "n"}},
+				Where:          []Relation{{Columns: []string{"a"}, Op: "in", Values: []Term{Literal{Value: int64(1), Kind: Integer}, Literal{Value: int64(2), Kind: Integer}}}, {Columns: []string{"b"}, Op: ">", Value: Marker{}}},
+				OrderBy:        []Ordering{{Column: "b", Desc: true}},
+				Limit:          Literal{Value: int64(3), Kind: Integer},
+				AllowFiltering: true,
+			},
+		},
+		{
+			"update t set c = c + ?, l = [1] + l, m['k'] = ? where a = ? if c = 1",
+			&Update{
+				Table: "t",
+				Set: []Assignment{
+					{Column: "c", Op: "+", Value: Marker{Index: 0}},
+					{Column: "l", Op: "prepend", Value: ListLiteral{Elems: []Term{Literal{Value: int64(1), Kind: Integer}}}},
+					{Column: "m", Op: "index", Key: Literal{Value: "k", Kind: String}, Value: Marker{Index: 1}},
+				},
+				Where: []Relation{{Columns: []string{"a"}, Op: "=", Value: Marker{Index: 2}}},
+				If:    []Relation{{Columns: []string{"c"}, Op: "=", Value: Literal{Value: int64(1), Kind: Integer}}},
+			},
+		},
+		{
+			"delete b from t where a = :a if exists",
+			&Delete{Table: "t", Columns: []string{"b"}, Where: []Relation{{Columns: []string{"a"}, Op: "=", Value: Marker{Name: "a"}}}, IfExists: true},
+		},
+	} {
+		var a, err = Parse(c.statement)
+
+		if err != nil {
+			t.Errorf("Actual error %v, expected no error for %v", err, c.statement)
+		} else if !reflect.DeepEqual(a, c.expected) {
+			t.Errorf("Actual statement %#v, expected %#v", a, c.expected)
+		}
+	}
+
+	for _, s := range []string{"", "select", "select * from", "insert into t (a) values (1, 2)", "create table t (a int)", "select * from t extra"} {
+		if _, err := Parse(s); err == nil {
+			t.Errorf("Actual no error, expected error for %q", s)
+		}
+	}
+}
+
+func TestParseType(t *testing.T) {
+	for s, e := range map[string]string{
+		"int":                                 "int",
+		"map<text, frozen<list<int>>>":        "map<text, list<int>>",
+		"frozen<tuple<int, frozen<address>>>": "tuple<int, address>",
+	} {
+		if a, err := ParseType(s); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		} else if a.String() != e {
+			t.Errorf("Actual type %v, expected %v", a, e)
+		}
+	}
+
+	for _, s := range []string{"", "list<int", "int int"} {
+		if _, err := ParseType(s); err == nil {
+			t.Errorf("Actual no error for %q, expected error", s)
+		}
+	}
+}
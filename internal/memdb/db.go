@@ -0,0 +1,472 @@
+// Package memdb is an in-memory store that executes the CQL subset parsed by
+// package cql. It backs the gockle fakes.
+package memdb
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// Error is a request error. It satisfies gocql.RequestError.
+type Error struct {
+	code    int
+	message string
+
+	// Keyspace and Table name what already exists for ErrCodeAlreadyExists.
+	Keyspace string
+	Table    string
+}
+
+// Code returns the native protocol error code.
+func (e *Error) Code() int {
+	return e.code
+}
+
+// Message returns the error message.
+func (e *Error) Message() string {
+	return e.message
+}
+
+func (e *Error) Error() string {
+	return e.message
+}
+
+var _ gocql.RequestError = &Error{}
+
+// Errorf returns an Error with code and a formatted message.
+func Errorf(code int, format string, args ...interface{}) *Error {
+	return &Error{code: code, message: fmt.Sprintf(format, args...)}
+}
+
+func invalidf(format string, args ...interface{}) *Error {
+	return Errorf(gocql.ErrCodeInvalid, format, args...)
+}
+
+// Kinds of columns.
+const (
+	PartitionKey = "partition_key"
+	Clustering   = "clustering"
+	Regular      = "regular"
+	Static       = "static"
+)
+
+// Column is a column of a table or a result.
+type Column struct {
+	Keyspace string
+	Table    string
+	Name     string
+	Type     gocql.TypeInfo
+
+	// CQLType is the type as written in CREATE TABLE.
+	CQLType string
+
+	// Kind is PartitionKey, Clustering, Regular, or Static.
+	Kind string
+
+	// Position is the position of a key column within its key, or -1.
+	Position int
+
+	// Desc is whether a clustering column is in descending order.
+	Desc bool
+}
+
+// Table is table metadata. Tables are never modified once they are returned.
+type Table struct {
+	Keyspace string
+	Name     string
+
+	// Columns are ordered like SELECT *: partition key, clustering key, then the
+	// other columns by name.
+	Columns []*Column
+
+	PartitionKey []string
+	Clustering   []string
+	Options      map[string]string
+
+	columns map[string]*Column
+}
+
+// Column returns the column name or nil.
+func (t *Table) Column(name string) *Column {
+	return t.columns[name]
+}
+
+// Keyspace is keyspace metadata. Keyspaces are never modified once they are
+// returned.
+type Keyspace struct {
+	Name          string
+	DurableWrites bool
+	Replication   map[string]string
+	Tables        map[string]*Table
+}
+
+// TableNames returns the table names in order.
+func (k *Keyspace) TableNames() []string {
+	var ns = make([]string, 0, len(k.Tables))
+
+	for n := range k.Tables {
+		ns = append(ns, n)
+	}
+
+	sort.Strings(ns)
+
+	return ns
+}
+
+// Change describes a schema change made by a statement.
+type Change struct {
+	// Kind is CREATED, UPDATED, or DROPPED.
+	Kind string
+
+	// Target is KEYSPACE or TABLE.
+	Target string
+
+	Keyspace string
+	Table    string
+}
+
+// Result is the result of a statement.
+type Result struct {
+	Columns []Column
+	Rows    [][]interface{}
+
+	// Keyspace is set by USE.
+	Keyspace string
+
+	// Change is set by schema statements that changed the schema.
+	Change *Change
+}
+
+// DB is an in-memory database. It is safe for concurrent use.
+type DB struct {
+	mu        sync.Mutex
+	keyspaces map[string]*Keyspace
+	data      map[string]*tableData
+}
+
+// New returns a DB with only the system keyspaces.
+func New() *DB {
+	var db = &DB{keyspaces: map[string]*Keyspace{}, data: map[string]*tableData{}}
+
+	db.createSystem()
+
+	return db
+}
+
+// Keyspace returns the metadata for keyspace name, or nil.
+func (db *DB) Keyspace(name string) *Keyspace {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	return db.keyspaces[name]
+}
+
+// Keyspaces returns the keyspace names in order.
+func (db *DB) Keyspaces() []string {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var ns = make([]string, 0, len(db.keyspaces))
+
+	for n := range db.keyspaces {
+		ns = append(ns, n)
+	}
+
+	sort.Strings(ns)
+
+	return ns
+}
+
+// Exec executes statement with values. Keyspace is the keyspace for
+// unqualified table names.
+func (db *DB) Exec(keyspace, statement string, values []interface{}) (*Result, error) {
+	var s, err = parse(statement)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var e = &exec{db: db, keyspace: keyspace, values: values}
+	var r *Result
+
+	if r, err = e.statement(s); err != nil {
+		return nil, err
+	}
+
+	if r.Change != nil {
+		db.refreshSchema()
+	}
+
+	return r, nil
+}
+
+// Entry is a statement in a batch.
+type Entry struct {
+	Statement string
+	Values    []interface{}
+}
+
+// Batch executes entries atomically. If any of them has a condition, either
+// all conditions hold and every entry is applied, or none are.
+func (db *DB) Batch(keyspace string, entries []Entry) (*Result, error) {
+	var ss = make([]cql.Statement, len(entries))
+
+	for i, en := range entries {
+		var s, err = parse(en.Statement)
+
+		if err != nil {
+			return nil, err
+		}
+
+		switch s.(type) {
+		case *cql.Insert, *cql.Update, *cql.Delete:
+		default:
+			return nil, invalidf("only INSERT, UPDATE, and DELETE statements are allowed in a batch")
+		}
+
+		ss[i] = s
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var es = make([]*exec, len(entries))
+
+	for i, en := range entries {
+		es[i] = &exec{db: db, keyspace: keyspace, values: en.Values}
+	}
+
+	return batch(es, ss)
+}
+
+func parse(statement string) (cql.Statement, error) {
+	var s, err = cql.Parse(statement)
+
+	if err != nil {
+		return nil, Errorf(gocql.ErrCodeSyntax, "%v", err)
+	}
+
+	return s, nil
+}
+
+func (db *DB) table(keyspace, name string) (*Table, *tableData, error) {
+	if keyspace == "" {
+		return nil, nil, invalidf("no keyspace has been specified")
+	}
+
+	var k, ok = db.keyspaces[keyspace]
+
+	if !ok {
+		return nil, nil, invalidf("keyspace %v does not exist", keyspace)
+	}
+
+	t, ok := k.Tables[name]
+
+	if !ok {
+		return nil, nil, invalidf("unconfigured table %v", name)
+	}
+
+	return t, db.data[keyspace+"."+name], nil
+}
+
+func (db *DB) createKeyspace(c *cql.CreateKeyspace) (*Result, error) {
+	if _, ok := db.keyspaces[c.Name]; ok {
+		if c.IfNotExists {
+			return &Result{}, nil
+		}
+
+		var err = Errorf(gocql.ErrCodeAlreadyExists, "keyspace %v already exists", c.Name)
+
+		err.Keyspace = c.Name
+
+		return nil, err
+	}
+
+	if c.Replication["class"] == "" {
+		return nil, Errorf(gocql.ErrCodeConfig, "missing mandatory replication strategy class")
+	}
+
+	db.keyspaces[c.Name] = &Keyspace{Name: c.Name, DurableWrites: c.DurableWrites, Replication: c.Replication, Tables: map[string]*Table{}}
+
+	return &Result{Change: &Change{Kind: "CREATED", Target: "KEYSPACE", Keyspace: c.Name}}, nil
+}
+
+func (db *DB) dropKeyspace(d *cql.DropKeyspace) (*Result, error) {
+	var k, ok = db.keyspaces[d.Name]
+
+	if !ok {
+		if d.IfExists {
+			return &Result{}, nil
+		}
+
+		return nil, Errorf(gocql.ErrCodeConfig, "cannot drop non existing keyspace '%v'", d.Name)
+	}
+
+	for n := range k.Tables {
+		delete(db.data, d.Name+"."+n)
+	}
+
+	delete(db.keyspaces, d.Name)
+
+	return &Result{Change: &Change{Kind: "DROPPED", Target: "KEYSPACE", Keyspace: d.Name}}, nil
+}
+
+func (db *DB) createTable(keyspace string, c *cql.CreateTable) (*Result, error) {
+	if c.Keyspace != "" {
+		keyspace = c.Keyspace
+	}
+
+	if keyspace == "" {
+		return nil, invalidf("no keyspace has been specified")
+	}
+
+	var k, ok = db.keyspaces[keyspace]
+
+	if !ok {
+		return nil, invalidf("keyspace %v does not exist", keyspace)
+	}
+
+	if _, ok := k.Tables[c.Name]; ok {
+		if c.IfNotExists {
+			return &Result{}, nil
+		}
+
+		var err = Errorf(gocql.ErrCodeAlreadyExists, "table %v.%v already exists", keyspace, c.Name)
+
+		err.Keyspace, err.Table = keyspace, c.Name
+
+		return nil, err
+	}
+
+	var t, err = newTable(keyspace, c)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ts = map[string]*Table{}
+
+	for n, t := range k.Tables {
+		ts[n] = t
+	}
+
+	ts[c.Name] = t
+
+	var nk = *k
+
+	nk.Tables = ts
+	db.keyspaces[keyspace] = &nk
+	db.data[keyspace+"."+c.Name] = newTableData()
+
+	return &Result{Change: &Change{Kind: "CREATED", Target: "TABLE", Keyspace: keyspace, Table: c.Name}}, nil
+}
+
+func (db *DB) dropTable(keyspace string, d *cql.DropTable) (*Result, error) {
+	if d.Keyspace != "" {
+		keyspace = d.Keyspace
+	}
+
+	var _, _, err = db.table(keyspace, d.Name)
+
+	if err != nil {
+		if d.IfExists {
+			return &Result{}, nil
+		}
+
+		return nil, err
+	}
+
+	var k = db.keyspaces[keyspace]
+	var ts = map[string]*Table{}
+
+	for n, t := range k.Tables {
+		if n != d.Name {
+			ts[n] = t
+		}
+	}
+
+	var nk = *k
+
+	nk.Tables = ts
+	db.keyspaces[keyspace] = &nk
+	delete(db.data, keyspace+"."+d.Name)
+
+	return &Result{Change: &Change{Kind: "DROPPED", Target: "TABLE", Keyspace: keyspace, Table: d.Name}}, nil
+}
+
+func newTable(keyspace string, c *cql.CreateTable) (*Table, error) {
+	var t = &Table{Keyspace: keyspace, Name: c.Name, PartitionKey: c.PartitionKey, Clustering: c.Clustering, Options: c.Options, columns: map[string]*Column{}}
+
+	for _, d := range c.Columns {
+		if _, ok := t.columns[d.Name]; ok {
+			return nil, invalidf("multiple definition of identifier %v", d.Name)
+		}
+
+		var ti, err = TypeInfo(d.Type)
+
+		if err != nil {
+			return nil, err
+		}
+
+		var kind = Regular
+
+		if d.Static {
+			kind = Static
+		}
+
+		t.columns[d.Name] = &Column{Keyspace: keyspace, Table: c.Name, Name: d.Name, Type: ti, CQLType: d.Type.String(), Kind: kind, Position: -1}
+	}
+
+	for i, n := range c.PartitionKey {
+		var col, ok = t.columns[n]
+
+		if !ok {
+			return nil, invalidf("unknown definition %v referenced in PRIMARY KEY", n)
+		}
+
+		col.Kind, col.Position = PartitionKey, i
+	}
+
+	for i, n := range c.Clustering {
+		var col, ok = t.columns[n]
+
+		if !ok {
+			return nil, invalidf("unknown definition %v referenced in PRIMARY KEY", n)
+		}
+
+		col.Kind, col.Position, col.Desc = Clustering, i, c.Descending[n]
+	}
+
+	for n := range c.Descending {
+		if col, ok := t.columns[n]; !ok || col.Kind != Clustering {
+			return nil, invalidf("missing CLUSTERING ORDER for column %v", n)
+		}
+	}
+
+	for _, n := range append(append([]string{}, c.PartitionKey...), c.Clustering...) {
+		t.Columns = append(t.Columns, t.columns[n])
+	}
+
+	var rest []*Column
+
+	for _, col := range t.columns {
+		if col.Kind == Regular || col.Kind == Static {
+			rest = append(rest, col)
+		}
+	}
+
+	sort.Slice(rest, func(i, j int) bool {
+		return rest[i].Name < rest[j].Name
+	})
+
+	t.Columns = append(t.Columns, rest...)
+
+	return t, nil
+}
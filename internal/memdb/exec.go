@@ -0,0 +1,1112 @@
+package memdb
+
+import (
+	"reflect"
+	"sort"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// ColumnApplied is the name of the column that reports whether a conditional
+// statement was applied.
+const ColumnApplied = "[applied]"
+
+var (
+	typeBigInt   = gocql.NewNativeType(protoVersion, gocql.TypeBigInt, "")
+	typeBoolean  = gocql.NewNativeType(protoVersion, gocql.TypeBoolean, "")
+	typeInt      = gocql.NewNativeType(protoVersion, gocql.TypeInt, "")
+	typeTimeUUID = gocql.NewNativeType(protoVersion, gocql.TypeTimeUUID, "")
+)
+
+type row struct {
+	part   string
+	values map[string]interface{}
+}
+
+type tableData struct {
+	rows  map[string]*row
+	parts map[string]int
+	seq   int
+}
+
+func newTableData() *tableData {
+	return &tableData{rows: map[string]*row{}, parts: map[string]int{}}
+}
+
+// sorted returns the rows in partition order, then clustering order.
+func (d *tableData) sorted(t *Table) []*row {
+	var rs = make([]*row, 0, len(d.rows))
+
+	for _, r := range d.rows {
+		rs = append(rs, r)
+	}
+
+	sort.Slice(rs, func(i, j int) bool {
+		if pi, pj := d.parts[rs[i].part], d.parts[rs[j].part]; pi != pj {
+			return pi < pj
+		}
+
+		for _, n := range t.Clustering {
+			var c = compare(rs[i].values[n], rs[j].values[n])
+
+			if t.columns[n].Desc {
+				c = -c
+			}
+
+			if c != 0 {
+				return c < 0
+			}
+		}
+
+		return false
+	})
+
+	return rs
+}
+
+func (d *tableData) put(t *Table, values map[string]interface{}) *row {
+	var part, k = rowKeys(t, values)
+	var r, ok = d.rows[k]
+
+	if !ok {
+		r = &row{part: part, values: map[string]interface{}{}}
+		d.rows[k] = r
+
+		if _, ok := d.parts[part]; !ok {
+			d.seq++
+			d.parts[part] = d.seq
+		}
+	}
+
+	for n, v := range values {
+		r.values[n] = v
+	}
+
+	return r
+}
+
+func (d *tableData) remove(k string) {
+	var r, ok = d.rows[k]
+
+	if !ok {
+		return
+	}
+
+	delete(d.rows, k)
+
+	for _, o := range d.rows {
+		if o.part == r.part {
+			return
+		}
+	}
+
+	delete(d.parts, r.part)
+}
+
+func rowKeys(t *Table, values map[string]interface{}) (string, string) {
+	var pk, ck []interface{}
+
+	for _, n := range t.PartitionKey {
+		pk = append(pk, values[n])
+	}
+
+	for _, n := range t.Clustering {
+		ck = append(ck, values[n])
+	}
+
+	var part = keys(pk)
+
+	return part, part + "#" + keys(ck)
+}
+
+type exec struct {
+	db       *DB
+	keyspace string
+	values   []interface{}
+}
+
+func (e *exec) statement(s cql.Statement) (*Result, error) {
+	switch s := s.(type) {
+	case *cql.CreateKeyspace:
+		return e.db.createKeyspace(s)
+
+	case *cql.DropKeyspace:
+		return e.db.dropKeyspace(s)
+
+	case *cql.CreateTable:
+		return e.db.createTable(e.keyspace, s)
+
+	case *cql.DropTable:
+		return e.db.dropTable(e.keyspace, s)
+
+	case *cql.Use:
+		if _, ok := e.db.keyspaces[s.Keyspace]; !ok {
+			return nil, invalidf("keyspace '%v' does not exist", s.Keyspace)
+		}
+
+		return &Result{Keyspace: s.Keyspace}, nil
+
+	case *cql.Truncate:
+		var t, _, err = e.db.table(e.qualify(s.Keyspace), s.Name)
+
+		if err != nil {
+			return nil, err
+		}
+
+		e.db.data[t.Keyspace+"."+t.Name] = newTableData()
+
+		return &Result{}, nil
+
+	case *cql.Select:
+		return e.selectRows(s)
+
+	case *cql.Batch:
+		var es = make([]*exec, len(s.Statements))
+
+		for i := range es {
+			es[i] = e
+		}
+
+		return batch(es, s.Statements)
+	}
+
+	return batch([]*exec{e}, []cql.Statement{s})
+}
+
+func (e *exec) qualify(keyspace string) string {
+	if keyspace != "" {
+		return keyspace
+	}
+
+	return e.keyspace
+}
+
+// value evaluates term as a value of type t.
+func (e *exec) value(t gocql.TypeInfo, term cql.Term) (interface{}, error) {
+	switch term := term.(type) {
+	case cql.Marker:
+		if term.Index >= len(e.values) {
+			return nil, invalidf("there were %v markers(?) in CQL but %v bound variables", term.Index+1, len(e.values))
+		}
+
+		return convert(t, e.values[term.Index])
+
+	case cql.Literal:
+		return Literal(t, term)
+
+	case cql.ListLiteral:
+		return e.collection(t, term.Elems)
+
+	case cql.SetLiteral:
+		if t.Type() == gocql.TypeMap && len(term.Elems) == 0 {
+			return convert(t, map[interface{}]interface{}{})
+		}
+
+		return e.collection(t, term.Elems)
+
+	case cql.MapLiteral:
+		var c, ok = t.(gocql.CollectionType)
+
+		if !ok || t.Type() != gocql.TypeMap {
+			return nil, invalidf("invalid map literal for type %v", t)
+		}
+
+		var m = map[interface{}]interface{}{}
+
+		for i, kt := range term.Keys {
+			var k, err = e.value(c.Key, kt)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v, err := e.value(c.Elem, term.Values[i])
+
+			if err != nil {
+				return nil, err
+			}
+
+			m[k] = v
+		}
+
+		return convert(t, m)
+
+	case cql.Call:
+		var args []interface{}
+
+		for _, a := range term.Args {
+			var v, err = e.value(typeTimeUUID, a)
+
+			if err != nil {
+				return nil, err
+			}
+
+			args = append(args, v)
+		}
+
+		return call(t, term, args)
+	}
+
+	return nil, invalidf("invalid value")
+}
+
+func (e *exec) collection(t gocql.TypeInfo, terms []cql.Term) (interface{}, error) {
+	var c, ok = t.(gocql.CollectionType)
+
+	if !ok || (t.Type() != gocql.TypeList && t.Type() != gocql.TypeSet) {
+		return nil, invalidf("invalid collection literal for type %v", t)
+	}
+
+	var vs = []interface{}{}
+
+	for _, term := range terms {
+		var v, err = e.value(c.Elem, term)
+
+		if err != nil {
+			return nil, err
+		}
+
+		vs = append(vs, v)
+	}
+
+	return convert(t, vs)
+}
+
+func (e *exec) intValue(term cql.Term) (int, error) {
+	var v, err = e.value(typeInt, term)
+
+	if err != nil {
+		return 0, err
+	}
+
+	var n, _ = v.(int)
+
+	return n, nil
+}
+
+type predicate struct {
+	column *Column
+	op     string
+	values []interface{}
+}
+
+func (p predicate) match(values map[string]interface{}) bool {
+	var v = values[p.column.Name]
+
+	switch p.op {
+	case "=":
+		return equal(v, p.values[0])
+
+	case "!=":
+		return !equal(v, p.values[0])
+
+	case "in":
+		for _, pv := range p.values {
+			if equal(v, pv) {
+				return true
+			}
+		}
+
+		return false
+
+	case "<", "<=", ">", ">=":
+		if v == nil || p.values[0] == nil {
+			return false
+		}
+
+		var c = compare(v, p.values[0])
+
+		switch p.op {
+		case "<":
+			return c < 0
+		case "<=":
+			return c <= 0
+		case ">":
+			return c > 0
+		default:
+			return c >= 0
+		}
+
+	case "contains", "contains key":
+		var rv = reflect.ValueOf(v)
+
+		switch rv.Kind() {
+		case reflect.Slice:
+			for i := 0; i < rv.Len(); i++ {
+				if equal(rv.Index(i).Interface(), p.values[0]) {
+					return true
+				}
+			}
+
+		case reflect.Map:
+			for _, k := range rv.MapKeys() {
+				var x = rv.MapIndex(k)
+
+				if p.op == "contains key" {
+					x = k
+				}
+
+				if equal(x.Interface(), p.values[0]) {
+					return true
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+func (e *exec) predicates(t *Table, rs []cql.Relation) ([]predicate, error) {
+	var ps []predicate
+
+	for _, r := range rs {
+		if r.Token {
+			return nil, invalidf("token relations are only supported in SELECT")
+		}
+
+		var col = t.Column(r.Column())
+
+		if col == nil {
+			return nil, invalidf("undefined column name %v", r.Column())
+		}
+
+		var p = predicate{column: col, op: r.Op}
+		var vt = col.Type
+
+		if r.Op == "contains" || r.Op == "contains key" {
+			var c, ok = col.Type.(gocql.CollectionType)
+
+			if !ok {
+				return nil, invalidf("cannot use CONTAINS on non-collection column %v", col.Name)
+			}
+
+			vt = c.Elem
+
+			if r.Op == "contains key" {
+				vt = c.Key
+			}
+		}
+
+		switch {
+		case r.Op == "in" && r.Value != nil:
+			var lt = gocql.CollectionType{NativeType: gocql.NewNativeType(protoVersion, gocql.TypeList, ""), Elem: col.Type}
+			var v, err = e.value(lt, r.Value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			var rv = reflect.ValueOf(v)
+
+			for i := 0; rv.IsValid() && i < rv.Len(); i++ {
+				p.values = append(p.values, rv.Index(i).Interface())
+			}
+
+		case r.Op == "in":
+			for _, term := range r.Values {
+				var v, err = e.value(col.Type, term)
+
+				if err != nil {
+					return nil, err
+				}
+
+				p.values = append(p.values, v)
+			}
+
+		default:
+			var v, err = e.value(vt, r.Value)
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.values = []interface{}{v}
+		}
+
+		ps = append(ps, p)
+	}
+
+	return ps, nil
+}
+
+func matches(ps []predicate, values map[string]interface{}) bool {
+	for _, p := range ps {
+		if !p.match(values) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// primaryKeys returns the primary keys restricted by ps, which must restrict
+// every primary key column with = or IN.
+func primaryKeys(t *Table, ps []predicate) ([]map[string]interface{}, error) {
+	var byColumn = map[string]predicate{}
+
+	for _, p := range ps {
+		if p.column.Kind != PartitionKey && p.column.Kind != Clustering {
+			return nil, invalidf("non PRIMARY KEY columns found in where clause: %v", p.column.Name)
+		}
+
+		if p.op != "=" && p.op != "in" {
+			return nil, invalidf("invalid operator %v for PRIMARY KEY part %v", p.op, p.column.Name)
+		}
+
+		byColumn[p.column.Name] = p
+	}
+
+	var ks = []map[string]interface{}{{}}
+
+	for _, n := range append(append([]string{}, t.PartitionKey...), t.Clustering...) {
+		var p, ok = byColumn[n]
+
+		if !ok {
+			return nil, invalidf("some primary key parts are missing: %v", n)
+		}
+
+		var next []map[string]interface{}
+
+		for _, k := range ks {
+			for _, v := range p.values {
+				if v == nil {
+					return nil, invalidf("invalid null value for primary key part %v", n)
+				}
+
+				var c = map[string]interface{}{}
+
+				for kn, kv := range k {
+					c[kn] = kv
+				}
+
+				c[n] = v
+				next = append(next, c)
+			}
+		}
+
+		ks = next
+	}
+
+	return ks, nil
+}
+
+// plan is a planned write. Conditions are evaluated when the plan is made, and
+// write performs the write without failing.
+type plan struct {
+	table       *Table
+	conditional bool
+	applied     bool
+
+	// report and current are the columns and values reported when a
+	// conditional write is not applied.
+	report  []*Column
+	current map[string]interface{}
+
+	write func()
+}
+
+func (e *exec) plan(s cql.Statement) (*plan, error) {
+	switch s := s.(type) {
+	case *cql.Insert:
+		return e.insert(s)
+	case *cql.Update:
+		return e.update(s)
+	case *cql.Delete:
+		return e.delete(s)
+	}
+
+	return nil, invalidf("invalid statement in batch")
+}
+
+func (e *exec) using(u cql.Using) error {
+	if u.TTL != nil {
+		if _, err := e.intValue(u.TTL); err != nil {
+			return err
+		}
+	}
+
+	if u.Timestamp != nil {
+		if _, err := e.value(typeBigInt, u.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (e *exec) insert(in *cql.Insert) (*plan, error) {
+	var t, d, err = e.db.table(e.qualify(in.Keyspace), in.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.using(in.Using); err != nil {
+		return nil, err
+	}
+
+	var values = map[string]interface{}{}
+
+	for i, n := range in.Columns {
+		var col = t.Column(n)
+
+		if col == nil {
+			return nil, invalidf("undefined column name %v", n)
+		}
+
+		if col.Type.Type() == gocql.TypeCounter {
+			return nil, invalidf("cannot set the value of counter column %v (counters can only be incremented/decremented, not set)", n)
+		}
+
+		if _, ok := values[n]; ok {
+			return nil, invalidf("multiple definitions found for column %v", n)
+		}
+
+		var v, err = e.value(col.Type, in.Values[i])
+
+		if err != nil {
+			return nil, err
+		}
+
+		values[n] = v
+	}
+
+	for _, n := range append(append([]string{}, t.PartitionKey...), t.Clustering...) {
+		if v, ok := values[n]; !ok {
+			return nil, invalidf("some primary key parts are missing: %v", n)
+		} else if v == nil {
+			return nil, invalidf("invalid null value for primary key part %v", n)
+		}
+	}
+
+	var p = &plan{table: t, conditional: in.IfNotExists, applied: true}
+	var _, k = rowKeys(t, values)
+
+	if r, ok := d.rows[k]; ok && in.IfNotExists {
+		p.applied, p.report, p.current = false, t.Columns, r.values
+	}
+
+	p.write = func() {
+		d.put(t, values)
+	}
+
+	return p, nil
+}
+
+type assignment struct {
+	column *Column
+	op     string
+	key    interface{}
+	value  interface{}
+}
+
+func (e *exec) assignments(t *Table, as []cql.Assignment) ([]assignment, error) {
+	var out []assignment
+
+	for _, a := range as {
+		var col = t.Column(a.Column)
+
+		if col == nil {
+			return nil, invalidf("undefined column name %v", a.Column)
+		}
+
+		if col.Kind == PartitionKey || col.Kind == Clustering {
+			return nil, invalidf("PRIMARY KEY part %v found in SET part", col.Name)
+		}
+
+		var counter = col.Type.Type() == gocql.TypeCounter
+		var collection = col.Type.Type() == gocql.TypeList || col.Type.Type() == gocql.TypeSet || col.Type.Type() == gocql.TypeMap
+
+		if counter && a.Op == "=" {
+			return nil, invalidf("cannot set the value of counter column %v (counters can only be incremented/decremented, not set)", col.Name)
+		}
+
+		if !counter && !collection && a.Op != "=" {
+			return nil, invalidf("invalid operation (%v = %v %v ...) for non counter column %v", col.Name, col.Name, a.Op, col.Name)
+		}
+
+		var out1 = assignment{column: col, op: a.Op}
+		var vt = col.Type
+
+		switch {
+		case counter:
+			vt = typeBigInt
+
+		case a.Op == "index":
+			var c = col.Type.(gocql.CollectionType)
+			var kt = c.Key
+
+			if col.Type.Type() == gocql.TypeList {
+				kt = typeInt
+			} else if col.Type.Type() == gocql.TypeSet {
+				return nil, invalidf("invalid operation (%v[?] = ?) for set column %v", col.Name, col.Name)
+			}
+
+			var k, err = e.value(kt, a.Key)
+
+			if err != nil {
+				return nil, err
+			}
+
+			out1.key = k
+			vt = c.Elem
+
+		case a.Op == "-" && col.Type.Type() == gocql.TypeMap:
+			vt = gocql.CollectionType{NativeType: gocql.NewNativeType(protoVersion, gocql.TypeSet, ""), Elem: col.Type.(gocql.CollectionType).Key}
+		}
+
+		var v, err = e.value(vt, a.Value)
+
+		if err != nil {
+			return nil, err
+		}
+
+		out1.value = v
+		out = append(out, out1)
+	}
+
+	return out, nil
+}
+
+func (a assignment) apply(values map[string]interface{}) {
+	var n = a.column.Name
+	var old = values[n]
+
+	if a.column.Type.Type() == gocql.TypeCounter {
+		var o, _ = old.(int64)
+		var d, _ = a.value.(int64)
+
+		if a.op == "-" {
+			d = -d
+		}
+
+		values[n] = o + d
+
+		return
+	}
+
+	switch a.op {
+	case "=":
+		values[n] = a.value
+
+	case "index":
+		if a.column.Type.Type() == gocql.TypeList {
+			var l = reflect.ValueOf(old)
+			var i, _ = a.key.(int)
+			var c = reflect.MakeSlice(l.Type(), 0, l.Len())
+
+			for j := 0; j < l.Len(); j++ {
+				if j != i {
+					c = reflect.Append(c, l.Index(j))
+				} else if a.value != nil {
+					c = reflect.Append(c, reflect.ValueOf(a.value))
+				}
+			}
+
+			values[n] = c.Interface()
+
+			return
+		}
+
+		var m = copyMap(old, a.column.Type)
+
+		if a.value == nil {
+			m.SetMapIndex(reflect.ValueOf(a.key), reflect.Value{})
+		} else {
+			m.SetMapIndex(reflect.ValueOf(a.key), reflect.ValueOf(a.value))
+		}
+
+		values[n] = m.Interface()
+
+	case "+", "prepend":
+		if a.value == nil {
+			return
+		}
+
+		if a.column.Type.Type() == gocql.TypeMap {
+			var m = copyMap(old, a.column.Type)
+			var add = reflect.ValueOf(a.value)
+
+			for _, k := range add.MapKeys() {
+				m.SetMapIndex(k, add.MapIndex(k))
+			}
+
+			values[n] = m.Interface()
+
+			return
+		}
+
+		var l, add = reflect.ValueOf(old), reflect.ValueOf(a.value)
+
+		if old == nil {
+			l = reflect.MakeSlice(add.Type(), 0, 0)
+		}
+
+		if a.op == "prepend" {
+			values[n] = reflect.AppendSlice(add, l).Interface()
+		} else {
+			values[n] = reflect.AppendSlice(l, add).Interface()
+		}
+
+		if a.column.Type.Type() == gocql.TypeSet {
+			values[n] = normalizeSet(values[n])
+		}
+
+	case "-":
+		if old == nil || a.value == nil {
+			return
+		}
+
+		var remove = reflect.ValueOf(a.value)
+		var removed = func(v reflect.Value) bool {
+			for i := 0; i < remove.Len(); i++ {
+				if equal(remove.Index(i).Interface(), v.Interface()) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if a.column.Type.Type() == gocql.TypeMap {
+			var m = copyMap(old, a.column.Type)
+
+			for _, k := range m.MapKeys() {
+				if removed(k) {
+					m.SetMapIndex(k, reflect.Value{})
+				}
+			}
+
+			values[n] = m.Interface()
+
+			return
+		}
+
+		var l = reflect.ValueOf(old)
+		var c = reflect.MakeSlice(l.Type(), 0, l.Len())
+
+		for i := 0; i < l.Len(); i++ {
+			if !removed(l.Index(i)) {
+				c = reflect.Append(c, l.Index(i))
+			}
+		}
+
+		values[n] = c.Interface()
+	}
+}
+
+func copyMap(m interface{}, t gocql.TypeInfo) reflect.Value {
+	var mt = reflect.TypeOf(Zero(t))
+	var c = reflect.MakeMap(mt)
+
+	if m != nil {
+		var rv = reflect.ValueOf(m)
+
+		for _, k := range rv.MapKeys() {
+			c.SetMapIndex(k, rv.MapIndex(k))
+		}
+	}
+
+	return c
+}
+
+func (e *exec) conditions(t *Table, rs []cql.Relation) ([]predicate, []*Column, error) {
+	var ps, err = e.predicates(t, rs)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var cs []*Column
+
+	for _, p := range ps {
+		if p.column.Kind == PartitionKey || p.column.Kind == Clustering {
+			return nil, nil, invalidf("PRIMARY KEY column '%v' cannot have IF conditions", p.column.Name)
+		}
+
+		cs = append(cs, p.column)
+	}
+
+	return ps, cs, nil
+}
+
+func (e *exec) update(u *cql.Update) (*plan, error) {
+	var t, d, err = e.db.table(e.qualify(u.Keyspace), u.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.using(u.Using); err != nil {
+		return nil, err
+	}
+
+	as, err := e.assignments(t, u.Set)
+
+	if err != nil {
+		return nil, err
+	}
+
+	where, err := e.predicates(t, u.Where)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ks, err := primaryKeys(t, where)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var p = &plan{table: t, conditional: u.IfExists || len(u.If) > 0, applied: true}
+
+	if p.conditional {
+		if len(ks) != 1 {
+			return nil, invalidf("IN on the primary key columns is not supported with conditional updates")
+		}
+
+		var _, k = rowKeys(t, ks[0])
+		var r, ok = d.rows[k]
+
+		if ok {
+			p.current = r.values
+		}
+
+		if u.IfExists {
+			p.applied = ok
+		} else {
+			var ifs, cs, err = e.conditions(t, u.If)
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.applied = matches(ifs, p.current)
+			p.report = cs
+		}
+	}
+
+	p.write = func() {
+		for _, k := range ks {
+			var _, rk = rowKeys(t, k)
+			var values = map[string]interface{}{}
+
+			if r, ok := d.rows[rk]; ok {
+				for n, v := range r.values {
+					values[n] = v
+				}
+			}
+
+			for n, v := range k {
+				values[n] = v
+			}
+
+			for _, a := range as {
+				a.apply(values)
+			}
+
+			d.put(t, values)
+		}
+	}
+
+	return p, nil
+}
+
+func (e *exec) delete(del *cql.Delete) (*plan, error) {
+	var t, d, err = e.db.table(e.qualify(del.Keyspace), del.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.using(del.Using); err != nil {
+		return nil, err
+	}
+
+	for _, n := range del.Columns {
+		var col = t.Column(n)
+
+		if col == nil {
+			return nil, invalidf("undefined column name %v", n)
+		}
+
+		if col.Kind == PartitionKey || col.Kind == Clustering {
+			return nil, invalidf("invalid identifier %v for deletion (should not be a PRIMARY KEY part)", n)
+		}
+	}
+
+	where, err := e.predicates(t, del.Where)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var restricted = map[string]bool{}
+
+	for _, w := range where {
+		if w.column.Kind != PartitionKey && w.column.Kind != Clustering {
+			return nil, invalidf("non PRIMARY KEY columns found in where clause: %v", w.column.Name)
+		}
+
+		restricted[w.column.Name] = true
+	}
+
+	for _, n := range t.PartitionKey {
+		if !restricted[n] {
+			return nil, invalidf("some partition key parts are missing: %v", n)
+		}
+	}
+
+	var targets []string
+
+	for k, r := range d.rows {
+		if matches(where, r.values) {
+			targets = append(targets, k)
+		}
+	}
+
+	var p = &plan{table: t, conditional: del.IfExists || len(del.If) > 0, applied: true}
+
+	if p.conditional {
+		if _, err := primaryKeys(t, where); err != nil {
+			return nil, invalidf("DELETE statements must restrict all PRIMARY KEY columns with equality relations in order to use IF conditions")
+		}
+
+		if len(targets) > 1 {
+			return nil, invalidf("IN on the primary key columns is not supported with conditional deletions")
+		}
+
+		if len(targets) == 1 {
+			p.current = d.rows[targets[0]].values
+		}
+
+		if del.IfExists {
+			p.applied = p.current != nil
+		} else {
+			var ifs, cs, err = e.conditions(t, del.If)
+
+			if err != nil {
+				return nil, err
+			}
+
+			p.applied = matches(ifs, p.current)
+			p.report = cs
+		}
+	}
+
+	p.write = func() {
+		for _, k := range targets {
+			var r, ok = d.rows[k]
+
+			if !ok {
+				continue
+			}
+
+			if len(del.Columns) == 0 {
+				d.remove(k)
+
+				continue
+			}
+
+			for _, n := range del.Columns {
+				r.values[n] = nil
+			}
+		}
+	}
+
+	return p, nil
+}
+
+// batch plans every statement against the current data, then writes them all
+// if every condition holds.
+func batch(es []*exec, ss []cql.Statement) (*Result, error) {
+	var ps []*plan
+	var conditional, applied = false, true
+
+	for i, s := range ss {
+		var p, err = es[i].plan(s)
+
+		if err != nil {
+			return nil, err
+		}
+
+		conditional = conditional || p.conditional
+		applied = applied && p.applied
+		ps = append(ps, p)
+	}
+
+	if conditional {
+		for _, p := range ps[1:] {
+			if p.table != ps[0].table {
+				return nil, invalidf("batch with conditions cannot span multiple tables")
+			}
+		}
+	}
+
+	if applied {
+		for _, p := range ps {
+			p.write()
+		}
+	}
+
+	if !conditional {
+		return &Result{}, nil
+	}
+
+	var t = ps[0].table
+	var r = &Result{Columns: []Column{{Keyspace: t.Keyspace, Table: t.Name, Name: ColumnApplied, Type: typeBoolean}}}
+
+	if applied {
+		r.Rows = [][]interface{}{{true}}
+
+		return r, nil
+	}
+
+	var report []*Column
+	var seen = map[string]bool{}
+
+	for _, p := range ps {
+		if !p.conditional {
+			continue
+		}
+
+		var cs = p.report
+
+		if len(ss) > 1 {
+			cs = append(t.Columns[:len(t.PartitionKey)+len(t.Clustering):len(t.PartitionKey)+len(t.Clustering)], cs...)
+		}
+
+		for _, c := range cs {
+			if !seen[c.Name] {
+				seen[c.Name] = true
+				report = append(report, c)
+			}
+		}
+	}
+
+	for _, c := range report {
+		r.Columns = append(r.Columns, *c)
+	}
+
+	for _, p := range ps {
+		if !p.conditional {
+			continue
+		}
+
+		var vs = []interface{}{false}
+
+		for _, c := range report {
+			if p.current == nil {
+				vs = append(vs, nil)
+			} else {
+				vs = append(vs, p.current[c.Name])
+			}
+		}
+
+		r.Rows = append(r.Rows, vs)
+	}
+
+	return r, nil
+}
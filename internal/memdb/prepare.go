@@ -0,0 +1,238 @@
+package memdb
+
+import (
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// Prepared describes the bind markers and result columns of a statement.
+type Prepared struct {
+	Markers []Column
+	Columns []Column
+}
+
+// Prepare describes statement without executing it.
+func (db *DB) Prepare(keyspace, statement string) (*Prepared, error) {
+	var s, err = parse(statement)
+
+	if err != nil {
+		return nil, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+
+	var p = &Prepared{}
+	var e = &exec{db: db, keyspace: keyspace}
+
+	if err := e.describe(s, p); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+func (e *exec) describe(s cql.Statement, p *Prepared) error {
+	var markers = map[int]Column{}
+
+	var mark = func(t *Table, name string, ti gocql.TypeInfo, term cql.Term) {
+		switch term := term.(type) {
+		case cql.Marker:
+			markers[term.Index] = Column{Keyspace: t.Keyspace, Table: t.Name, Name: name, Type: ti, Position: -1}
+
+		case cql.ListLiteral, cql.SetLiteral:
+			var elems []cql.Term
+
+			if l, ok := term.(cql.ListLiteral); ok {
+				elems = l.Elems
+			} else {
+				elems = term.(cql.SetLiteral).Elems
+			}
+
+			if c, ok := ti.(gocql.CollectionType); ok {
+				for _, el := range elems {
+					if m, ok := el.(cql.Marker); ok {
+						markers[m.Index] = Column{Keyspace: t.Keyspace, Table: t.Name, Name: name, Type: c.Elem, Position: -1}
+					}
+				}
+			}
+		}
+	}
+
+	var using = func(t *Table, u cql.Using) {
+		mark(t, "[ttl]", typeInt, u.TTL)
+		mark(t, "[timestamp]", typeBigInt, u.Timestamp)
+	}
+
+	var relations = func(t *Table, rs []cql.Relation) error {
+		for _, r := range rs {
+			if r.Token {
+				mark(t, "partition key token", typeBigInt, r.Value)
+
+				continue
+			}
+
+			var c = t.Column(r.Column())
+
+			if c == nil {
+				return invalidf("undefined column name %v", r.Column())
+			}
+
+			var ti = c.Type
+
+			switch r.Op {
+			case "in":
+				if r.Value != nil {
+					ti = gocql.CollectionType{NativeType: gocql.NewNativeType(protoVersion, gocql.TypeList, ""), Elem: c.Type}
+					mark(t, "in("+c.Name+")", ti, r.Value)
+				}
+
+				for _, v := range r.Values {
+					mark(t, c.Name, ti, v)
+				}
+
+				continue
+
+			case "contains":
+				ti = c.Type.(gocql.CollectionType).Elem
+
+			case "contains key":
+				ti = c.Type.(gocql.CollectionType).Key
+			}
+
+			mark(t, c.Name, ti, r.Value)
+		}
+
+		return nil
+	}
+
+	var statements = []cql.Statement{s}
+
+	if b, ok := s.(*cql.Batch); ok {
+		statements = b.Statements
+	}
+
+	for _, s := range statements {
+		switch s := s.(type) {
+		case *cql.Select:
+			var t, _, err = e.db.table(e.qualify(s.Keyspace), s.Table)
+
+			if err != nil {
+				return err
+			}
+
+			if err := relations(t, s.Where); err != nil {
+				return err
+			}
+
+			mark(t, "[limit]", typeInt, s.PerPartitionLimit)
+			mark(t, "[limit]", typeInt, s.Limit)
+
+			columns, _, err := selection(t, s.Selectors)
+
+			if err != nil {
+				return err
+			}
+
+			for _, c := range columns {
+				p.Columns = append(p.Columns, c.Column)
+			}
+
+		case *cql.Insert:
+			var t, _, err = e.db.table(e.qualify(s.Keyspace), s.Table)
+
+			if err != nil {
+				return err
+			}
+
+			for i, n := range s.Columns {
+				var c = t.Column(n)
+
+				if c == nil {
+					return invalidf("undefined column name %v", n)
+				}
+
+				mark(t, n, c.Type, s.Values[i])
+			}
+
+			using(t, s.Using)
+
+		case *cql.Update:
+			var t, _, err = e.db.table(e.qualify(s.Keyspace), s.Table)
+
+			if err != nil {
+				return err
+			}
+
+			using(t, s.Using)
+
+			for _, a := range s.Set {
+				var c = t.Column(a.Column)
+
+				if c == nil {
+					return invalidf("undefined column name %v", a.Column)
+				}
+
+				var ti = c.Type
+
+				switch {
+				case ti.Type() == gocql.TypeCounter:
+					ti = typeBigInt
+
+				case a.Op == "index":
+					var ct = c.Type.(gocql.CollectionType)
+
+					if c.Type.Type() == gocql.TypeList {
+						mark(t, "idx("+c.Name+")", typeInt, a.Key)
+					} else {
+						mark(t, "key("+c.Name+")", ct.Key, a.Key)
+					}
+
+					ti = ct.Elem
+
+				case a.Op == "-" && ti.Type() == gocql.TypeMap:
+					ti = gocql.CollectionType{NativeType: gocql.NewNativeType(protoVersion, gocql.TypeSet, ""), Elem: c.Type.(gocql.CollectionType).Key}
+				}
+
+				mark(t, c.Name, ti, a.Value)
+			}
+
+			if err := relations(t, s.Where); err != nil {
+				return err
+			}
+
+			if err := relations(t, s.If); err != nil {
+				return err
+			}
+
+		case *cql.Delete:
+			var t, _, err = e.db.table(e.qualify(s.Keyspace), s.Table)
+
+			if err != nil {
+				return err
+			}
+
+			using(t, s.Using)
+
+			if err := relations(t, s.Where); err != nil {
+				return err
+			}
+
+			if err := relations(t, s.If); err != nil {
+				return err
+			}
+		}
+	}
+
+	for i := 0; i < len(markers); i++ {
+		var c, ok = markers[i]
+
+		if !ok {
+			return invalidf("unsupported bind marker position %v", i)
+		}
+
+		p.Markers = append(p.Markers, c)
+	}
+
+	return nil
+}
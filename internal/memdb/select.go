@@ -0,0 +1,349 @@
+package memdb
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+	"github.com/kerkerj/gockle/internal/partitioner"
+)
+
+func (e *exec) selectRows(s *cql.Select) (*Result, error) {
+	var t, d, err = e.db.table(e.qualify(s.Keyspace), s.Table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	tokens, relations, err := e.tokenPredicates(t, s.Where)
+
+	if err != nil {
+		return nil, err
+	}
+
+	where, err := e.predicates(t, relations)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var rs []*row
+
+	for _, r := range d.sorted(t) {
+		if matches(where, r.values) && matchesToken(t, tokens, r.values) {
+			rs = append(rs, r)
+		}
+	}
+
+	if len(s.OrderBy) > 0 {
+		for _, o := range s.OrderBy {
+			if c := t.Column(o.Column); c == nil || c.Kind != Clustering {
+				return nil, invalidf("order by is only supported on clustered columns, got %v", o.Column)
+			}
+		}
+
+		sort.SliceStable(rs, func(i, j int) bool {
+			if pi, pj := d.parts[rs[i].part], d.parts[rs[j].part]; pi != pj {
+				return pi < pj
+			}
+
+			for _, o := range s.OrderBy {
+				var c = compare(rs[i].values[o.Column], rs[j].values[o.Column])
+
+				if o.Desc {
+					c = -c
+				}
+
+				if c != 0 {
+					return c < 0
+				}
+			}
+
+			return false
+		})
+	}
+
+	if s.Distinct {
+		var seen = map[string]bool{}
+		var distinct []*row
+
+		for _, r := range rs {
+			if !seen[r.part] {
+				seen[r.part] = true
+				distinct = append(distinct, r)
+			}
+		}
+
+		rs = distinct
+	}
+
+	if s.PerPartitionLimit != nil {
+		var n, err = e.intValue(s.PerPartitionLimit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if n <= 0 {
+			return nil, invalidf("PER PARTITION LIMIT must be strictly positive")
+		}
+
+		var counts = map[string]int{}
+		var limited []*row
+
+		for _, r := range rs {
+			if counts[r.part] < n {
+				counts[r.part]++
+				limited = append(limited, r)
+			}
+		}
+
+		rs = limited
+	}
+
+	columns, aggregate, err := selection(t, s.Selectors)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var result = &Result{}
+
+	for _, c := range columns {
+		result.Columns = append(result.Columns, c.Column)
+	}
+
+	if aggregate {
+		var vs = make([]interface{}, len(columns))
+
+		for i, c := range columns {
+			vs[i] = c.aggregate(rs)
+		}
+
+		result.Rows = [][]interface{}{vs}
+
+		return result, nil
+	}
+
+	if s.Limit != nil {
+		var n, err = e.intValue(s.Limit)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if n <= 0 {
+			return nil, invalidf("LIMIT must be strictly positive")
+		}
+
+		if n < len(rs) {
+			rs = rs[:n]
+		}
+	}
+
+	for _, r := range rs {
+		var vs = make([]interface{}, len(columns))
+
+		for i, c := range columns {
+			vs[i] = r.values[c.source]
+		}
+
+		result.Rows = append(result.Rows, vs)
+	}
+
+	return result, nil
+}
+
+type selected struct {
+	Column
+
+	source   string
+	function string
+}
+
+func (s selected) aggregate(rs []*row) interface{} {
+	switch s.function {
+	case "count":
+		var n int64
+
+		for _, r := range rs {
+			if s.source == "*" || r.values[s.source] != nil {
+				n++
+			}
+		}
+
+		return n
+
+	case "min", "max":
+		var m interface{}
+
+		for _, r := range rs {
+			var v = r.values[s.source]
+
+			if v == nil {
+				continue
+			}
+
+			if c := compare(v, m); m == nil || (s.function == "min" && c < 0) || (s.function == "max" && c > 0) {
+				m = v
+			}
+		}
+
+		return m
+	}
+
+	if len(rs) == 0 {
+		return nil
+	}
+
+	return rs[0].values[s.source]
+}
+
+func selection(t *Table, ss []cql.Selector) ([]selected, bool, error) {
+	var out []selected
+	var aggregate = false
+
+	for _, s := range ss {
+		if s.Column == "*" && s.Function == "" {
+			for _, c := range t.Columns {
+				out = append(out, selected{Column: *c, source: c.Name})
+			}
+
+			continue
+		}
+
+		var sel = selected{source: s.Column, function: s.Function}
+
+		if s.Column != "*" {
+			var c = t.Column(s.Column)
+
+			if c == nil {
+				return nil, false, invalidf("undefined column name %v", s.Column)
+			}
+
+			sel.Column = *c
+		}
+
+		switch s.Function {
+		case "":
+
+		case "count":
+			sel.Type = typeBigInt
+			aggregate = true
+
+		case "min", "max":
+			if s.Column == "*" {
+				return nil, false, invalidf("invalid argument * for function %v", s.Function)
+			}
+
+			aggregate = true
+
+		default:
+			return nil, false, invalidf("unknown function %v", s.Function)
+		}
+
+		sel.Keyspace, sel.Table, sel.Name = t.Keyspace, t.Name, s.Name()
+
+		if sel.Kind == "" {
+			sel.Kind, sel.Position = Regular, -1
+		}
+
+		out = append(out, sel)
+	}
+
+	return out, aggregate, nil
+}
+
+// tokenPredicate is a relation on the Murmur3 token of the partition key.
+type tokenPredicate struct {
+	op    string
+	value int64
+}
+
+// tokenPredicates returns the predicates of the token relations of rs, and the
+// other relations.
+func (e *exec) tokenPredicates(t *Table, rs []cql.Relation) ([]tokenPredicate, []cql.Relation, error) {
+	var ps []tokenPredicate
+	var others []cql.Relation
+
+	for _, r := range rs {
+		if !r.Token {
+			others = append(others, r)
+
+			continue
+		}
+
+		if strings.Join(r.Columns, ", ") != strings.Join(t.PartitionKey, ", ") {
+			return nil, nil, invalidf("the token function arguments must be in the partition key order: %v", strings.Join(t.PartitionKey, ", "))
+		}
+
+		switch r.Op {
+		case "=", "<", "<=", ">", ">=":
+
+		default:
+			return nil, nil, invalidf("invalid operator %v for token relation", r.Op)
+		}
+
+		var v, err = e.value(typeBigInt, r.Value)
+
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var n, ok = v.(int64)
+
+		if !ok {
+			return nil, nil, invalidf("invalid null value for token relation")
+		}
+
+		ps = append(ps, tokenPredicate{op: r.Op, value: n})
+	}
+
+	return ps, others, nil
+}
+
+// matchesToken returns whether the token of the partition of values matches
+// ps.
+func matchesToken(t *Table, ps []tokenPredicate, values map[string]interface{}) bool {
+	if len(ps) == 0 {
+		return true
+	}
+
+	var bs = make([][]byte, len(t.PartitionKey))
+
+	for i, n := range t.PartitionKey {
+		var b, err = gocql.Marshal(t.Column(n).Type, values[n])
+
+		if err != nil {
+			return false
+		}
+
+		bs[i] = b
+	}
+
+	var token = partitioner.Murmur3(partitioner.Key(bs))
+
+	for _, p := range ps {
+		var ok bool
+
+		switch p.op {
+		case "=":
+			ok = token == p.value
+		case "<":
+			ok = token < p.value
+		case "<=":
+			ok = token <= p.value
+		case ">":
+			ok = token > p.value
+		default:
+			ok = token >= p.value
+		}
+
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,152 @@
+package memdb
+
+import (
+	"sort"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// The system keyspaces hold the node and schema metadata that drivers read
+// when they connect, laid out like Cassandra 3.11. System.local has one row
+// and system.peers none. The system_schema tables are rebuilt after every
+// schema change.
+var systemTables = []string{
+	"create table system.local (key text primary key, bootstrapped text, broadcast_address inet, cluster_name text, cql_version text, data_center text, host_id uuid, listen_address inet, native_protocol_version text, partitioner text, rack text, release_version text, rpc_address inet, schema_version uuid, tokens set<text>)",
+	"create table system.peers (peer inet primary key, data_center text, host_id uuid, preferred_ip inet, rack text, release_version text, rpc_address inet, schema_version uuid, tokens set<text>)",
+	"create table system_schema.keyspaces (keyspace_name text primary key, durable_writes boolean, replication map<text, text>)",
+	"create table system_schema.tables (keyspace_name text, table_name text, bloom_filter_fp_chance double, caching map<text, text>, comment text, compaction map<text, text>, compression map<text, text>, crc_check_chance double, dclocal_read_repair_chance double, default_time_to_live int, extensions map<text, blob>, flags set<text>, gc_grace_seconds int, id uuid, max_index_interval int, memtable_flush_period_in_ms int, min_index_interval int, read_repair_chance double, speculative_retry text, primary key (keyspace_name, table_name))",
+	"create table system_schema.columns (keyspace_name text, table_name text, column_name text, clustering_order text, column_name_bytes blob, kind text, position int, type text, primary key (keyspace_name, table_name, column_name))",
+	"create table system_schema.views (keyspace_name text, view_name text, base_table_id uuid, base_table_name text, bloom_filter_fp_chance double, caching map<text, text>, comment text, compaction map<text, text>, compression map<text, text>, crc_check_chance double, dclocal_read_repair_chance double, default_time_to_live int, extensions map<text, blob>, gc_grace_seconds int, id uuid, include_all_columns boolean, max_index_interval int, memtable_flush_period_in_ms int, min_index_interval int, read_repair_chance double, speculative_retry text, where_clause text, primary key (keyspace_name, view_name))",
+	"create table system_schema.indexes (keyspace_name text, table_name text, index_name text, kind text, options map<text, text>, primary key (keyspace_name, table_name, index_name))",
+	"create table system_schema.types (keyspace_name text, type_name text, field_names list<text>, field_types list<text>, primary key (keyspace_name, type_name))",
+	"create table system_schema.functions (keyspace_name text, function_name text, argument_types list<text>, argument_names list<text>, body text, called_on_null_input boolean, language text, return_type text, primary key (keyspace_name, function_name, argument_types))",
+	"create table system_schema.aggregates (keyspace_name text, aggregate_name text, argument_types list<text>, final_func text, initcond text, return_type text, state_func text, state_type text, primary key (keyspace_name, aggregate_name, argument_types))",
+}
+
+func (db *DB) createSystem() {
+	for _, n := range []string{"system", "system_schema"} {
+		db.keyspaces[n] = &Keyspace{Name: n, DurableWrites: true, Replication: map[string]string{"class": "org.apache.cassandra.locator.LocalStrategy"}, Tables: map[string]*Table{}}
+	}
+
+	for _, s := range systemTables {
+		var c, err = cql.Parse(s)
+
+		if err != nil {
+			panic(err)
+		}
+
+		if _, err := db.createTable("", c.(*cql.CreateTable)); err != nil {
+			panic(err)
+		}
+	}
+
+	var id, err = gocql.RandomUUID()
+
+	if err != nil {
+		panic(err)
+	}
+
+	db.putSystem("system", "local", map[string]interface{}{
+		"key":                     "local",
+		"bootstrapped":            "COMPLETED",
+		"broadcast_address":       "127.0.0.1",
+		"cluster_name":            "gockle",
+		"cql_version":             "3.4.4",
+		"data_center":             "datacenter1",
+		"host_id":                 id,
+		"listen_address":          "127.0.0.1",
+		"native_protocol_version": "4",
+		"partitioner":             "org.apache.cassandra.dht.Murmur3Partitioner",
+		"rack":                    "rack1",
+		"release_version":         "3.11.4",
+		"rpc_address":             "127.0.0.1",
+		"tokens":                  []string{"0"},
+	})
+
+	db.refreshSchema()
+}
+
+// refreshSchema rebuilds the system_schema tables from the keyspaces and
+// gives system.local a new schema version.
+func (db *DB) refreshSchema() {
+	for _, n := range []string{"keyspaces", "tables", "columns"} {
+		db.data["system_schema."+n] = newTableData()
+	}
+
+	var ns = make([]string, 0, len(db.keyspaces))
+
+	for n := range db.keyspaces {
+		ns = append(ns, n)
+	}
+
+	sort.Strings(ns)
+
+	for _, n := range ns {
+		var k = db.keyspaces[n]
+
+		db.putSystem("system_schema", "keyspaces", map[string]interface{}{
+			"keyspace_name":  k.Name,
+			"durable_writes": k.DurableWrites,
+			"replication":    k.Replication,
+		})
+
+		for _, tn := range k.TableNames() {
+			var t = k.Tables[tn]
+
+			db.putSystem("system_schema", "tables", map[string]interface{}{
+				"keyspace_name": k.Name,
+				"table_name":    t.Name,
+				"comment":       t.Options["comment"],
+			})
+
+			for _, c := range t.Columns {
+				var order = "none"
+
+				if c.Kind == Clustering {
+					order = "asc"
+
+					if c.Desc {
+						order = "desc"
+					}
+				}
+
+				db.putSystem("system_schema", "columns", map[string]interface{}{
+					"keyspace_name":     k.Name,
+					"table_name":        t.Name,
+					"column_name":       c.Name,
+					"clustering_order":  order,
+					"column_name_bytes": []byte(c.Name),
+					"kind":              c.Kind,
+					"position":          c.Position,
+					"type":              c.CQLType,
+				})
+			}
+		}
+	}
+
+	var version, err = gocql.RandomUUID()
+
+	if err != nil {
+		panic(err)
+	}
+
+	db.putSystem("system", "local", map[string]interface{}{"key": "local", "schema_version": version})
+}
+
+func (db *DB) putSystem(keyspace, table string, values map[string]interface{}) {
+	var t = db.keyspaces[keyspace].Tables[table]
+	var vs = map[string]interface{}{}
+
+	for n, v := range values {
+		var cv, err = convert(t.Column(n).Type, v)
+
+		if err != nil {
+			panic(err)
+		}
+
+		vs[n] = cv
+	}
+
+	db.data[keyspace+"."+table].put(t, vs)
+}
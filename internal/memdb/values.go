@@ -0,0 +1,452 @@
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"math/big"
+	"net"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+const protoVersion = 4
+
+var nativeTypes = map[string]gocql.Type{
+	"ascii":     gocql.TypeAscii,
+	"bigint":    gocql.TypeBigInt,
+	"blob":      gocql.TypeBlob,
+	"boolean":   gocql.TypeBoolean,
+	"counter":   gocql.TypeCounter,
+	"date":      gocql.TypeDate,
+	"decimal":   gocql.TypeDecimal,
+	"double":    gocql.TypeDouble,
+	"duration":  gocql.TypeDuration,
+	"float":     gocql.TypeFloat,
+	"inet":      gocql.TypeInet,
+	"int":       gocql.TypeInt,
+	"smallint":  gocql.TypeSmallInt,
+	"text":      gocql.TypeVarchar,
+	"time":      gocql.TypeTime,
+	"timestamp": gocql.TypeTimestamp,
+	"timeuuid":  gocql.TypeTimeUUID,
+	"tinyint":   gocql.TypeTinyInt,
+	"uuid":      gocql.TypeUUID,
+	"varchar":   gocql.TypeVarchar,
+	"varint":    gocql.TypeVarint,
+}
+
+// TypeInfo returns the gocql type for t.
+func TypeInfo(t cql.Type) (gocql.TypeInfo, error) {
+	switch t.Name {
+	case "list", "set":
+		if len(t.Args) != 1 {
+			return nil, invalidf("%v takes one type argument", t.Name)
+		}
+
+		var e, err = TypeInfo(t.Args[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		var k = gocql.TypeList
+
+		if t.Name == "set" {
+			k = gocql.TypeSet
+		}
+
+		return gocql.CollectionType{NativeType: gocql.NewNativeType(protoVersion, k, ""), Elem: e}, nil
+
+	case "map":
+		if len(t.Args) != 2 {
+			return nil, invalidf("map takes two type arguments")
+		}
+
+		var k, err = TypeInfo(t.Args[0])
+
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := TypeInfo(t.Args[1])
+
+		if err != nil {
+			return nil, err
+		}
+
+		return gocql.CollectionType{NativeType: gocql.NewNativeType(protoVersion, gocql.TypeMap, ""), Key: k, Elem: e}, nil
+	}
+
+	var n, ok = nativeTypes[t.Name]
+
+	if !ok || len(t.Args) > 0 {
+		return nil, invalidf("unknown type %v", t)
+	}
+
+	return gocql.NewNativeType(protoVersion, n, ""), nil
+}
+
+// Zero returns the value gocql produces for a null of type t.
+func Zero(t gocql.TypeInfo) interface{} {
+	return reflect.ValueOf(t.New()).Elem().Interface()
+}
+
+// Scan copies v, a value of type t, into dest the way gocql does. A nil dest
+// skips the value.
+func Scan(t gocql.TypeInfo, v interface{}, dest interface{}) error {
+	if dest == nil {
+		return nil
+	}
+
+	var b []byte
+
+	if v != nil {
+		var err error
+
+		if b, err = gocql.Marshal(t, v); err != nil {
+			return err
+		}
+	}
+
+	return gocql.Unmarshal(t, b, dest)
+}
+
+// convert converts v to the Go type gocql uses for t.
+func convert(t gocql.TypeInfo, v interface{}) (interface{}, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil, nil
+		}
+
+		if _, ok := v.(*big.Int); !ok {
+			v = rv.Elem().Interface()
+		}
+	}
+
+	var b, err = gocql.Marshal(t, v)
+
+	if err != nil {
+		return nil, invalidf("invalid value %v for type %v: %v", v, t, err)
+	}
+
+	return Decode(t, b)
+}
+
+// Decode unmarshals the serialized value b of type t. A nil b is null.
+func Decode(t gocql.TypeInfo, b []byte) (interface{}, error) {
+	if b == nil {
+		return nil, nil
+	}
+
+	var p = t.New()
+
+	if err := gocql.Unmarshal(t, b, p); err != nil {
+		return nil, invalidf("invalid value for type %v: %v", t, err)
+	}
+
+	var v = reflect.ValueOf(p).Elem().Interface()
+
+	if t.Type() == gocql.TypeSet {
+		v = normalizeSet(v)
+	}
+
+	return v, nil
+}
+
+// Literal converts the literal l to the Go type gocql uses for t.
+func Literal(t gocql.TypeInfo, l cql.Literal) (interface{}, error) {
+	var v = l.Value
+
+	if v == nil {
+		return nil, nil
+	}
+
+	switch t.Type() {
+	case gocql.TypeFloat, gocql.TypeDouble:
+		if n, ok := v.(int64); ok {
+			v = float64(n)
+		}
+
+		if f, ok := v.(float64); ok && t.Type() == gocql.TypeFloat {
+			v = float32(f)
+		}
+
+	case gocql.TypeTimestamp, gocql.TypeDate:
+		if s, ok := v.(string); ok {
+			var tm, err = parseTime(s)
+
+			if err != nil {
+				return nil, err
+			}
+
+			v = tm
+		}
+
+	case gocql.TypeInet:
+		if s, ok := v.(string); ok && net.ParseIP(s) == nil {
+			return nil, invalidf("invalid inet %v", s)
+		}
+
+	case gocql.TypeVarint:
+		if n, ok := v.(int64); ok {
+			v = big.NewInt(n)
+		}
+	}
+
+	if l.Kind == cql.String {
+		switch t.Type() {
+		case gocql.TypeInt, gocql.TypeBigInt, gocql.TypeSmallInt, gocql.TypeTinyInt, gocql.TypeCounter, gocql.TypeBoolean, gocql.TypeFloat, gocql.TypeDouble:
+			return nil, invalidf("invalid string constant %q for type %v", v, t)
+		}
+	}
+
+	return convert(t, v)
+}
+
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999-07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04",
+	"2006-01-02",
+}
+
+func parseTime(s string) (time.Time, error) {
+	for _, l := range timeLayouts {
+		if t, err := time.Parse(l, s); err == nil {
+			return t.UTC(), nil
+		}
+	}
+
+	return time.Time{}, invalidf("unable to coerce '%v' to a formatted date", s)
+}
+
+func call(t gocql.TypeInfo, c cql.Call, args []interface{}) (interface{}, error) {
+	switch c.Name {
+	case "now":
+		return convert(t, gocql.TimeUUID())
+
+	case "uuid":
+		var u, err = gocql.RandomUUID()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return convert(t, u)
+
+	case "totimestamp", "todate":
+		if len(args) == 1 {
+			if u, ok := args[0].(gocql.UUID); ok {
+				return convert(t, u.Time())
+			}
+		}
+
+	case "currenttimestamp", "currentdate":
+		return convert(t, time.Now())
+
+	case "currenttimeuuid":
+		return convert(t, gocql.TimeUUID())
+	}
+
+	return nil, invalidf("unknown function %v", c.Name)
+}
+
+// compare orders two values of the same CQL type. Null sorts first.
+func compare(a, b interface{}) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return -1
+	case b == nil:
+		return 1
+	}
+
+	switch a := a.(type) {
+	case string:
+		return strings.Compare(a, fmt.Sprint(b))
+
+	case bool:
+		var bb, _ = b.(bool)
+
+		switch {
+		case a == bb:
+			return 0
+		case !a:
+			return -1
+		default:
+			return 1
+		}
+
+	case []byte:
+		var bb, _ = b.([]byte)
+
+		return bytes.Compare(a, bb)
+
+	case time.Time:
+		var bb, _ = b.(time.Time)
+
+		switch {
+		case a.Before(bb):
+			return -1
+		case a.After(bb):
+			return 1
+		default:
+			return 0
+		}
+
+	case gocql.UUID:
+		var bb, _ = b.(gocql.UUID)
+
+		if a.Version() == 1 && bb.Version() == 1 {
+			if c := compare(a.Time(), bb.Time()); c != 0 {
+				return c
+			}
+		}
+
+		return bytes.Compare(a[:], bb[:])
+
+	case *big.Int:
+		var bb, _ = b.(*big.Int)
+
+		return a.Cmp(bb)
+	}
+
+	var av, bv = reflect.ValueOf(a), reflect.ValueOf(b)
+
+	switch av.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		var x, y = av.Int(), toInt(bv)
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+
+	case reflect.Float32, reflect.Float64:
+		var x, y = av.Float(), toFloat(bv)
+
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		default:
+			return 0
+		}
+
+	case reflect.Slice:
+		for i := 0; i < av.Len() && i < bv.Len(); i++ {
+			if c := compare(av.Index(i).Interface(), bv.Index(i).Interface()); c != 0 {
+				return c
+			}
+		}
+
+		return av.Len() - bv.Len()
+	}
+
+	return strings.Compare(key(a), key(b))
+}
+
+func toInt(v reflect.Value) int64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int()
+	case reflect.Float32, reflect.Float64:
+		return int64(v.Float())
+	}
+
+	return 0
+}
+
+func toFloat(v reflect.Value) float64 {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int())
+	case reflect.Float32, reflect.Float64:
+		return v.Float()
+	}
+
+	return 0
+}
+
+// key returns a string that is equal for equal values.
+func key(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case time.Time:
+		return fmt.Sprintf("time:%v", v.UnixNano())
+	case *big.Int:
+		return "varint:" + v.String()
+	case []byte:
+		return fmt.Sprintf("blob:%x", v)
+	}
+
+	return fmt.Sprintf("%T:%#v", v, v)
+}
+
+func keys(vs []interface{}) string {
+	var ss = make([]string, len(vs))
+
+	for i, v := range vs {
+		ss[i] = key(v)
+	}
+
+	return strings.Join(ss, "|")
+}
+
+func equal(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+
+	return compare(a, b) == 0
+}
+
+// normalizeSet sorts the slice v and removes duplicates.
+func normalizeSet(v interface{}) interface{} {
+	var s = reflect.ValueOf(v)
+
+	if s.Kind() != reflect.Slice || s.Len() == 0 {
+		return v
+	}
+
+	var es = make([]interface{}, s.Len())
+
+	for i := range es {
+		es[i] = s.Index(i).Interface()
+	}
+
+	sort.SliceStable(es, func(i, j int) bool {
+		return compare(es[i], es[j]) < 0
+	})
+
+	var out = reflect.MakeSlice(s.Type(), 0, len(es))
+
+	for i, e := range es {
+		if i > 0 && equal(es[i-1], e) {
+			continue
+		}
+
+		out = reflect.Append(out, reflect.ValueOf(e))
+	}
+
+	return out.Interface()
+}
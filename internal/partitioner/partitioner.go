@@ -0,0 +1,136 @@
+// Package partitioner computes the tokens of Cassandra partitioners from
+// serialized partition keys.
+package partitioner
+
+import (
+	"crypto/md5"
+	"encoding/binary"
+	"math"
+	"math/big"
+)
+
+// Key returns the serialized partition key of the serialized values of its
+// columns: the value of one column as is, and the values of more each as a
+// 2-byte length, the value, and a 0 byte.
+func Key(values [][]byte) []byte {
+	if len(values) == 1 {
+		return values[0]
+	}
+
+	var k []byte
+
+	for _, v := range values {
+		k = append(k, byte(len(v)>>8), byte(len(v)))
+		k = append(k, v...)
+		k = append(k, 0)
+	}
+
+	return k
+}
+
+// Murmur3 returns the Murmur3Partitioner token of k: the first half of the
+// 128-bit x64 MurmurHash3 as Cassandra computes it, with sign-extended tail
+// bytes, and math.MinInt64 replaced by math.MaxInt64.
+func Murmur3(k []byte) int64 {
+	const (
+		c1 = -8663945395140668459
+		c2 = 5545529020109919103
+	)
+
+	var h1, h2, k1, k2 int64
+	var blocks = len(k) / 16
+
+	for i := 0; i < blocks; i++ {
+		k1 = int64(binary.LittleEndian.Uint64(k[i*16:]))
+		k2 = int64(binary.LittleEndian.Uint64(k[i*16+8:]))
+
+		k1 *= c1
+		k1 = rotl(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+
+		h1 = rotl(h1, 27)
+		h1 += h2
+		h1 = h1*5 + 0x52dce729
+
+		k2 *= c2
+		k2 = rotl(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+
+		h2 = rotl(h2, 31)
+		h2 += h1
+		h2 = h2*5 + 0x38495ab5
+	}
+
+	var tail = k[blocks*16:]
+
+	k1, k2 = 0, 0
+
+	for i := len(tail) - 1; i >= 8; i-- {
+		k2 ^= int64(int8(tail[i])) << (8 * (i - 8))
+	}
+
+	if len(tail) > 8 {
+		k2 *= c2
+		k2 = rotl(k2, 33)
+		k2 *= c1
+		h2 ^= k2
+	}
+
+	for i := min(len(tail), 8) - 1; i >= 0; i-- {
+		k1 ^= int64(int8(tail[i])) << (8 * i)
+	}
+
+	if len(tail) > 0 {
+		k1 *= c1
+		k1 = rotl(k1, 31)
+		k1 *= c2
+		h1 ^= k1
+	}
+
+	h1 ^= int64(len(k))
+	h2 ^= int64(len(k))
+
+	h1 += h2
+	h2 += h1
+
+	h1 = fmix(h1)
+	h2 = fmix(h2)
+
+	h1 += h2
+
+	if h1 == math.MinInt64 {
+		return math.MaxInt64
+	}
+
+	return h1
+}
+
+func rotl(x int64, r uint) int64 {
+	return x<<r | int64(uint64(x)>>(64-r))
+}
+
+func fmix(k int64) int64 {
+	k ^= int64(uint64(k) >> 33)
+	k *= -49064778989728563
+	k ^= int64(uint64(k) >> 33)
+	k *= -4265267296055464877
+	k ^= int64(uint64(k) >> 33)
+
+	return k
+}
+
+// Random returns the RandomPartitioner token of k: the absolute value of its
+// MD5 hash as a signed integer.
+func Random(k []byte) *big.Int {
+	var sum = md5.Sum(k)
+	var t = new(big.Int).SetBytes(sum[:])
+
+	if sum[0] > 127 {
+		t.Sub(t, new(big.Int).Lsh(big.NewInt(1), 128))
+		t.Abs(t)
+	}
+
+	return t
+}
@@ -0,0 +1,79 @@
+package partitioner
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestMurmur3(t *testing.T) {
+	// The values of the Java driver for "", "0", "01", ..., with every tail
+	// length.
+	var series = []uint64{
+		0x0000000000000000,
+		0x2ac9debed546a380,
+		0x649e4eaa7fc1708e,
+		0xce68f60d7c353bdb,
+		0x0f95757ce7f38254,
+		0x0f04e459497f3fc1,
+		0x88c0a92586be0a27,
+		0x13eb9fb82606f7a6,
+		0x8236039b7387354d,
+		0x4c1e87519fe738ba,
+		0x3f9652ac3effeb24,
+		0x3f33760ded9006c6,
+		0xaed70a6631854cb1,
+		0x8a299a8f8e0e2da7,
+		0x624b675c779249a6,
+		0xa4b203bb1d90b9a3,
+		0xa3293ad698ecb99a,
+		0xbc740023dbd50048,
+		0x3fe5ab9837d25cdd,
+		0x2d0338c1ca87d132,
+	}
+
+	var k string
+
+	for i, e := range series {
+		if a := Murmur3([]byte(k)); a != int64(e) {
+			t.Errorf("Actual token %v, expected %v for %q", a, int64(e), k)
+		}
+
+		k += strconv.Itoa(i % 10)
+	}
+
+	for k, e := range map[string]uint64{
+		"hello":        0xcbd8a7b341bd9b02,
+		"hello, world": 0x342fac623a5ebc8e,
+		"The quick brown fox jumps over the lazy dog.": 0xcd99481f9ee902c9,
+	} {
+		if a := Murmur3([]byte(k)); a != int64(e) {
+			t.Errorf("Actual token %v, expected %v for %q", a, int64(e), k)
+		}
+	}
+
+	if a, e := Murmur3([]byte{0, 0, 0, 1}), int64(-4069959284402364209); a != e {
+		t.Errorf("Actual token %v, expected %v", a, e)
+	}
+}
+
+func TestRandom(t *testing.T) {
+	for k, e := range map[string]string{
+		"":  "58332598431525814501020785164969033090",
+		"a": "16955237001963240173058271559858726497",
+	} {
+		if a := Random([]byte(k)).String(); a != e {
+			t.Errorf("Actual token %v, expected %v for %q", a, e, k)
+		}
+	}
+}
+
+func TestKey(t *testing.T) {
+	if a, e := Key([][]byte{{1, 2}}), []byte{1, 2}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual key %v, expected %v", a, e)
+	}
+
+	if a, e := Key([][]byte{{1, 2}, {}}), []byte{0, 2, 1, 2, 0, 0, 0, 0}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual key %v, expected %v", a, e)
+	}
+}
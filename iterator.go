@@ -1,11 +1,18 @@
 package gockle
 
 import (
+	"iter"
+
 	"github.com/gocql/gocql"
 )
 
 // Iterator iterates CQL query result rows.
 type Iterator interface {
+	// All returns the result rows like ScanMap, followed by the error of Close
+	// if there is one. It closes the Iterator after the last row. If the loop
+	// stops early, it closes the Iterator, and Close returns the error again.
+	All() iter.Seq2[map[string]interface{}, error]
+
 	// Close closes the Iterator.
 	Close() error
 
@@ -21,9 +28,18 @@ type Iterator interface {
 	// next page is available.
 	WillSwitchPage() bool
 	// PageState return the current paging state for a query which can be used
-	// for subsequent quries to resume paging this point.
+	// for subsequent quries to resume paging this point. It is nil if there are
+	// no more pages.
 	PageState() []byte
 
+	// StructScan puts the current result row in the struct that dest points to
+	// and returns whether there are more result rows. Columns bind to fields by
+	// their cql tags, or by the snake case of their names; embedded structs are
+	// flattened, and pointer fields are nil for null values. A column without a
+	// field or of a type that does not fit its field stops the iteration, and
+	// Close returns the error.
+	StructScan(dest interface{}) bool
+
 	// SliceMap is a helper function to make the API easier to use
 	// returns the data from the query in the form of []map[string]interface{}
 	SliceMap() ([]map[string]interface{}, error)
@@ -31,33 +47,95 @@ type Iterator interface {
 
 var (
 	_ Iterator = &IteratorMock{}
-	_ Iterator = iterator{}
+	_ Iterator = &iterator{}
 )
 
 type iterator struct {
-	i *gocql.Iter
+	i         *gocql.Iter
+	statement string
+
+	err error
+}
+
+// error returns err as an *Error for the statement of i.
+func (i *iterator) error(err error) error {
+	return newError(err, i.statement, coordinator(i.i.Host()))
 }
 
-func (i iterator) Close() error {
-	return i.i.Close()
+func (i *iterator) All() iter.Seq2[map[string]interface{}, error] {
+	return all(i)
 }
 
-func (i iterator) Scan(results ...interface{}) bool {
+func (i *iterator) Close() error {
+	var err = i.i.Close()
+
+	if i.err != nil {
+		return i.err
+	}
+
+	return i.error(err)
+}
+
+func (i *iterator) Scan(results ...interface{}) bool {
 	return i.i.Scan(results...)
 }
 
-func (i iterator) ScanMap(results map[string]interface{}) bool {
+func (i *iterator) ScanMap(results map[string]interface{}) bool {
 	return i.i.MapScan(results)
 }
 
-func (i iterator) WillSwitchPage() bool {
+func (i *iterator) WillSwitchPage() bool {
 	return i.i.WillSwitchPage()
 }
 
-func (i iterator) PageState() []byte {
-	return i.i.PageState()
+func (i *iterator) PageState() []byte {
+	// gocql returns an empty state instead of nil for prepared statements.
+	if s := i.i.PageState(); len(s) > 0 {
+		return s
+	}
+
+	return nil
 }
 
-func (i iterator) SliceMap() ([]map[string]interface{}, error) {
-	return i.i.SliceMap()
+func (i *iterator) StructScan(dest interface{}) bool {
+	if i.err != nil {
+		return false
+	}
+
+	var more, err = structScan(i.i.Columns(), dest, func(dest []interface{}) bool {
+		return i.i.Scan(dest...)
+	})
+
+	i.err = err
+
+	return more
+}
+
+func (i *iterator) SliceMap() ([]map[string]interface{}, error) {
+	var ms, err = i.i.SliceMap()
+
+	return ms, i.error(err)
+}
+
+// all returns the rows of i as described by Iterator.All.
+func all(i Iterator) iter.Seq2[map[string]interface{}, error] {
+	return func(yield func(map[string]interface{}, error) bool) {
+		for {
+			var m = map[string]interface{}{}
+
+			if !i.ScanMap(m) {
+				break
+			}
+
+			if !yield(m, nil) {
+				i.Close()
+
+				return
+			}
+		}
+
+		if err := i.Close(); err != nil {
+			yield(nil, err)
+		}
+	}
 }
@@ -1,12 +1,32 @@
 package gockle
 
-import "github.com/stretchr/testify/mock"
+import (
+	"iter"
+
+	"github.com/stretchr/testify/mock"
+)
 
 // IteratorMock is an autogenerated mock type for the Iterator type
 type IteratorMock struct {
 	mock.Mock
 }
 
+// All provides a mock function with given fields:
+func (_m *IteratorMock) All() iter.Seq2[map[string]interface{}, error] {
+	ret := _m.Called()
+
+	var r0 iter.Seq2[map[string]interface{}, error]
+	if rf, ok := ret.Get(0).(func() iter.Seq2[map[string]interface{}, error]); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(iter.Seq2[map[string]interface{}, error])
+		}
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields:
 func (_m *IteratorMock) Close() error {
 	ret := _m.Called()
@@ -90,6 +110,20 @@ func (_m *IteratorMock) SliceMap() ([]map[string]interface{}, error) {
 	return r0, r1
 }
 
+// StructScan provides a mock function with given fields: dest
+func (_m *IteratorMock) StructScan(dest interface{}) bool {
+	ret := _m.Called(dest)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(interface{}) bool); ok {
+		r0 = rf(dest)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	return r0
+}
+
 // WillSwitchPage provides a mock function with given fields:
 func (_m *IteratorMock) WillSwitchPage() bool {
 	ret := _m.Called()
@@ -161,3 +161,80 @@ func TestIteratorPaging(t *testing.T) {
 		}
 	}
 }
+
+func TestIteratorAll(t *testing.T) {
+	var closes int
+	var count = func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			if o.Kind == OperationIterClose {
+				closes++
+			}
+
+			return next(ctx, o)
+		}
+	}
+
+	var s = Chain(newMemorySession(t, rowInsert, rowInsert2), count)
+
+	defer s.Close()
+
+	var rows []map[string]interface{}
+
+	for row, err := range s.ScanIterator("select * from gockle_test.test").All() {
+		if err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		}
+
+		rows = append(rows, row)
+	}
+
+	if e := []map[string]interface{}{{"id": 1, "n": 2}, {"id": 3, "n": 4}}; !reflect.DeepEqual(rows, e) {
+		t.Errorf("Actual rows %v, expected %v", rows, e)
+	}
+
+	if closes != 1 {
+		t.Errorf("Actual closes %v, expected 1", closes)
+	}
+
+	for range s.ScanIterator("select * from gockle_test.test").All() {
+		break
+	}
+
+	if closes != 2 {
+		t.Errorf("Actual closes %v, expected 2", closes)
+	}
+
+	var errs []error
+
+	for _, err := range s.ScanIterator("select * from gockle_test.missing").All() {
+		errs = append(errs, err)
+	}
+
+	if len(errs) != 1 || errs[0] == nil {
+		t.Errorf("Actual errors %v, expected one error", errs)
+	}
+
+	var ns []int
+
+	for n, err := range NewRows[int](s.ScanIterator("select n from gockle_test.test")).All() {
+		if err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		}
+
+		ns = append(ns, n)
+	}
+
+	if e := []int{2, 4}; !reflect.DeepEqual(ns, e) {
+		t.Errorf("Actual rows %v, expected %v", ns, e)
+	}
+
+	var r = NewRows[int](s.ScanIterator("select n from gockle_test.missing"))
+
+	for range r.All() {
+		break
+	}
+
+	if r.Err() == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
@@ -0,0 +1,889 @@
+package gockle
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"iter"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/memdb"
+)
+
+var (
+	_ Batch    = &memoryBatch{}
+	_ Iterator = &memoryIterator{}
+	_ Query    = &memoryQuery{}
+	_ Session  = &memorySession{}
+)
+
+// NewMemorySession returns a new Session backed by an in-process store instead
+// of a Cassandra cluster. It understands a subset of CQL: CREATE and DROP
+// KEYSPACE and TABLE, USE, TRUNCATE, INSERT, SELECT, UPDATE, DELETE, and BEGIN
+// BATCH, including partition and clustering key predicates, IN, ORDER BY,
+// LIMIT, IF EXISTS, IF NOT EXISTS, and IF conditions, and token relations on
+// the partition key in SELECT, by the Murmur3 partitioner. Columns and Tables answer
+// from the schema created through the Session.
+//
+// The store is permissive: it filters on any column without ALLOW FILTERING,
+// and it ignores TTLs, timestamps, consistency levels, retry policies, tracers,
+// and routing keys.
+func NewMemorySession() Session {
+	return &memorySession{db: memdb.New(), limit: newLimiter(DefaultAsyncLimit)}
+}
+
+type memorySession struct {
+	db *memdb.DB
+
+	mu       sync.Mutex
+	closed   bool
+	keyspace string
+
+	feed  schemaFeed
+	limit limiter
+}
+
+func (s *memorySession) exec(statement string, arguments []interface{}) (*memdb.Result, error) {
+	s.mu.Lock()
+	var closed, keyspace = s.closed, s.keyspace
+	s.mu.Unlock()
+
+	if closed {
+		return nil, gocql.ErrSessionClosed
+	}
+
+	var r, err = s.db.Exec(keyspace, statement, arguments)
+
+	if err != nil {
+		return nil, newError(err, statement, "")
+	}
+
+	if r.Keyspace != "" {
+		s.mu.Lock()
+		s.keyspace = r.Keyspace
+		s.mu.Unlock()
+	}
+
+	if c := r.Change; c != nil {
+		s.feed.publish(SchemaChange{Kind: c.Kind, Target: c.Target, Keyspace: c.Keyspace, Table: c.Table})
+	}
+
+	return r, nil
+}
+
+func (s *memorySession) metadata(keyspace string) (*memdb.Keyspace, error) {
+	s.mu.Lock()
+	var closed = s.closed
+	s.mu.Unlock()
+
+	if closed {
+		return nil, gocql.ErrSessionClosed
+	}
+
+	var k = s.db.Keyspace(keyspace)
+
+	if k == nil {
+		return nil, keyspaceNotFound(keyspace)
+	}
+
+	return k, nil
+}
+
+// AwaitSchemaAgreement returns ctx.Err(). There is one node, so it always
+// agrees.
+func (s *memorySession) AwaitSchemaAgreement(ctx context.Context) error {
+	return ctx.Err()
+}
+
+func (s *memorySession) asyncLimiter() limiter {
+	return s.limit
+}
+
+func (s *memorySession) Batch(kind BatchKind) Batch {
+	return &memoryBatch{s: s, kind: kind, ctx: context.Background()}
+}
+
+func (s *memorySession) Close() {
+	s.mu.Lock()
+	s.closed = true
+	s.mu.Unlock()
+
+	s.feed.close()
+}
+
+func (s *memorySession) Columns(keyspace, table string) (map[string]gocql.TypeInfo, error) {
+	return s.ColumnsContext(context.Background(), keyspace, table)
+}
+
+func (s *memorySession) ColumnsContext(ctx context.Context, keyspace, table string) (map[string]gocql.TypeInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var k, err = s.metadata(keyspace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var t, ok = k.Tables[table]
+
+	if !ok {
+		return nil, tableNotFound(keyspace, table)
+	}
+
+	var types = map[string]gocql.TypeInfo{}
+
+	for _, c := range t.Columns {
+		types[c.Name] = c.Type
+	}
+
+	return types, nil
+}
+
+func (s *memorySession) Exec(statement string, arguments ...interface{}) error {
+	return s.ExecContext(context.Background(), statement, arguments...)
+}
+
+func (s *memorySession) ExecContext(ctx context.Context, statement string, arguments ...interface{}) error {
+	return s.Query(statement, arguments...).WithContext(ctx).Exec()
+}
+
+func (s *memorySession) ExecAsync(statement string, arguments ...interface{}) *Future {
+	return s.ExecAsyncContext(context.Background(), statement, arguments...)
+}
+
+func (s *memorySession) ExecAsyncContext(ctx context.Context, statement string, arguments ...interface{}) *Future {
+	return s.limit.run(ctx, func(*Future) error {
+		return s.ExecContext(ctx, statement, arguments...)
+	})
+}
+
+func (s *memorySession) Scan(statement string, results []interface{}, arguments ...interface{}) error {
+	return s.ScanContext(context.Background(), statement, results, arguments...)
+}
+
+func (s *memorySession) ScanContext(ctx context.Context, statement string, results []interface{}, arguments ...interface{}) error {
+	return s.Query(statement, arguments...).WithContext(ctx).Scan(results...)
+}
+
+func (s *memorySession) ScanIterator(statement string, arguments ...interface{}) Iterator {
+	return s.ScanIteratorContext(context.Background(), statement, arguments...)
+}
+
+func (s *memorySession) ScanIteratorContext(ctx context.Context, statement string, arguments ...interface{}) Iterator {
+	return s.Query(statement, arguments...).WithContext(ctx).Iter()
+}
+
+func (s *memorySession) ScanMap(statement string, results map[string]interface{}, arguments ...interface{}) error {
+	return s.ScanMapContext(context.Background(), statement, results, arguments...)
+}
+
+func (s *memorySession) ScanMapContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) error {
+	return s.Query(statement, arguments...).WithContext(ctx).MapScan(results)
+}
+
+func (s *memorySession) ScanMapAsync(statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	return s.ScanMapAsyncContext(context.Background(), statement, results, arguments...)
+}
+
+func (s *memorySession) ScanMapAsyncContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	return s.limit.run(ctx, func(*Future) error {
+		return s.ScanMapContext(ctx, statement, results, arguments...)
+	})
+}
+
+func (s *memorySession) ScanMapSlice(statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
+	return s.ScanMapSliceContext(context.Background(), statement, arguments...)
+}
+
+func (s *memorySession) ScanMapSliceContext(ctx context.Context, statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
+	return s.Query(statement, arguments...).WithContext(ctx).Iter().SliceMap()
+}
+
+func (s *memorySession) ScanMapTx(statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
+	return s.ScanMapTxContext(context.Background(), statement, results, arguments...)
+}
+
+func (s *memorySession) ScanMapTxContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, err
+	}
+
+	var r, err = s.exec(statement, arguments)
+
+	if err != nil {
+		return false, err
+	}
+
+	return scanMapTx(r, results)
+}
+
+func (s *memorySession) ScanStruct(statement string, dest interface{}, arguments ...interface{}) error {
+	return s.ScanStructContext(context.Background(), statement, dest, arguments...)
+}
+
+func (s *memorySession) ScanStructContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	return s.Query(statement, arguments...).WithContext(ctx).ScanStruct(dest)
+}
+
+func (s *memorySession) ScanStructSlice(statement string, dest interface{}, arguments ...interface{}) error {
+	return s.ScanStructSliceContext(context.Background(), statement, dest, arguments...)
+}
+
+func (s *memorySession) ScanStructSliceContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	return s.Query(statement, arguments...).WithContext(ctx).ScanStructSlice(dest)
+}
+
+func (s *memorySession) Tables(keyspace string) ([]string, error) {
+	return s.TablesContext(context.Background(), keyspace)
+}
+
+func (s *memorySession) TablesContext(ctx context.Context, keyspace string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var k, err = s.metadata(keyspace)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return k.TableNames(), nil
+}
+
+func (s *memorySession) Keyspace(keyspace string) (KeyspaceInfo, error) {
+	return s.KeyspaceContext(context.Background(), keyspace)
+}
+
+// KeyspaceContext returns the metadata of keyspace. There are no views, types,
+// functions, aggregates, or indexes, and table options are the strings given
+// to CREATE TABLE.
+func (s *memorySession) KeyspaceContext(ctx context.Context, keyspace string) (KeyspaceInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return KeyspaceInfo{}, err
+	}
+
+	var k, err = s.metadata(keyspace)
+
+	if err != nil {
+		return KeyspaceInfo{}, err
+	}
+
+	var info = KeyspaceInfo{Name: k.Name, DurableWrites: k.DurableWrites, Replication: map[string]string{}}
+
+	for n, v := range k.Replication {
+		info.Replication[n] = v
+	}
+
+	for _, n := range k.TableNames() {
+		info.Tables = append(info.Tables, memoryTableInfo(k.Tables[n]))
+	}
+
+	return info, nil
+}
+
+func (s *memorySession) Table(keyspace, table string) (TableInfo, error) {
+	return s.TableContext(context.Background(), keyspace, table)
+}
+
+func (s *memorySession) TableContext(ctx context.Context, keyspace, table string) (TableInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return TableInfo{}, err
+	}
+
+	var k, err = s.metadata(keyspace)
+
+	if err != nil {
+		return TableInfo{}, err
+	}
+
+	var t, ok = k.Tables[table]
+
+	if !ok {
+		return TableInfo{}, tableNotFound(keyspace, table)
+	}
+
+	return memoryTableInfo(t), nil
+}
+
+func (s *memorySession) RefreshSchema(keyspace string) error {
+	return s.RefreshSchemaContext(context.Background(), keyspace)
+}
+
+// RefreshSchemaContext only checks that keyspace exists. The metadata is never
+// cached.
+func (s *memorySession) RefreshSchemaContext(ctx context.Context, keyspace string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	var _, err = s.metadata(keyspace)
+
+	return err
+}
+
+// SchemaChanges sends the changes as the statements of the Session make them.
+func (s *memorySession) SchemaChanges() <-chan SchemaChange {
+	return s.feed.subscribe()
+}
+
+func (s *memorySession) Token(keyspace, table string, keyValues ...interface{}) (*big.Int, error) {
+	return s.TokenContext(context.Background(), keyspace, table, keyValues...)
+}
+
+func (s *memorySession) TokenContext(ctx context.Context, keyspace, table string, keyValues ...interface{}) (*big.Int, error) {
+	var t, err = s.TableContext(ctx, keyspace, table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var p string
+
+	if err := s.ScanContext(ctx, "select partitioner from system.local", []interface{}{&p}); err != nil {
+		return nil, err
+	}
+
+	return token(p, t, keyValues)
+}
+
+func (s *memorySession) Query(statement string, arguments ...interface{}) Query {
+	return &memoryQuery{s: s, statement: statement, arguments: arguments, ctx: context.Background()}
+}
+
+func memoryTableInfo(t *memdb.Table) TableInfo {
+	var info = TableInfo{Keyspace: t.Keyspace, Name: t.Name, Options: map[string]interface{}{}}
+
+	info.PartitionKey = append(info.PartitionKey, t.PartitionKey...)
+	info.ClusteringColumns = append(info.ClusteringColumns, t.Clustering...)
+
+	for _, c := range t.Columns {
+		info.Columns = append(info.Columns, ColumnInfo{Name: c.Name, Type: c.Type, Kind: ColumnKind(c.Kind), Position: c.Position, Descending: c.Desc})
+	}
+
+	for n, v := range t.Options {
+		info.Options[n] = v
+	}
+
+	return info
+}
+
+// scanMapTx puts the first row of r except ColumnApplied in results and
+// returns ColumnApplied, like gocql.Query.MapScanCAS.
+func scanMapTx(r *memdb.Result, results map[string]interface{}) (bool, error) {
+	if len(r.Rows) == 0 {
+		return false, gocql.ErrNotFound
+	}
+
+	mapRow(r.Columns, r.Rows[0], results)
+
+	var applied, _ = results[ColumnApplied].(bool)
+
+	delete(results, ColumnApplied)
+
+	return applied, nil
+}
+
+// applied returns whether r is not the result of a conditional statement that
+// is not applied.
+func applied(r *memdb.Result) bool {
+	if len(r.Columns) == 0 || r.Columns[0].Name != ColumnApplied || len(r.Rows) == 0 {
+		return true
+	}
+
+	var a, _ = r.Rows[0][0].(bool)
+
+	return a
+}
+
+func mapRow(columns []memdb.Column, row []interface{}, m map[string]interface{}) {
+	for i, c := range columns {
+		if row[i] == nil {
+			m[c.Name] = memdb.Zero(c.Type)
+		} else {
+			m[c.Name] = row[i]
+		}
+	}
+}
+
+func scanRow(columns []memdb.Column, row []interface{}, dest []interface{}) error {
+	if len(dest) != len(columns) {
+		return fmt.Errorf("gocql: not enough columns to scan into: have %d want %d", len(dest), len(columns))
+	}
+
+	for i, c := range columns {
+		if err := memdb.Scan(c.Type, row[i], dest[i]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+type memoryBatch struct {
+	s *memorySession
+
+	kind    BatchKind
+	entries []memdb.Entry
+	ctx     context.Context
+	err     error
+}
+
+func (b *memoryBatch) Add(statement string, arguments ...interface{}) {
+	b.entries = append(b.entries, memdb.Entry{Statement: statement, Values: arguments})
+}
+
+func (b *memoryBatch) AddWithTimestamp(timestamp int64, statement string, arguments ...interface{}) {
+	var s, err = usingTimestamp(statement, timestamp)
+
+	if err != nil {
+		if b.err == nil {
+			b.err = err
+		}
+
+		return
+	}
+
+	b.Add(s, arguments...)
+}
+
+func (b *memoryBatch) WithContext(ctx context.Context) Batch {
+	b.ctx = ctx
+
+	return b
+}
+
+func (b *memoryBatch) Consistency(c gocql.Consistency) Batch {
+	return b
+}
+
+func (b *memoryBatch) SerialConsistency(c gocql.SerialConsistency) Batch {
+	return b
+}
+
+func (b *memoryBatch) WithTimestamp(timestamp int64) Batch {
+	return b
+}
+
+func (b *memoryBatch) Size() int {
+	return len(b.entries)
+}
+
+func (b *memoryBatch) Statements() []BatchEntry {
+	var es = make([]BatchEntry, len(b.entries))
+
+	for i, e := range b.entries {
+		es[i] = BatchEntry{Statement: e.Statement, Arguments: e.Values}
+	}
+
+	return es
+}
+
+func (b *memoryBatch) Reset() {
+	b.entries, b.err = nil, nil
+}
+
+func (b *memoryBatch) exec(ctx context.Context) (*memdb.Result, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	b.s.mu.Lock()
+	var closed, keyspace = b.s.closed, b.s.keyspace
+	b.s.mu.Unlock()
+
+	if closed {
+		return nil, gocql.ErrSessionClosed
+	}
+
+	var r, err = b.s.db.Batch(keyspace, b.entries)
+
+	if err != nil {
+		return nil, newError(err, b.entries[0].Statement, "")
+	}
+
+	return r, nil
+}
+
+func (b *memoryBatch) Exec() error {
+	return b.ExecContext(b.ctx)
+}
+
+func (b *memoryBatch) ExecContext(ctx context.Context) error {
+	var r, err = b.exec(ctx)
+
+	if err != nil {
+		return err
+	}
+
+	if reportNotApplied(ctx) && !applied(r) {
+		return notApplied(b.entries[0].Statement, "")
+	}
+
+	return nil
+}
+
+func (b *memoryBatch) ExecTx() ([]map[string]interface{}, error) {
+	return b.ExecTxContext(b.ctx)
+}
+
+func (b *memoryBatch) ExecTxContext(ctx context.Context) ([]map[string]interface{}, error) {
+	var r, err = b.exec(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Rows) == 0 {
+		return nil, gocql.ErrNotFound
+	}
+
+	var ms = make([]map[string]interface{}, len(r.Rows))
+
+	for i, row := range r.Rows {
+		ms[i] = map[string]interface{}{}
+		mapRow(r.Columns, row, ms[i])
+	}
+
+	return ms, nil
+}
+
+type memoryQuery struct {
+	s *memorySession
+
+	statement string
+	arguments []interface{}
+
+	consistency gocql.Consistency
+	ctx         context.Context
+	idempotent  bool
+	observer    gocql.QueryObserver
+	pageSize    int
+	pageState   []byte
+}
+
+func (q *memoryQuery) Consistency(c gocql.Consistency) Query {
+	q.consistency = c
+
+	return q
+}
+
+func (q *memoryQuery) PageSize(n int) Query {
+	q.pageSize = n
+
+	return q
+}
+
+func (q *memoryQuery) WithContext(ctx context.Context) Query {
+	q.ctx = ctx
+
+	return q
+}
+
+func (q *memoryQuery) PageState(state []byte) Query {
+	q.pageState = state
+
+	return q
+}
+
+func (q *memoryQuery) Idempotent(value bool) Query {
+	q.idempotent = value
+
+	return q
+}
+
+func (q *memoryQuery) SerialConsistency(c gocql.SerialConsistency) Query {
+	return q
+}
+
+func (q *memoryQuery) WithTimestamp(timestamp int64) Query {
+	return q
+}
+
+func (q *memoryQuery) DefaultTimestamp(enable bool) Query {
+	return q
+}
+
+func (q *memoryQuery) RetryPolicy(r gocql.RetryPolicy) Query {
+	return q
+}
+
+func (q *memoryQuery) Trace(t gocql.Tracer) Query {
+	return q
+}
+
+// Observer sets an observer that is called once for each run of q, without a
+// host or metrics.
+func (q *memoryQuery) Observer(o gocql.QueryObserver) Query {
+	q.observer = o
+
+	return q
+}
+
+func (q *memoryQuery) NoSkipMetadata() Query {
+	return q
+}
+
+func (q *memoryQuery) RoutingKey(key []byte) Query {
+	return q
+}
+
+func (q *memoryQuery) Bind(values ...interface{}) Query {
+	q.arguments = values
+
+	return q
+}
+
+func (q *memoryQuery) exec() (*memdb.Result, error) {
+	if err := q.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if q.observer == nil {
+		return q.s.exec(q.statement, q.arguments)
+	}
+
+	q.s.mu.Lock()
+	var o = gocql.ObservedQuery{Keyspace: q.s.keyspace, Statement: q.statement, Values: q.arguments, Start: time.Now()}
+	q.s.mu.Unlock()
+
+	var r, err = q.s.exec(q.statement, q.arguments)
+
+	o.End, o.Err = time.Now(), err
+
+	if r != nil {
+		o.Rows = len(r.Rows)
+	}
+
+	q.observer.ObserveQuery(q.ctx, o)
+
+	return r, err
+}
+
+func (q *memoryQuery) Exec() error {
+	var r, err = q.exec()
+
+	if err != nil {
+		return err
+	}
+
+	if reportNotApplied(q.ctx) && !applied(r) {
+		return notApplied(q.statement, "")
+	}
+
+	return nil
+}
+
+func (q *memoryQuery) Iter() Iterator {
+	var r, err = q.exec()
+
+	if err != nil {
+		return &memoryIterator{err: err}
+	}
+
+	var i = &memoryIterator{columns: r.Columns, rows: r.Rows, pageSize: q.pageSize}
+
+	if q.pageSize > 0 && len(q.pageState) == 8 {
+		i.offset = int(binary.BigEndian.Uint64(q.pageState))
+
+		if i.offset > len(i.rows) {
+			i.offset = len(i.rows)
+		}
+	}
+
+	i.pos = i.offset
+
+	return i
+}
+
+func (q *memoryQuery) IterAsync() *Future {
+	return q.s.limit.iter(q.ctx, q.Iter)
+}
+
+func (q *memoryQuery) MapScan(m map[string]interface{}) error {
+	var r, err = q.exec()
+
+	if err != nil {
+		return err
+	}
+
+	if len(r.Rows) == 0 {
+		return gocql.ErrNotFound
+	}
+
+	mapRow(r.Columns, r.Rows[0], m)
+
+	return nil
+}
+
+func (q *memoryQuery) Scan(dest ...interface{}) error {
+	var r, err = q.exec()
+
+	if err != nil {
+		return err
+	}
+
+	if len(r.Rows) == 0 {
+		return gocql.ErrNotFound
+	}
+
+	return scanRow(r.Columns, r.Rows[0], dest)
+}
+
+func (q *memoryQuery) ScanStruct(dest interface{}) error {
+	return scanStruct(q.Iter(), dest)
+}
+
+func (q *memoryQuery) ScanStructSlice(dest interface{}) error {
+	return scanStructSlice(q.Iter(), dest)
+}
+
+func (q *memoryQuery) Release() {}
+
+// memoryIterator iterates rows[offset:]. With a page size, the rows are split
+// into pages of that size starting at offset.
+type memoryIterator struct {
+	columns []memdb.Column
+	rows    [][]interface{}
+
+	offset   int
+	pageSize int
+	pos      int
+
+	err error
+}
+
+func (i *memoryIterator) All() iter.Seq2[map[string]interface{}, error] {
+	return all(i)
+}
+
+func (i *memoryIterator) Close() error {
+	return i.err
+}
+
+func (i *memoryIterator) next() ([]interface{}, bool) {
+	if i.err != nil || i.pos >= len(i.rows) {
+		return nil, false
+	}
+
+	i.pos++
+
+	return i.rows[i.pos-1], true
+}
+
+func (i *memoryIterator) Scan(results ...interface{}) bool {
+	var row, ok = i.next()
+
+	if !ok {
+		return false
+	}
+
+	if err := scanRow(i.columns, row, results); err != nil {
+		i.err = err
+
+		return false
+	}
+
+	return true
+}
+
+func (i *memoryIterator) ScanMap(results map[string]interface{}) bool {
+	var row, ok = i.next()
+
+	if !ok {
+		return false
+	}
+
+	mapRow(i.columns, row, results)
+
+	return true
+}
+
+// nextPage returns the offset of the page after the current one, which is the
+// page of the last row scanned.
+func (i *memoryIterator) nextPage() int {
+	var scanned = i.pos - i.offset
+
+	if scanned > 0 {
+		scanned--
+	}
+
+	return i.offset + (scanned/i.pageSize+1)*i.pageSize
+}
+
+func (i *memoryIterator) WillSwitchPage() bool {
+	if i.pageSize <= 0 || i.pos == i.offset {
+		return false
+	}
+
+	return (i.pos-i.offset)%i.pageSize == 0 && i.pos < len(i.rows)
+}
+
+func (i *memoryIterator) PageState() []byte {
+	if i.pageSize <= 0 || i.err != nil {
+		return nil
+	}
+
+	var next = i.nextPage()
+
+	if next >= len(i.rows) {
+		return nil
+	}
+
+	var state = make([]byte, 8)
+
+	binary.BigEndian.PutUint64(state, uint64(next))
+
+	return state
+}
+
+func (i *memoryIterator) StructScan(dest interface{}) bool {
+	if i.err != nil || i.pos >= len(i.rows) {
+		return false
+	}
+
+	var columns = make([]gocql.ColumnInfo, len(i.columns))
+
+	for j, c := range i.columns {
+		columns[j] = gocql.ColumnInfo{Keyspace: c.Keyspace, Table: c.Table, Name: c.Name, TypeInfo: c.Type}
+	}
+
+	var more, err = structScan(columns, dest, func(dest []interface{}) bool {
+		return i.Scan(dest...)
+	})
+
+	if err != nil {
+		i.err = err
+	}
+
+	return more
+}
+
+func (i *memoryIterator) SliceMap() ([]map[string]interface{}, error) {
+	var ms []map[string]interface{}
+
+	for {
+		var m = map[string]interface{}{}
+
+		if !i.ScanMap(m) {
+			break
+		}
+
+		ms = append(ms, m)
+	}
+
+	if i.err != nil {
+		return nil, i.err
+	}
+
+	return ms, nil
+}
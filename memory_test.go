@@ -0,0 +1,427 @@
+package gockle
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func newMemorySession(t *testing.T, statements ...string) Session {
+	var s = NewMemorySession()
+
+	for _, st := range append([]string{ksCreate, tabCreate}, statements...) {
+		if err := s.Exec(st); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	return s
+}
+
+func TestMemorySessionMetadata(t *testing.T) {
+	var s = newMemorySession(t)
+
+	if a, err := s.Tables("gockle_test"); err == nil {
+		if e := ([]string{"test"}); !reflect.DeepEqual(a, e) {
+			t.Errorf("Actual tables %v, expected %v", a, e)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if _, err := s.Tables("gockle_test_invalid"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if a, err := s.Columns("gockle_test", "test"); err == nil {
+		var ts = map[string]gocql.Type{"id": gocql.TypeInt, "n": gocql.TypeInt}
+
+		if la, le := len(a), len(ts); la != le {
+			t.Fatalf("Actual count %v, expected %v", la, le)
+		}
+
+		for n, at := range a {
+			if et := ts[n]; at.Type() != et {
+				t.Errorf("Actual type %v, expected %v", at, et)
+			}
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if _, err := s.Columns("gockle_test", "invalid"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if err := s.Exec(tabDrop); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if a, err := s.Tables("gockle_test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(a) != 0 {
+		t.Errorf("Actual tables %v, expected none", a)
+	}
+
+	s.Close()
+
+	if _, err := s.Tables("gockle_test"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestMemorySessionQuery(t *testing.T) {
+	var s = newMemorySession(t, rowInsert)
+
+	// Scan
+	var id, n int
+
+	if err := s.Scan("select id, n from gockle_test.test", []interface{}{&id, &n}); err == nil {
+		if id != 1 {
+			t.Errorf("Actual id %v, expected 1", id)
+		}
+
+		if n != 2 {
+			t.Errorf("Actual n %v, expected 2", n)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Scan("select id, n from gockle_test.test where id = ?", []interface{}{&id, &n}, 9); err != gocql.ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, gocql.ErrNotFound)
+	}
+
+	// ScanMap
+	var am, em = map[string]interface{}{}, map[string]interface{}{"id": 1, "n": 2}
+
+	if err := s.ScanMap("select id, n from gockle_test.test", am); err == nil {
+		if !reflect.DeepEqual(am, em) {
+			t.Errorf("Actual map %v, expected %v", am, em)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	// ScanMapTx
+	am = map[string]interface{}{}
+
+	if b, err := s.ScanMapTx("update gockle_test.test set n = 3 where id = 1 if n = 2", am); err == nil {
+		if !b {
+			t.Error("Actual applied false, expected true")
+		}
+
+		if l := len(am); l != 0 {
+			t.Errorf("Actual length %v, expected 0", l)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	am = map[string]interface{}{}
+
+	if b, err := s.ScanMapTx("update gockle_test.test set n = 4 where id = 1 if n = 2", am); err == nil {
+		if b {
+			t.Error("Actual applied true, expected false")
+		}
+
+		if e := (map[string]interface{}{"n": 3}); !reflect.DeepEqual(am, e) {
+			t.Errorf("Actual map %v, expected %v", am, e)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	am = map[string]interface{}{}
+
+	if b, err := s.ScanMapTx("insert into gockle_test.test (id, n) values (1, 5) if not exists", am); err == nil {
+		if b {
+			t.Error("Actual applied true, expected false")
+		}
+
+		if e := (map[string]interface{}{"id": 1, "n": 3}); !reflect.DeepEqual(am, e) {
+			t.Errorf("Actual map %v, expected %v", am, e)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	// ScanMapSlice
+	var es = []map[string]interface{}{{"id": 1, "n": 3}}
+
+	if as, err := s.ScanMapSlice("select * from gockle_test.test"); err == nil {
+		if !reflect.DeepEqual(as, es) {
+			t.Errorf("Actual rows %v, expected %v", as, es)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	// Exec
+	if err := s.Exec("delete from gockle_test.test where id = ?", 1); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if as, err := s.ScanMapSlice("select * from gockle_test.test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(as) != 0 {
+		t.Errorf("Actual rows %v, expected none", as)
+	}
+
+	if err := s.Exec("select * from gockle_test.invalid"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if err := s.Exec("selec * from gockle_test.test"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	s.Close()
+
+	if err := s.Exec(rowInsert); err != gocql.ErrSessionClosed {
+		t.Errorf("Actual error %v, expected %v", err, gocql.ErrSessionClosed)
+	}
+}
+
+func TestMemorySessionClustering(t *testing.T) {
+	var s = newMemorySession(t,
+		"create table gockle_test.events (user text, at int, kind text, tags set<text>, primary key ((user), at)) with clustering order by (at desc)",
+		"use gockle_test")
+
+	for i := 1; i <= 5; i++ {
+		if err := s.Exec("insert into events (user, at, kind) values (?, ?, ?)", "alex", i, "login"); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	if err := s.Exec("insert into events (user, at, kind) values ('bo', 1, 'logout')"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var ats = func(statement string, arguments ...interface{}) []int {
+		var rows, err = s.ScanMapSlice(statement, arguments...)
+
+		if err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		var ats []int
+
+		for _, r := range rows {
+			ats = append(ats, r["at"].(int))
+		}
+
+		return ats
+	}
+
+	for _, c := range []struct {
+		statement string
+		arguments []interface{}
+		expected  []int
+	}{
+		{"select at from events where user = ?", []interface{}{"alex"}, []int{5, 4, 3, 2, 1}},
+		{"select at from events where user = ? order by at asc", []interface{}{"alex"}, []int{1, 2, 3, 4, 5}},
+		{"select at from events where user = ? and at > ? and at <= ?", []interface{}{"alex", 1, 3}, []int{3, 2}},
+		{"select at from events where user = ? limit 2", []interface{}{"alex"}, []int{5, 4}},
+		{"select at from events where user in ('alex', 'bo') and at in (1, 2)", nil, []int{2, 1, 1}},
+		{"select at from events where user = ? and at in ?", []interface{}{"alex", []int{2, 4}}, []int{4, 2}},
+		{"select at from events where kind = 'logout' allow filtering", nil, []int{1}},
+	} {
+		if a := ats(c.statement, c.arguments...); !reflect.DeepEqual(a, c.expected) {
+			t.Errorf("Actual rows %v, expected %v for %v", a, c.expected, c.statement)
+		}
+	}
+
+	var count int64
+
+	if err := s.Scan("select count(*) from events where user = 'alex'", []interface{}{&count}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if count != 5 {
+		t.Errorf("Actual count %v, expected 5", count)
+	}
+
+	if err := s.Exec("update events set tags = tags + {'b', 'a'} where user = 'alex' and at = 1"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var tags []string
+
+	if err := s.Scan("select tags from events where user = 'alex' and at = 1", []interface{}{&tags}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []string{"a", "b"}; !reflect.DeepEqual(tags, e) {
+		t.Errorf("Actual tags %v, expected %v", tags, e)
+	}
+
+	if err := s.Exec("delete from events where user = 'alex' and at < 3"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if a, e := ats("select at from events where user = 'alex'"), []int{5, 4, 3}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	if err := s.Exec("insert into events (user, kind) values ('alex', 'login')"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestMemorySessionCounter(t *testing.T) {
+	var s = newMemorySession(t, "create table gockle_test.counts (id int primary key, c counter)")
+
+	for i := 0; i < 3; i++ {
+		if err := s.Exec("update gockle_test.counts set c = c + ? where id = 1", 2); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	var c int64
+
+	if err := s.Scan("select c from gockle_test.counts where id = 1", []interface{}{&c}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if c != 6 {
+		t.Errorf("Actual counter %v, expected 6", c)
+	}
+
+	if err := s.Exec("update gockle_test.counts set c = 1 where id = 1"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestMemoryBatch(t *testing.T) {
+	var s = newMemorySession(t, rowInsert)
+	var b = s.Batch(BatchLogged)
+
+	b.Add("update gockle_test.test set n = 3 where id = 1 if n = 2")
+
+	if err := b.Exec(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	b = s.Batch(BatchLogged)
+	b.Add("update gockle_test.test set n = 4 where id = 1 if n = 3")
+
+	if a, err := b.ExecTx(); err == nil {
+		if e := ([]map[string]interface{}{{"[applied]": true}}); !reflect.DeepEqual(a, e) {
+			t.Errorf("Actual tx %v, expected %v", a, e)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	b = s.Batch(BatchLogged)
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 2, 2)
+	b.Add("update gockle_test.test set n = 5 where id = 1 if n = 3")
+
+	if a, err := b.ExecTx(); err == nil {
+		if e := ([]map[string]interface{}{{"[applied]": false, "id": 1, "n": 4}}); !reflect.DeepEqual(a, e) {
+			t.Errorf("Actual tx %v, expected %v", a, e)
+		}
+	} else {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Scan("select n from gockle_test.test where id = 2", []interface{}{new(int)}); err != gocql.ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, gocql.ErrNotFound)
+	}
+
+	s.Close()
+
+	if _, err := b.ExecTx(); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestMemoryIteratorPaging(t *testing.T) {
+	var s = newMemorySession(t, rowInsert, rowInsert2)
+	var q = s.Query("select * from gockle_test.test").PageSize(1).WithContext(context.Background())
+	var i = q.Iter()
+	var id, n int
+
+	if !i.Scan(&id, &n) {
+		t.Fatal("Actual more false, expected true")
+	}
+
+	if id != 1 || n != 2 {
+		t.Errorf("Actual row %v %v, expected 1 2", id, n)
+	}
+
+	if !i.WillSwitchPage() {
+		t.Error("Actual WillSwitchPage false, expected true")
+	}
+
+	var state = i.PageState()
+
+	if state == nil {
+		t.Fatal("Actual PageState nil, expected not nil")
+	}
+
+	i = q.PageState(state).Iter()
+
+	if !i.Scan(&id, &n) {
+		t.Fatal("Actual more false, expected true")
+	}
+
+	if id != 3 || n != 4 {
+		t.Errorf("Actual row %v %v, expected 3 4", id, n)
+	}
+
+	if i.WillSwitchPage() {
+		t.Error("Actual WillSwitchPage true, expected false")
+	}
+
+	if state := i.PageState(); state != nil {
+		t.Errorf("Actual PageState %v, expected nil", state)
+	}
+
+	if i.Scan(&id, &n) {
+		t.Error("Actual more true, expected false")
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := s.Query("select * from gockle_test.test").WithContext(ctx).Exec(); err != context.Canceled {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestMemorySessionContext(t *testing.T) {
+	var s = newMemorySession(t, rowInsert)
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	if a, err := s.ScanMapSliceContext(ctx, "select * from gockle_test.test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := ([]map[string]interface{}{{"id": 1, "n": 2}}); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	cancel()
+
+	var m = map[string]interface{}{}
+	var b = s.Batch(BatchLogged)
+
+	b.Add(rowInsert2)
+
+	for _, err := range []error{
+		s.ExecContext(ctx, rowInsert2),
+		s.ScanContext(ctx, "select n from gockle_test.test", []interface{}{new(int)}),
+		s.ScanIteratorContext(ctx, "select * from gockle_test.test").Close(),
+		s.ScanMapContext(ctx, "select * from gockle_test.test", m),
+		errorOf(s.ScanMapTxContext(ctx, "update gockle_test.test set n = 3 where id = 1 if n = 2", m)),
+		errorOf(s.ColumnsContext(ctx, "gockle_test", "test")),
+		errorOf(s.TablesContext(ctx, "gockle_test")),
+		b.ExecContext(ctx),
+		errorOf(b.ExecTxContext(ctx)),
+	} {
+		if err != context.Canceled {
+			t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+		}
+	}
+}
@@ -0,0 +1,279 @@
+package gockle
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/gocql/gocql"
+)
+
+// ColumnKind is the kind of a column, named like system_schema.columns.
+type ColumnKind string
+
+// Column kinds.
+const (
+	ColumnPartitionKey ColumnKind = "partition_key"
+	ColumnClustering   ColumnKind = "clustering"
+	ColumnRegular      ColumnKind = "regular"
+	ColumnStatic       ColumnKind = "static"
+)
+
+// KeyspaceInfo is keyspace metadata. The slices are ordered by name.
+type KeyspaceInfo struct {
+	Name          string
+	DurableWrites bool
+
+	// Replication is the replication map, including class.
+	Replication map[string]string
+
+	Tables     []TableInfo
+	Views      []ViewInfo
+	Types      []UserTypeInfo
+	Functions  []FunctionInfo
+	Aggregates []AggregateInfo
+}
+
+// Table returns the TableInfo of the table named name, and whether there is
+// one.
+func (k KeyspaceInfo) Table(name string) (TableInfo, bool) {
+	var i = sort.Search(len(k.Tables), func(i int) bool {
+		return k.Tables[i].Name >= name
+	})
+
+	if i < len(k.Tables) && k.Tables[i].Name == name {
+		return k.Tables[i], true
+	}
+
+	return TableInfo{}, false
+}
+
+// TableInfo is table metadata.
+type TableInfo struct {
+	Keyspace string
+	Name     string
+
+	// Columns are ordered like SELECT *: partition key, clustering columns,
+	// then the others by name.
+	Columns []ColumnInfo
+
+	// PartitionKey and ClusteringColumns are the key column names in order.
+	PartitionKey      []string
+	ClusteringColumns []string
+
+	// Indexes are ordered by name. gocql knows the indexes only of Cassandra
+	// before 3.0.
+	Indexes []IndexInfo
+
+	// Options are the table options, like comment and compaction, of tables
+	// of the memory Session. gocql does not read them, so they are empty for
+	// the others.
+	Options map[string]interface{}
+}
+
+// Column returns the column name and whether there is one.
+func (t TableInfo) Column(name string) (ColumnInfo, bool) {
+	for _, c := range t.Columns {
+		if c.Name == name {
+			return c, true
+		}
+	}
+
+	return ColumnInfo{}, false
+}
+
+// ColumnInfo is column metadata.
+type ColumnInfo struct {
+	Name string
+	Type gocql.TypeInfo
+	Kind ColumnKind
+
+	// Position is the position of a key column within its key, or -1.
+	Position int
+
+	// Descending is whether a clustering column is in descending order.
+	Descending bool
+}
+
+// IndexInfo is secondary index metadata.
+type IndexInfo struct {
+	Name    string
+	Kind    string
+	Options map[string]string
+}
+
+// ViewInfo is materialized view metadata.
+type ViewInfo struct {
+	Name              string
+	BaseTable         string
+	IncludeAllColumns bool
+}
+
+// UserTypeInfo is user-defined type metadata.
+type UserTypeInfo struct {
+	Name       string
+	FieldNames []string
+	FieldTypes []gocql.TypeInfo
+}
+
+// FunctionInfo is user-defined function metadata.
+type FunctionInfo struct {
+	Name              string
+	ArgumentNames     []string
+	ArgumentTypes     []gocql.TypeInfo
+	ReturnType        gocql.TypeInfo
+	Language          string
+	Body              string
+	CalledOnNullInput bool
+}
+
+// AggregateInfo is user-defined aggregate metadata.
+type AggregateInfo struct {
+	Name          string
+	ArgumentTypes []gocql.TypeInfo
+	ReturnType    gocql.TypeInfo
+	StateType     gocql.TypeInfo
+	StateFunc     string
+	FinalFunc     string
+	InitCond      string
+}
+
+// keyspaceInfo returns the KeyspaceInfo for the gocql metadata m.
+func keyspaceInfo(m *gocql.KeyspaceMetadata) KeyspaceInfo {
+	var k = KeyspaceInfo{Name: m.Name, DurableWrites: m.DurableWrites, Replication: map[string]string{"class": m.StrategyClass}}
+
+	for n, v := range m.StrategyOptions {
+		k.Replication[n] = fmt.Sprint(v)
+	}
+
+	for _, t := range m.Tables {
+		k.Tables = append(k.Tables, tableInfo(t))
+	}
+
+	sort.Slice(k.Tables, func(i, j int) bool {
+		return k.Tables[i].Name < k.Tables[j].Name
+	})
+
+	for _, v := range m.MaterializedViews {
+		var i = ViewInfo{Name: v.Name, IncludeAllColumns: v.IncludeAllColumns}
+
+		if v.BaseTable != nil {
+			i.BaseTable = v.BaseTable.Name
+		}
+
+		k.Views = append(k.Views, i)
+	}
+
+	sort.Slice(k.Views, func(i, j int) bool {
+		return k.Views[i].Name < k.Views[j].Name
+	})
+
+	for _, t := range m.UserTypes {
+		k.Types = append(k.Types, UserTypeInfo{Name: t.Name, FieldNames: t.FieldNames, FieldTypes: t.FieldTypes})
+	}
+
+	sort.Slice(k.Types, func(i, j int) bool {
+		return k.Types[i].Name < k.Types[j].Name
+	})
+
+	for _, f := range m.Functions {
+		k.Functions = append(k.Functions, FunctionInfo{
+			Name:              f.Name,
+			ArgumentNames:     f.ArgumentNames,
+			ArgumentTypes:     f.ArgumentTypes,
+			ReturnType:        f.ReturnType,
+			Language:          f.Language,
+			Body:              f.Body,
+			CalledOnNullInput: f.CalledOnNullInput,
+		})
+	}
+
+	sort.Slice(k.Functions, func(i, j int) bool {
+		return k.Functions[i].Name < k.Functions[j].Name
+	})
+
+	for _, a := range m.Aggregates {
+		k.Aggregates = append(k.Aggregates, AggregateInfo{
+			Name:          a.Name,
+			ArgumentTypes: a.ArgumentTypes,
+			ReturnType:    a.ReturnType,
+			StateType:     a.StateType,
+			StateFunc:     a.StateFunc.Name,
+			FinalFunc:     a.FinalFunc.Name,
+			InitCond:      a.InitCond,
+		})
+	}
+
+	sort.Slice(k.Aggregates, func(i, j int) bool {
+		return k.Aggregates[i].Name < k.Aggregates[j].Name
+	})
+
+	return k
+}
+
+// tableInfo returns the TableInfo for the gocql metadata m.
+func tableInfo(m *gocql.TableMetadata) TableInfo {
+	var t = TableInfo{Keyspace: m.Keyspace, Name: m.Name, Options: map[string]interface{}{}}
+	var others []ColumnInfo
+
+	for _, c := range m.PartitionKey {
+		if c != nil {
+			t.PartitionKey = append(t.PartitionKey, c.Name)
+			t.Columns = append(t.Columns, columnInfo(c))
+		}
+	}
+
+	for _, c := range m.ClusteringColumns {
+		if c != nil {
+			t.ClusteringColumns = append(t.ClusteringColumns, c.Name)
+			t.Columns = append(t.Columns, columnInfo(c))
+		}
+	}
+
+	for _, c := range m.Columns {
+		var i = columnInfo(c)
+
+		if i.Kind != ColumnPartitionKey && i.Kind != ColumnClustering {
+			others = append(others, i)
+		}
+
+		if c.Index.Name != "" {
+			var options = map[string]string{}
+
+			for n, v := range c.Index.Options {
+				options[n] = fmt.Sprint(v)
+			}
+
+			t.Indexes = append(t.Indexes, IndexInfo{Name: c.Index.Name, Kind: c.Index.Type, Options: options})
+		}
+	}
+
+	sort.Slice(others, func(i, j int) bool {
+		return others[i].Name < others[j].Name
+	})
+
+	sort.Slice(t.Indexes, func(i, j int) bool {
+		return t.Indexes[i].Name < t.Indexes[j].Name
+	})
+
+	t.Columns = append(t.Columns, others...)
+
+	return t
+}
+
+// columnInfo returns the ColumnInfo for the gocql metadata m.
+func columnInfo(m *gocql.ColumnMetadata) ColumnInfo {
+	var c = ColumnInfo{Name: m.Name, Type: m.Type, Kind: ColumnRegular, Position: -1}
+
+	switch m.Kind {
+	case gocql.ColumnPartitionKey:
+		c.Kind, c.Position = ColumnPartitionKey, m.ComponentIndex
+
+	case gocql.ColumnClusteringKey:
+		c.Kind, c.Position, c.Descending = ColumnClustering, m.ComponentIndex, m.Order == gocql.DESC
+
+	case gocql.ColumnStatic:
+		c.Kind = ColumnStatic
+	}
+
+	return c
+}
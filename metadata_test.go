@@ -0,0 +1,127 @@
+package gockle
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestSessionKeyspace(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	var exec = func(q string) {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	exec(ksDropIf)
+	exec(ksCreate)
+
+	defer exec(ksDrop)
+
+	exec(tabCreate)
+	testKeyspace(t, s)
+}
+
+func TestMemorySessionKeyspace(t *testing.T) {
+	var s = newMemorySession(t)
+
+	testKeyspace(t, s)
+
+	if tab, err := s.Table("gockle_test", "events"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if a := tab.Options["comment"]; a != "log" {
+		t.Errorf("Actual comment %v, expected log", a)
+	}
+}
+
+func testKeyspace(t *testing.T, s Session) {
+	if err := s.Exec("create table gockle_test.events (a int, b text, c timestamp, d int, e text, s int static, primary key ((b, a), c, d)) with clustering order by (c desc, d asc) and comment = 'log'"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var k, err = s.Keyspace("gockle_test")
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if a, e := k.Replication, map[string]string{"class": "SimpleStrategy", "replication_factor": "1"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual replication %v, expected %v", a, e)
+	}
+
+	var names []string
+
+	for _, t := range k.Tables {
+		names = append(names, t.Name)
+	}
+
+	if e := []string{"events", "test"}; !reflect.DeepEqual(names, e) {
+		t.Errorf("Actual tables %v, expected %v", names, e)
+	}
+
+	var tab TableInfo
+
+	if tab, err = s.Table("gockle_test", "events"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if a, e := tab.PartitionKey, []string{"b", "a"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual partition key %v, expected %v", a, e)
+	}
+
+	if a, e := tab.ClusteringColumns, []string{"c", "d"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual clustering columns %v, expected %v", a, e)
+	}
+
+	var columns []string
+
+	for _, c := range tab.Columns {
+		columns = append(columns, c.Name)
+	}
+
+	if e := []string{"b", "a", "c", "d", "e", "s"}; !reflect.DeepEqual(columns, e) {
+		t.Errorf("Actual columns %v, expected %v", columns, e)
+	}
+
+	if c, ok := tab.Column("c"); !ok {
+		t.Error("Actual column c missing, expected present")
+	} else if c.Kind != ColumnClustering || c.Position != 0 || !c.Descending || c.Type.Type() != gocql.TypeTimestamp {
+		t.Errorf("Actual column %+v, expected descending clustering timestamp", c)
+	}
+
+	if c, _ := tab.Column("a"); c.Kind != ColumnPartitionKey || c.Position != 1 {
+		t.Errorf("Actual column %+v, expected partition key at 1", c)
+	}
+
+	if c, _ := tab.Column("s"); c.Kind != ColumnStatic || c.Position != -1 {
+		t.Errorf("Actual column %+v, expected static", c)
+	}
+
+	if _, err := s.Table("gockle_test", "missing"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if _, err := s.Keyspace("missing"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	cancel()
+
+	if _, err := s.TableContext(ctx, "gockle_test", "events"); err != context.Canceled {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+
+	if a, err := s.Tables("gockle_test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []string{"events", "test"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual tables %v, expected %v", a, e)
+	}
+}
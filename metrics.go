@@ -0,0 +1,426 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// Defaults of Metrics.
+var (
+	// DefaultLatencyBuckets are the default upper bounds of the latency
+	// histogram buckets, in seconds.
+	DefaultLatencyBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+	// DefaultBatchSizeBuckets are the default upper bounds of the batch size
+	// histogram buckets.
+	DefaultBatchSizeBuckets = []float64{1, 2, 5, 10, 25, 50, 100, 250}
+)
+
+// Names of the metrics of Metrics. All are labelled by the fingerprint of the
+// statement, or of the first statement of a batch, which is empty for
+// metadata.
+const (
+	// MetricDuration is a histogram of the durations of Operations in seconds,
+	// labelled by operation. That of OperationIter ends when the first page
+	// is read.
+	MetricDuration = "gockle_operation_duration_seconds"
+
+	// MetricErrors counts the failed Operations, labelled by operation and
+	// error_type, the kind of error like "timeout". See AttributeErrorType.
+	MetricErrors = "gockle_operation_errors_total"
+
+	// MetricRows counts the rows returned, labelled by operation.
+	MetricRows = "gockle_rows_total"
+
+	// MetricPages counts the pages read by Iterators.
+	MetricPages = "gockle_pages_total"
+
+	// MetricBatchSize is a histogram of the numbers of statements of batches.
+	MetricBatchSize = "gockle_batch_statements"
+
+	// MetricLWT counts the conditional statements and batches, labelled by
+	// applied, "true" or "false". Those run with Exec count only with a context
+	// from ReportNotApplied.
+	MetricLWT = "gockle_lwt_total"
+)
+
+// Metrics records metrics of the Operations of Sessions, to expose in the
+// Prometheus text format. Its methods are safe for concurrent use.
+type Metrics struct {
+	mu       sync.Mutex
+	families []*metricFamily
+}
+
+// NewMetrics returns new Metrics with the default buckets.
+func NewMetrics() *Metrics {
+	return NewMetricsBuckets(DefaultLatencyBuckets, DefaultBatchSizeBuckets)
+}
+
+// NewMetricsBuckets returns new Metrics with latency and batch size buckets,
+// which are sorted upper bounds.
+func NewMetricsBuckets(latency, batchSize []float64) *Metrics {
+	return &Metrics{families: []*metricFamily{
+		{name: MetricDuration, help: "Duration of Cassandra operations in seconds.", kind: "histogram", buckets: latency},
+		{name: MetricErrors, help: "Failed Cassandra operations.", kind: "counter"},
+		{name: MetricRows, help: "Rows returned by Cassandra operations.", kind: "counter"},
+		{name: MetricPages, help: "Pages read by Cassandra iterators.", kind: "counter"},
+		{name: MetricBatchSize, help: "Statements of Cassandra batches.", kind: "histogram", buckets: batchSize},
+		{name: MetricLWT, help: "Conditional Cassandra statements and batches by whether they were applied.", kind: "counter"},
+	}}
+}
+
+// Wrap returns s with the Middleware of m.
+func (m *Metrics) Wrap(s Session) Session {
+	return Chain(s, m.Middleware())
+}
+
+// Middleware returns Middleware that records the Operations in m.
+func (m *Metrics) Middleware() Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			var counted, _ = o.Iterator.(*countingIterator)
+			var start = time.Now()
+			var err = next(ctx, o)
+			var d = time.Since(start)
+
+			if o.Kind == OperationIter && err == nil && o.Iterator != nil {
+				o.Iterator = &countingIterator{Iterator: o.Iterator, ctx: ctx, pages: 1}
+			}
+
+			var statement = operationStatement(o)
+			var fingerprint = Fingerprint(statement)
+			var operation = o.Kind.String()
+
+			m.mu.Lock()
+			defer m.mu.Unlock()
+
+			m.family(MetricDuration).observe(d.Seconds(), "operation", operation, "fingerprint", fingerprint)
+
+			if err != nil {
+				m.family(MetricErrors).add(1, "operation", operation, "fingerprint", fingerprint, "error_type", errorType(err))
+			}
+
+			if rows, ok := operationRows(o, counted, err); ok {
+				m.family(MetricRows).add(float64(rows), "operation", operation, "fingerprint", fingerprint)
+			}
+
+			if o.Kind == OperationIterClose && counted != nil {
+				m.family(MetricPages).add(float64(counted.pages), "fingerprint", fingerprint)
+			}
+
+			if o.Kind == OperationBatchExec || o.Kind == OperationBatchExecTx {
+				m.family(MetricBatchSize).observe(float64(len(o.Batch)), "fingerprint", fingerprint)
+			}
+
+			if applied, ok := operationApplied(ctx, o, err); ok {
+				m.family(MetricLWT).add(1, "fingerprint", fingerprint, "applied", strconv.FormatBool(applied))
+			}
+
+			return err
+		}
+	}
+}
+
+// operationApplied returns whether o, a conditional statement or batch, was
+// applied, or false if o is not one, failed otherwise, or is run with Exec
+// without a context from ReportNotApplied.
+func operationApplied(ctx context.Context, o *Operation, err error) (applied, ok bool) {
+	switch o.Kind {
+	case OperationScanMapTx:
+		return o.Applied, err == nil
+
+	case OperationBatchExecTx:
+		if err != nil || len(o.Rows) == 0 {
+			return false, false
+		}
+
+		applied, ok = o.Rows[0][ColumnApplied].(bool)
+
+		return applied, ok
+
+	case OperationExec, OperationBatchExec:
+		switch {
+		case !reportNotApplied(ctx) || !operationConditional(o):
+			return false, false
+
+		case err == nil:
+			return true, true
+
+		case errors.Is(err, ErrNotApplied):
+			return false, true
+		}
+	}
+
+	return false, false
+}
+
+// MetricSample is a sample of the Prometheus text format, like a counter or a
+// bucket of a histogram.
+type MetricSample struct {
+	// Name is the name of the sample, like "gockle_rows_total" or
+	// "gockle_operation_duration_seconds_bucket".
+	Name string
+
+	Labels map[string]string
+	Value  float64
+}
+
+// Samples returns the samples of m in the order of the text format.
+func (m *Metrics) Samples() []MetricSample {
+	var ss []MetricSample
+
+	m.each(func(f *metricFamily, s []MetricSample) {
+		ss = append(ss, s...)
+	})
+
+	return ss
+}
+
+// Value returns the value of the sample named name with exactly labels, or 0 if
+// there is none.
+func (m *Metrics) Value(name string, labels map[string]string) float64 {
+	for _, s := range m.Samples() {
+		if s.Name != name || len(s.Labels) != len(labels) {
+			continue
+		}
+
+		var match = true
+
+		for k, v := range labels {
+			if l, ok := s.Labels[k]; !ok || l != v {
+				match = false
+			}
+		}
+
+		if match {
+			return s.Value
+		}
+	}
+
+	return 0
+}
+
+// WriteTo writes m to w in the Prometheus text format, version 0.0.4.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+
+	m.each(func(f *metricFamily, ss []MetricSample) {
+		fmt.Fprintf(&b, "# HELP %v %v\n# TYPE %v %v\n", f.name, f.help, f.name, f.kind)
+
+		for _, s := range ss {
+			fmt.Fprintf(&b, "%v%v %v\n", s.Name, formatLabels(s.Labels), formatFloat(s.Value))
+		}
+	})
+
+	var n, err = io.WriteString(w, b.String())
+
+	return int64(n), err
+}
+
+// ServeHTTP writes m in the Prometheus text format, so that m can serve the
+// /metrics endpoint.
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// Reset forgets the recorded values.
+func (m *Metrics) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, f := range m.families {
+		f.series = nil
+	}
+}
+
+// each calls f with each family of m that has series and its samples, in
+// order.
+func (m *Metrics) each(f func(f *metricFamily, ss []MetricSample)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, fam := range m.families {
+		if len(fam.series) > 0 {
+			f(fam, fam.samples())
+		}
+	}
+}
+
+func (m *Metrics) family(name string) *metricFamily {
+	for _, f := range m.families {
+		if f.name == name {
+			return f
+		}
+	}
+
+	panic("gockle: no metric " + name)
+}
+
+// metricFamily is a counter or histogram with series by label values.
+type metricFamily struct {
+	name    string
+	help    string
+	kind    string
+	buckets []float64
+	series  map[string]*metricSeries
+}
+
+// metricSeries is the value of a counter, or the bucket counts, sum in value,
+// and count of a histogram, for labels.
+type metricSeries struct {
+	labels map[string]string
+	value  float64
+	counts []uint64
+	count  uint64
+}
+
+// get returns the series for labels, pairs of names and values.
+func (f *metricFamily) get(labels []string) *metricSeries {
+	var key = strings.Join(labels, "\xff")
+
+	if s, ok := f.series[key]; ok {
+		return s
+	}
+
+	var s = &metricSeries{labels: map[string]string{}, counts: make([]uint64, len(f.buckets))}
+
+	for i := 0; i < len(labels); i += 2 {
+		s.labels[labels[i]] = labels[i+1]
+	}
+
+	if f.series == nil {
+		f.series = map[string]*metricSeries{}
+	}
+
+	f.series[key] = s
+
+	return s
+}
+
+func (f *metricFamily) add(v float64, labels ...string) {
+	f.get(labels).value += v
+}
+
+func (f *metricFamily) observe(v float64, labels ...string) {
+	var s = f.get(labels)
+
+	for i, b := range f.buckets {
+		if v <= b {
+			s.counts[i]++
+		}
+	}
+
+	s.value += v
+	s.count++
+}
+
+// samples returns the samples of f, with the series sorted by labels.
+func (f *metricFamily) samples() []MetricSample {
+	var series = make([]*metricSeries, 0, len(f.series))
+
+	for _, s := range f.series {
+		series = append(series, s)
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return formatLabels(series[i].labels) < formatLabels(series[j].labels)
+	})
+
+	var ss []MetricSample
+
+	for _, s := range series {
+		if f.kind == "counter" {
+			ss = append(ss, MetricSample{Name: f.name, Labels: withLabels(s.labels), Value: s.value})
+
+			continue
+		}
+
+		for i, b := range f.buckets {
+			ss = append(ss, MetricSample{Name: f.name + "_bucket", Labels: withLabels(s.labels, "le", formatFloat(b)), Value: float64(s.counts[i])})
+		}
+
+		ss = append(ss,
+			MetricSample{Name: f.name + "_bucket", Labels: withLabels(s.labels, "le", "+Inf"), Value: float64(s.count)},
+			MetricSample{Name: f.name + "_sum", Labels: withLabels(s.labels), Value: s.value},
+			MetricSample{Name: f.name + "_count", Labels: withLabels(s.labels), Value: float64(s.count)},
+		)
+	}
+
+	return ss
+}
+
+// withLabels returns a copy of labels with more, pairs of names and values.
+func withLabels(labels map[string]string, more ...string) map[string]string {
+	var m = map[string]string{}
+
+	for k, v := range labels {
+		m[k] = v
+	}
+
+	for i := 0; i < len(more); i += 2 {
+		m[more[i]] = more[i+1]
+	}
+
+	return m
+}
+
+// formatLabels formats labels sorted by name, with "le" last.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	var names = make([]string, 0, len(labels))
+
+	for n := range labels {
+		if n != "le" {
+			names = append(names, n)
+		}
+	}
+
+	sort.Strings(names)
+
+	if _, ok := labels["le"]; ok {
+		names = append(names, "le")
+	}
+
+	var r = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	var ps = make([]string, len(names))
+
+	for i, n := range names {
+		ps[i] = n + `="` + r.Replace(labels[n]) + `"`
+	}
+
+	return "{" + strings.Join(ps, ",") + "}"
+}
+
+func formatFloat(f float64) string {
+	if math.IsInf(f, 1) {
+		return "+Inf"
+	}
+
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+// Fingerprint returns statement normalized to group statements that differ in
+// values: literals and bind markers are ?, lists of them after IN are one ?,
+// unquoted identifiers and keywords are lowercase, and whitespace, comments,
+// and a final semicolon are dropped. It returns empty if statement does not
+// lex.
+func Fingerprint(statement string) string {
+	var f, _ = cql.Normalize(statement, false)
+
+	return f
+}
@@ -0,0 +1,158 @@
+package gockle
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestFingerprint(t *testing.T) {
+	for _, c := range []struct {
+		statement, expected string
+	}{
+		{"SELECT * FROM ks.t WHERE id = 5 AND name = 'x';", "select * from ks.t where id = ? and name = ?"},
+		{"select  a,b from t\n where id in (1, 2, 3) -- comment", "select a, b from t where id in (?)"},
+		{"select * from t where id in ?", "select * from t where id in ?"},
+		{`insert into "Tab" (id, n) values (:id, ?) using ttl 10`, `insert into "Tab" (id, n) values (?, ?) using ttl ?`},
+		{"update t set m['k'] = 0x01 where id = 5a5b1b1c-9b59-11e8-98d0-529269fb1459 if n = -1.5", "update t set m[?] = ? where id = ? if n = ?"},
+		{"select 'unterminated", ""},
+	} {
+		if a := Fingerprint(c.statement); a != c.expected {
+			t.Errorf("Actual fingerprint %q, expected %q", a, c.expected)
+		}
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	var metrics = NewMetricsBuckets([]float64{60}, []float64{1, 5})
+	var s = metrics.Wrap(newMemorySession(t, rowInsert))
+
+	defer s.Close()
+
+	var lwt = "update gockle_test.test set n = ? where id = ? if n = ?"
+
+	var report = ReportNotApplied(context.Background())
+
+	if err := s.ExecContext(report, "update gockle_test.test set n = 3 where id = 1 if n = 2"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Exec("update gockle_test.test set n = 4 where id = 1 if n = 2"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.ExecContext(report, "update gockle_test.test set n = 4 where id = 1 if n = 2"); err == nil {
+		t.Fatal("Actual no error, expected error")
+	}
+
+	var b = s.Batch(BatchUnlogged)
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 2, 2)
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 3, 3)
+
+	if err := b.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var i = s.Query("select * from gockle_test.test").PageSize(2).Iter()
+
+	for i.ScanMap(map[string]interface{}{}) {
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var all = "select * from gockle_test.test"
+	var insert = "insert into gockle_test.test (id, n) values (?, ?)"
+
+	for _, c := range []struct {
+		name     string
+		labels   map[string]string
+		expected float64
+	}{
+		{MetricDuration + "_count", map[string]string{"operation": "Exec", "fingerprint": lwt}, 3},
+		{MetricDuration + "_bucket", map[string]string{"operation": "Exec", "fingerprint": lwt, "le": "60"}, 3},
+		{MetricErrors, map[string]string{"operation": "Exec", "fingerprint": lwt, "error_type": "not_applied"}, 1},
+		{MetricLWT, map[string]string{"fingerprint": lwt, "applied": "true"}, 1},
+		{MetricLWT, map[string]string{"fingerprint": lwt, "applied": "false"}, 1},
+		{MetricBatchSize + "_bucket", map[string]string{"fingerprint": insert, "le": "1"}, 0},
+		{MetricBatchSize + "_bucket", map[string]string{"fingerprint": insert, "le": "5"}, 1},
+		{MetricBatchSize + "_sum", map[string]string{"fingerprint": insert}, 2},
+		{MetricRows, map[string]string{"operation": "IterClose", "fingerprint": all}, 3},
+		{MetricPages, map[string]string{"fingerprint": all}, 2},
+	} {
+		if a := metrics.Value(c.name, c.labels); a != c.expected {
+			t.Errorf("Actual %v %v %v, expected %v", c.name, c.labels, a, c.expected)
+		}
+	}
+
+	metrics.Reset()
+
+	if a := metrics.Samples(); len(a) != 0 {
+		t.Errorf("Actual samples %v, expected none", a)
+	}
+}
+
+func TestMetricsMock(t *testing.T) {
+	var metrics = NewMetricsBuckets([]float64{60}, nil)
+	var m = &SessionMock{}
+
+	m.On("ScanMapSlice", "select * from t where id = 'a\"b'", mock.Anything).Return([]map[string]interface{}{{"id": 1}, {"id": 2}}, nil)
+	m.On("ScanMap", "select * from t where id = 2", mock.Anything).Return(ErrNotFound)
+
+	var s = metrics.Wrap(m)
+
+	if _, err := s.ScanMapSlice("select * from t where id = 'a\"b'"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.ScanMap("select * from t where id = 2", map[string]interface{}{}); err != ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, ErrNotFound)
+	}
+
+	m.AssertExpectations(t)
+
+	var r = httptest.NewRecorder()
+
+	metrics.ServeHTTP(r, httptest.NewRequest("GET", "/metrics", nil))
+
+	var lines []string
+
+	for _, l := range strings.Split(r.Body.String(), "\n") {
+		// Durations vary.
+		if !strings.HasPrefix(l, MetricDuration+"_sum") {
+			lines = append(lines, l)
+		}
+	}
+
+	var e = []string{
+		"# HELP gockle_operation_duration_seconds Duration of Cassandra operations in seconds.",
+		"# TYPE gockle_operation_duration_seconds histogram",
+		`gockle_operation_duration_seconds_bucket{fingerprint="select * from t where id = ?",operation="ScanMap",le="60"} 1`,
+		`gockle_operation_duration_seconds_bucket{fingerprint="select * from t where id = ?",operation="ScanMap",le="+Inf"} 1`,
+		`gockle_operation_duration_seconds_count{fingerprint="select * from t where id = ?",operation="ScanMap"} 1`,
+		`gockle_operation_duration_seconds_bucket{fingerprint="select * from t where id = ?",operation="ScanMapSlice",le="60"} 1`,
+		`gockle_operation_duration_seconds_bucket{fingerprint="select * from t where id = ?",operation="ScanMapSlice",le="+Inf"} 1`,
+		`gockle_operation_duration_seconds_count{fingerprint="select * from t where id = ?",operation="ScanMapSlice"} 1`,
+		"# HELP gockle_operation_errors_total Failed Cassandra operations.",
+		"# TYPE gockle_operation_errors_total counter",
+		`gockle_operation_errors_total{error_type="not_found",fingerprint="select * from t where id = ?",operation="ScanMap"} 1`,
+		"# HELP gockle_rows_total Rows returned by Cassandra operations.",
+		"# TYPE gockle_rows_total counter",
+		`gockle_rows_total{fingerprint="select * from t where id = ?",operation="ScanMapSlice"} 2`,
+		"",
+	}
+
+	if !reflect.DeepEqual(lines, e) {
+		t.Errorf("Actual exposition\n%v\nexpected\n%v", strings.Join(lines, "\n"), strings.Join(e, "\n"))
+	}
+
+	if a, e := r.Header().Get("Content-Type"), "text/plain; version=0.0.4; charset=utf-8"; a != e {
+		t.Errorf("Actual content type %v, expected %v", a, e)
+	}
+}
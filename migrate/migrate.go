@@ -0,0 +1,351 @@
+// Package migrate versions the schema of a keyspace with CQL migration files.
+//
+// A Migrator applies the migrations that are not yet applied, in version
+// order, and records each in the bookkeeping table Table of the keyspace with
+// the checksum of its file. The keyspace must exist, and the statements of the
+// migrations should name it, because Session runs them without one.
+//
+// Only one Migrator applies migrations at a time. It takes a lock, a row of
+// LockTable inserted with a lightweight transaction, which expires after
+// LockTTL if the Migrator stops without releasing it. The Migrator renews the
+// lock before each migration, so a single migration must take less than
+// LockTTL. It waits for schema agreement after each statement.
+//
+// A failed migration is not recorded, and the statements before the failed one
+// stay applied. Write statements that can run again, like CREATE TABLE IF NOT
+// EXISTS, so that the fixed migration can be applied.
+package migrate
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"math"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/kerkerj/gockle"
+)
+
+const (
+	// Table is the name of the bookkeeping table.
+	Table = "schema_migrations"
+
+	// LockTable is the name of the table for the lock.
+	LockTable = "schema_migrations_lock"
+
+	// DefaultLockTTL is the default for Migrator.LockTTL.
+	DefaultLockTTL = 10 * time.Minute
+
+	// unlockTimeout is how long releasing the lock may take, even after the
+	// context of UpTo is done.
+	unlockTimeout = 10 * time.Second
+)
+
+// ErrLocked is returned when another Migrator holds the lock.
+var ErrLocked = errors.New("migrate: locked by another migrator")
+
+// DriftError is returned when an applied migration does not match its file,
+// or has none.
+type DriftError struct {
+	Version int64
+	Name    string
+
+	// Applied and File are the checksums. File is empty if there is no file.
+	Applied string
+	File    string
+}
+
+func (e *DriftError) Error() string {
+	if e.File == "" {
+		return fmt.Sprintf("migrate: applied migration %v has no file", e.Name)
+	}
+
+	return fmt.Sprintf("migrate: migration %v has checksum %v but was applied with %v", e.Name, e.File, e.Applied)
+}
+
+// Status is the state of a migration.
+type Status struct {
+	Migration
+
+	Applied   bool
+	AppliedAt time.Time
+
+	// AppliedChecksum is the checksum the migration was applied with.
+	AppliedChecksum string
+
+	// Drift is whether the migration was applied with another checksum.
+	Drift bool
+
+	// Missing is whether the migration was applied but has no file. Only
+	// Version, Name, and Checksum are set, from the bookkeeping table.
+	Missing bool
+}
+
+// Migrator applies migrations to a keyspace.
+type Migrator struct {
+	// Owner identifies the Migrator in the lock. New sets it to a random
+	// string.
+	Owner string
+
+	// LockTTL is how long the lock lasts if the Migrator does not release or
+	// renew it. It is at least a second.
+	LockTTL time.Duration
+
+	s          gockle.Session
+	keyspace   string
+	migrations []Migration
+}
+
+// New returns a new Migrator for the migrations in the root of fsys, as read
+// by Read, and keyspace through s.
+func New(s gockle.Session, keyspace string, fsys fs.FS) (*Migrator, error) {
+	var ms, err = Read(fsys)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var b = make([]byte, 8)
+
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+
+	var owner = hex.EncodeToString(b)
+
+	if h, err := os.Hostname(); err == nil {
+		owner = h + "-" + owner
+	}
+
+	return &Migrator{Owner: owner, LockTTL: DefaultLockTTL, s: s, keyspace: keyspace, migrations: ms}, nil
+}
+
+// NewDir is like New for the directory dir.
+func NewDir(s gockle.Session, keyspace, dir string) (*Migrator, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return nil, err
+	}
+
+	return New(s, keyspace, os.DirFS(dir))
+}
+
+// Migrations returns the migrations.
+func (m *Migrator) Migrations() []Migration {
+	return append([]Migration(nil), m.migrations...)
+}
+
+// Status returns the status of each migration, followed by the applied
+// migrations without files. It creates the bookkeeping tables if they do not
+// exist.
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	return m.status(ctx)
+}
+
+// Up applies the pending migrations. It returns the applied migrations.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.UpTo(ctx, math.MaxInt64)
+}
+
+// UpTo applies the pending migrations up to and including version. It returns
+// the applied migrations. It returns a *DriftError without applying any if an
+// applied migration drifted, and ErrLocked if another Migrator holds the lock.
+func (m *Migrator) UpTo(ctx context.Context, version int64) (applied []Migration, err error) {
+	if m.LockTTL < time.Second {
+		return nil, fmt.Errorf("migrate: lock TTL %v is less than a second", m.LockTTL)
+	}
+
+	if err := m.init(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := m.lock(ctx); err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		var ctx, cancel = context.WithTimeout(context.WithoutCancel(ctx), unlockTimeout)
+
+		defer cancel()
+
+		if uerr := m.unlock(ctx); err == nil {
+			err = uerr
+		}
+	}()
+
+	var ss []Status
+
+	if ss, err = m.status(ctx); err != nil {
+		return nil, err
+	}
+
+	for _, s := range ss {
+		if s.Drift || s.Missing {
+			var e = &DriftError{Version: s.Version, Name: s.Name, Applied: s.AppliedChecksum}
+
+			if s.Drift {
+				e.File = s.Checksum
+			}
+
+			return nil, e
+		}
+	}
+
+	for _, s := range ss {
+		if s.Applied || s.Version > version {
+			continue
+		}
+
+		if err := m.renew(ctx); err != nil {
+			return applied, err
+		}
+
+		if err := m.apply(ctx, s.Migration); err != nil {
+			return applied, err
+		}
+
+		applied = append(applied, s.Migration)
+	}
+
+	return applied, nil
+}
+
+func (m *Migrator) table(name string) string {
+	return m.keyspace + "." + name
+}
+
+// init creates the bookkeeping tables.
+func (m *Migrator) init(ctx context.Context) error {
+	for _, s := range []string{
+		"create table if not exists " + m.table(Table) + " (version bigint primary key, name text, checksum text, applied_at timestamp)",
+		"create table if not exists " + m.table(LockTable) + " (name text primary key, owner text, locked_at timestamp)",
+	} {
+		if err := m.s.ExecContext(ctx, s); err != nil {
+			return err
+		}
+	}
+
+	return m.s.AwaitSchemaAgreement(ctx)
+}
+
+// ttl returns LockTTL in seconds.
+func (m *Migrator) ttl() int64 {
+	return int64(m.LockTTL / time.Second)
+}
+
+func (m *Migrator) lock(ctx context.Context) error {
+	var current = map[string]interface{}{}
+	var statement = fmt.Sprintf("insert into %v (name, owner, locked_at) values (?, ?, ?) if not exists using ttl %d", m.table(LockTable), m.ttl())
+	var ok, err = m.s.ScanMapTxContext(ctx, statement, current, "migrate", m.Owner, time.Now())
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("%w %v", ErrLocked, current["owner"])
+	}
+
+	return nil
+}
+
+// renew makes the lock last LockTTL from now.
+func (m *Migrator) renew(ctx context.Context) error {
+	var statement = fmt.Sprintf("update %v using ttl %d set owner = ?, locked_at = ? where name = ? if owner = ?", m.table(LockTable), m.ttl())
+	var ok, err = m.s.ScanMapTxContext(ctx, statement, map[string]interface{}{}, m.Owner, time.Now(), "migrate", m.Owner)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("migrate: lock of %v expired", m.Owner)
+	}
+
+	return nil
+}
+
+func (m *Migrator) unlock(ctx context.Context) error {
+	var ok, err = m.s.ScanMapTxContext(ctx, "delete from "+m.table(LockTable)+" where name = ? if owner = ?", map[string]interface{}{}, "migrate", m.Owner)
+
+	if err != nil {
+		return err
+	}
+
+	if !ok {
+		return fmt.Errorf("migrate: lock of %v expired", m.Owner)
+	}
+
+	return nil
+}
+
+// record is a row of the bookkeeping table.
+type record struct {
+	Version   int64
+	Name      string
+	Checksum  string
+	AppliedAt time.Time
+}
+
+func (m *Migrator) status(ctx context.Context) ([]Status, error) {
+	var rs []record
+
+	if err := m.s.ScanStructSliceContext(ctx, "select version, name, checksum, applied_at from "+m.table(Table), &rs); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(rs, func(i, j int) bool {
+		return rs[i].Version < rs[j].Version
+	})
+
+	var applied = map[int64]record{}
+
+	for _, r := range rs {
+		applied[r.Version] = r
+	}
+
+	var ss []Status
+
+	for _, mi := range m.migrations {
+		var s = Status{Migration: mi}
+
+		if r, ok := applied[mi.Version]; ok {
+			s.Applied, s.AppliedAt, s.AppliedChecksum = true, r.AppliedAt, r.Checksum
+			s.Drift = r.Checksum != mi.Checksum
+
+			delete(applied, mi.Version)
+		}
+
+		ss = append(ss, s)
+	}
+
+	for _, r := range rs {
+		if _, ok := applied[r.Version]; ok {
+			ss = append(ss, Status{Migration: Migration{Version: r.Version, Name: r.Name, Checksum: r.Checksum}, Applied: true, AppliedAt: r.AppliedAt, AppliedChecksum: r.Checksum, Missing: true})
+		}
+	}
+
+	return ss, nil
+}
+
+func (m *Migrator) apply(ctx context.Context, mi Migration) error {
+	for i, s := range mi.Statements {
+		if err := m.s.ExecContext(ctx, s); err != nil {
+			return fmt.Errorf("migrate: migration %v statement %v: %w", mi.Name, i+1, err)
+		}
+
+		if err := m.s.AwaitSchemaAgreement(ctx); err != nil {
+			return err
+		}
+	}
+
+	return m.s.ExecContext(ctx, "insert into "+m.table(Table)+" (version, name, checksum, applied_at) values (?, ?, ?, ?)", mi.Version, mi.Name, mi.Checksum, time.Now())
+}
@@ -0,0 +1,223 @@
+package migrate
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/kerkerj/gockle"
+)
+
+func newSession(t *testing.T) gockle.Session {
+	var s = gockle.NewMemorySession()
+
+	if err := s.Exec("create keyspace ks with replication = {'class': 'SimpleStrategy', 'replication_factor': 1}"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	return s
+}
+
+var files = fstest.MapFS{
+	"1_users.cql":  {Data: []byte("create table ks.users (id int primary key, name text);")},
+	"2_seed.cql":   {Data: []byte("insert into ks.users (id, name) values (1, 'a');")},
+	"3_orders.cql": {Data: []byte("create table ks.orders (id int primary key);")},
+}
+
+func TestMigrator(t *testing.T) {
+	var s = newSession(t)
+	var ctx = context.Background()
+
+	defer s.Close()
+
+	var m, err = New(s, "ks", files)
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if applied, err := m.UpTo(ctx, 2); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(applied) != 2 {
+		t.Errorf("Actual applied %v, expected 2", len(applied))
+	}
+
+	if ss, err := m.Status(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(ss) != 3 || !ss[0].Applied || !ss[1].Applied || ss[2].Applied || ss[0].AppliedAt.IsZero() {
+		t.Errorf("Actual status %+v, expected 2 of 3 applied", ss)
+	}
+
+	if applied, err := m.Up(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(applied) != 1 || applied[0].Version != 3 {
+		t.Errorf("Actual applied %v, expected version 3", applied)
+	}
+
+	if applied, err := m.Up(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(applied) != 0 {
+		t.Errorf("Actual applied %v, expected none", applied)
+	}
+
+	if n, err := s.ScanMapSlice("select * from ks.users"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(n) != 1 {
+		t.Errorf("Actual rows %v, expected 1", len(n))
+	}
+}
+
+func TestMigratorDrift(t *testing.T) {
+	var s = newSession(t)
+	var ctx = context.Background()
+
+	defer s.Close()
+
+	var m, _ = New(s, "ks", files)
+
+	if _, err := m.Up(ctx); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var changed = fstest.MapFS{
+		"1_users.cql": {Data: []byte("create table ks.users (id int primary key, name text, age int);")},
+		"2_seed.cql":  files["2_seed.cql"],
+	}
+
+	m, _ = New(s, "ks", changed)
+
+	var d *DriftError
+
+	if _, err := m.Up(ctx); !errors.As(err, &d) {
+		t.Errorf("Actual error %v, expected drift", err)
+	} else if d.Version != 1 || d.File == "" || d.File == d.Applied {
+		t.Errorf("Actual drift %+v, expected version 1 with checksums", d)
+	}
+
+	if ss, err := m.Status(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(ss) != 3 || !ss[0].Drift || ss[1].Drift || !ss[2].Missing || ss[2].Version != 3 {
+		t.Errorf("Actual status %+v, expected drift, applied, missing", ss)
+	}
+
+	m, _ = New(s, "ks", fstest.MapFS{"1_users.cql": files["1_users.cql"], "2_seed.cql": files["2_seed.cql"]})
+
+	if _, err := m.Up(ctx); !errors.As(err, &d) {
+		t.Errorf("Actual error %v, expected drift", err)
+	} else if d.Version != 3 || d.File != "" {
+		t.Errorf("Actual drift %+v, expected missing version 3", d)
+	}
+}
+
+func TestMigratorLock(t *testing.T) {
+	var s = newSession(t)
+	var ctx = context.Background()
+
+	defer s.Close()
+
+	var a, _ = New(s, "ks", files)
+	var b, _ = New(s, "ks", files)
+
+	if err := a.init(ctx); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if err := a.lock(ctx); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if _, err := b.Up(ctx); !errors.Is(err, ErrLocked) {
+		t.Errorf("Actual error %v, expected %v", err, ErrLocked)
+	}
+
+	if err := b.renew(ctx); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if err := b.unlock(ctx); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if err := a.renew(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := a.unlock(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if _, err := b.Up(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+}
+
+func TestMigratorUnlock(t *testing.T) {
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	defer cancel()
+
+	// The seed migration cancels ctx, and the Operations fail with ctx.
+	var s = gockle.Chain(newSession(t), func(next gockle.Handler) gockle.Handler {
+		return func(ctx context.Context, o *gockle.Operation) error {
+			if strings.HasPrefix(o.Statement, "insert into ks.users") {
+				cancel()
+			}
+
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			return next(ctx, o)
+		}
+	})
+
+	defer s.Close()
+
+	var m, _ = New(s, "ks", files)
+
+	if _, err := m.Up(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+
+	if _, err := m.Up(context.Background()); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	m.LockTTL = time.Millisecond
+
+	if _, err := m.Up(context.Background()); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestMigratorFailure(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	var m, _ = New(s, "ks", fstest.MapFS{
+		"1_a.cql": {Data: []byte("create table ks.a (id int primary key);")},
+		"2_b.cql": {Data: []byte("insert into ks.missing (id) values (1);")},
+	})
+
+	if applied, err := m.Up(context.Background()); err == nil {
+		t.Error("Actual no error, expected error")
+	} else if len(applied) != 1 {
+		t.Errorf("Actual applied %v, expected 1", applied)
+	}
+
+	if ss, _ := m.Status(context.Background()); len(ss) != 2 || !ss[0].Applied || ss[1].Applied {
+		t.Errorf("Actual status %+v, expected only the first applied", ss)
+	}
+
+	if _, err := NewDir(s, "ks", t.TempDir()); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if _, err := NewDir(s, "ks", "missing"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
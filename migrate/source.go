@@ -0,0 +1,149 @@
+package migrate
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Migration is a migration file. Its name is its version, an underscore, and
+// a description, with the extension .cql, like 001_create_users.cql.
+type Migration struct {
+	Version    int64
+	Name       string
+	Statements []string
+
+	// Checksum is the hex SHA-256 of the file.
+	Checksum string
+}
+
+// Read returns the migrations in the .cql files of the root of fsys, ordered by
+// version. Versions must be unique.
+func Read(fsys fs.FS) ([]Migration, error) {
+	var names, err = fs.Glob(fsys, "*.cql")
+
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []Migration
+
+	for _, n := range names {
+		var m, err = readMigration(fsys, n)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, m)
+	}
+
+	sort.Slice(ms, func(i, j int) bool {
+		return ms[i].Version < ms[j].Version
+	})
+
+	for i := 1; i < len(ms); i++ {
+		if ms[i].Version == ms[i-1].Version {
+			return nil, fmt.Errorf("migrate: migrations %v and %v have the same version", ms[i-1].Name, ms[i].Name)
+		}
+	}
+
+	return ms, nil
+}
+
+func readMigration(fsys fs.FS, name string) (Migration, error) {
+	var prefix, _, _ = strings.Cut(strings.TrimSuffix(path.Base(name), ".cql"), "_")
+	var v, err = strconv.ParseInt(prefix, 10, 64)
+
+	if err != nil || v < 0 {
+		return Migration{}, fmt.Errorf("migrate: migration %v has no version", name)
+	}
+
+	var b []byte
+
+	if b, err = fs.ReadFile(fsys, name); err != nil {
+		return Migration{}, err
+	}
+
+	var sum = sha256.Sum256(b)
+	var m = Migration{Version: v, Name: name, Checksum: hex.EncodeToString(sum[:])}
+
+	if m.Statements, err = split(string(b)); err != nil {
+		return Migration{}, fmt.Errorf("migrate: migration %v: %v", name, err)
+	}
+
+	return m, nil
+}
+
+// split returns the statements of the CQL script s without comments and
+// trailing semicolons.
+func split(s string) ([]string, error) {
+	var statements []string
+	var b strings.Builder
+
+	var flush = func() {
+		if st := strings.TrimSpace(b.String()); st != "" {
+			statements = append(statements, st)
+		}
+
+		b.Reset()
+	}
+
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == ';':
+			flush()
+
+		case strings.HasPrefix(s[i:], "--") || strings.HasPrefix(s[i:], "//"):
+			var end = strings.IndexByte(s[i:], '\n')
+
+			if end < 0 {
+				i = len(s)
+			} else {
+				i += end
+				b.WriteByte('\n')
+			}
+
+		case strings.HasPrefix(s[i:], "/*"):
+			var end = strings.Index(s[i+2:], "*/")
+
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated comment")
+			}
+
+			i += end + 3
+			b.WriteByte(' ')
+
+		case c == '\'' || c == '"' || strings.HasPrefix(s[i:], "$$"):
+			var quote = s[i : i+1]
+
+			if c == '$' {
+				quote = "$$"
+			}
+
+			var end = strings.Index(s[i+len(quote):], quote)
+
+			// Quotes are escaped by doubling, which reads as two quoted strings.
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quote %v", quote)
+			}
+
+			var next = i + len(quote) + end + len(quote)
+
+			b.WriteString(s[i:next])
+			i = next - 1
+
+		default:
+			b.WriteByte(c)
+		}
+	}
+
+	flush()
+
+	return statements, nil
+}
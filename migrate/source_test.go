@@ -0,0 +1,68 @@
+package migrate
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestRead(t *testing.T) {
+	var ms, err = Read(fstest.MapFS{
+		"2_b.cql":    {Data: []byte("create table ks.b (id int primary key);")},
+		"10_c.cql":   {Data: []byte("-- c\ncreate table ks.c (id int primary key)")},
+		"001_a.cql":  {Data: []byte("create table ks.a (id int primary key);\ninsert into ks.a (id) values (1);")},
+		"readme.txt": {Data: []byte("not a migration")},
+	})
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var versions []int64
+
+	for _, m := range ms {
+		versions = append(versions, m.Version)
+	}
+
+	if e := []int64{1, 2, 10}; !reflect.DeepEqual(versions, e) {
+		t.Errorf("Actual versions %v, expected %v", versions, e)
+	}
+
+	if a, e := ms[0].Statements, []string{"create table ks.a (id int primary key)", "insert into ks.a (id) values (1)"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual statements %v, expected %v", a, e)
+	}
+
+	if a := ms[2].Checksum; len(a) != 64 {
+		t.Errorf("Actual checksum %v, expected 64 hex digits", a)
+	}
+
+	if _, err := Read(fstest.MapFS{"1_a.cql": {}, "01_b.cql": {}}); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if _, err := Read(fstest.MapFS{"a.cql": {}}); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestSplit(t *testing.T) {
+	for s, e := range map[string][]string{
+		"":                            nil,
+		"a; b;\n;":                    {"a", "b"},
+		"a -- x; y\n; b // z;":        {"a", "b"},
+		"a /* ; */ b":                 {"a   b"},
+		"a 'x;''y'; b \"c;\"; $$d;$$": {"a 'x;''y'", "b \"c;\"", "$$d;$$"},
+	} {
+		if a, err := split(s); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		} else if !reflect.DeepEqual(a, e) {
+			t.Errorf("Actual statements %q, expected %q", a, e)
+		}
+	}
+
+	for _, s := range []string{"a /* b", "a 'b", "$$a"} {
+		if _, err := split(s); err == nil {
+			t.Errorf("Actual no error for %q, expected error", s)
+		}
+	}
+}
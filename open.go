@@ -0,0 +1,175 @@
+package gockle
+
+import (
+	"crypto/tls"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Option configures the Session made by Open.
+type Option func(o *options)
+
+// options are the settings of Open.
+type options struct {
+	cluster            *gocql.ClusterConfig
+	asyncLimit         int
+	schemaPollInterval time.Duration
+}
+
+// newOptions returns the settings for hosts and opts.
+func newOptions(hosts []string, opts []Option) *options {
+	var o = &options{cluster: gocql.NewCluster(hosts...), asyncLimit: DefaultAsyncLimit, schemaPollInterval: schemaPollInterval}
+
+	// Negotiate the protocol version unless it is set.
+	o.cluster.ProtoVersion = 0
+
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	return o
+}
+
+// Open returns a new Session for hosts configured by opts. Without options it
+// uses the gocql defaults and negotiates the native protocol version with the
+// first host.
+func Open(hosts []string, opts ...Option) (Session, error) {
+	var o = newOptions(hosts, opts)
+	var s, err = o.cluster.CreateSession()
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session{s: s, schema: newSchema(s, o.schemaPollInterval), limit: newLimiter(o.asyncLimit)}, nil
+}
+
+// WithKeyspace sets the keyspace of unqualified tables.
+func WithKeyspace(keyspace string) Option {
+	return func(o *options) {
+		o.cluster.Keyspace = keyspace
+	}
+}
+
+// WithConsistency sets the default consistency level of queries and batches.
+func WithConsistency(c gocql.Consistency) Option {
+	return func(o *options) {
+		o.cluster.Consistency = c
+	}
+}
+
+// WithTimeout sets how long to wait for a response to a request.
+func WithTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.cluster.Timeout = d
+	}
+}
+
+// WithConnectTimeout sets how long to wait to connect to a host.
+func WithConnectTimeout(d time.Duration) Option {
+	return func(o *options) {
+		o.cluster.ConnectTimeout = d
+	}
+}
+
+// WithProtoVersion sets the native protocol version. 0 negotiates it.
+func WithProtoVersion(version int) Option {
+	return func(o *options) {
+		o.cluster.ProtoVersion = version
+	}
+}
+
+// WithAuth authenticates with username and password.
+func WithAuth(username, password string) Option {
+	return func(o *options) {
+		o.cluster.Authenticator = gocql.PasswordAuthenticator{Username: username, Password: password}
+	}
+}
+
+// TLS is the TLS configuration of WithTLS.
+type TLS struct {
+	// CAFile is the PEM file of the certificate authorities that verify the
+	// hosts. If empty, those of the system do.
+	CAFile string
+
+	// CertFile and KeyFile are the PEM files of the client certificate and its
+	// key. Both are empty for none.
+	CertFile string
+	KeyFile  string
+
+	// ServerName is the name verified in the certificates of the hosts. If
+	// empty, it is the host name used to connect.
+	ServerName string
+
+	// InsecureSkipVerify disables the verification of the host certificates.
+	InsecureSkipVerify bool
+}
+
+// WithTLS connects with TLS configured by t.
+func WithTLS(t TLS) Option {
+	return func(o *options) {
+		o.cluster.SslOpts = &gocql.SslOptions{
+			Config:                 &tls.Config{ServerName: t.ServerName, InsecureSkipVerify: t.InsecureSkipVerify},
+			CaPath:                 t.CAFile,
+			CertPath:               t.CertFile,
+			KeyPath:                t.KeyFile,
+			EnableHostVerification: !t.InsecureSkipVerify,
+		}
+	}
+}
+
+// WithLocalDC sends requests to the replicas in data center dc, then to the
+// other hosts in dc, and only to other data centers if none is up.
+func WithLocalDC(dc string) Option {
+	return func(o *options) {
+		o.cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(dc))
+	}
+}
+
+// WithCompression compresses frames with Snappy.
+func WithCompression() Option {
+	return func(o *options) {
+		o.cluster.Compressor = gocql.SnappyCompressor{}
+	}
+}
+
+// WithPageSize sets the default page size of queries.
+func WithPageSize(n int) Option {
+	return func(o *options) {
+		o.cluster.PageSize = n
+	}
+}
+
+// WithNumConns sets the number of connections to each host.
+func WithNumConns(n int) Option {
+	return func(o *options) {
+		o.cluster.NumConns = n
+	}
+}
+
+// WithAsyncLimit sets the most asynchronous calls that run at once instead of
+// DefaultAsyncLimit. Less than 1 is 1.
+func WithAsyncLimit(n int) Option {
+	return func(o *options) {
+		o.asyncLimit = max(n, 1)
+	}
+}
+
+// WithSchemaPollInterval sets how often SchemaChanges looks for changes
+// instead of every second. gocql takes a second to notice a change anyway.
+func WithSchemaPollInterval(d time.Duration) Option {
+	return func(o *options) {
+		if d > 0 {
+			o.schemaPollInterval = d
+		}
+	}
+}
+
+// WithCluster calls f with the gocql configuration for the settings that have
+// no Option.
+func WithCluster(f func(c *gocql.ClusterConfig)) Option {
+	return func(o *options) {
+		f(o.cluster)
+	}
+}
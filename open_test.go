@@ -0,0 +1,97 @@
+package gockle
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+func TestOpen(t *testing.T) {
+	for _, opts := range [][]Option{
+		{WithProtoVersion(version), WithTimeout(5 * time.Second)},
+		{WithTimeout(5 * time.Second), WithConsistency(gocql.One), WithPageSize(10), WithNumConns(1), WithAsyncLimit(2)},
+	} {
+		var s, err = Open([]string{server.Addr}, opts...)
+
+		if err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		if err := s.Exec("select * from system.local"); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		}
+
+		s.Close()
+	}
+
+	if _, err := Open([]string{server.Addr}, WithKeyspace("invalid"), WithTimeout(5*time.Second)); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestOptions(t *testing.T) {
+	var o = newOptions([]string{"a", "b"}, []Option{
+		WithKeyspace("k"),
+		WithConsistency(gocql.LocalQuorum),
+		WithTimeout(time.Second),
+		WithConnectTimeout(2 * time.Second),
+		WithProtoVersion(3),
+		WithAuth("u", "p"),
+		WithTLS(TLS{CAFile: "ca.pem", CertFile: "cert.pem", KeyFile: "key.pem", ServerName: "cassandra"}),
+		WithLocalDC("dc1"),
+		WithCompression(),
+		WithPageSize(100),
+		WithNumConns(3),
+		WithAsyncLimit(0),
+		WithSchemaPollInterval(time.Minute),
+		WithCluster(func(c *gocql.ClusterConfig) {
+			c.MaxPreparedStmts = 10
+		}),
+	})
+
+	var c = o.cluster
+
+	if a, e := c.Hosts, []string{"a", "b"}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual hosts %v, expected %v", a, e)
+	}
+
+	if c.Keyspace != "k" || c.Consistency != gocql.LocalQuorum || c.Timeout != time.Second || c.ConnectTimeout != 2*time.Second || c.ProtoVersion != 3 {
+		t.Errorf("Actual keyspace %v, consistency %v, timeouts %v %v, and version %v, expected k, LOCAL_QUORUM, 1s 2s, and 3", c.Keyspace, c.Consistency, c.Timeout, c.ConnectTimeout, c.ProtoVersion)
+	}
+
+	if a, e := c.Authenticator, (gocql.PasswordAuthenticator{Username: "u", Password: "p"}); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual authenticator %v, expected %v", a, e)
+	}
+
+	if s := c.SslOpts; s == nil {
+		t.Error("Actual TLS nil, expected not nil")
+	} else if s.CaPath != "ca.pem" || s.CertPath != "cert.pem" || s.KeyPath != "key.pem" || s.ServerName != "cassandra" || !s.EnableHostVerification {
+		t.Errorf("Actual TLS %+v, expected the files, server name, and host verification", s)
+	}
+
+	if c.PoolConfig.HostSelectionPolicy == nil {
+		t.Error("Actual host selection policy nil, expected not nil")
+	}
+
+	if a, e := c.Compressor, (gocql.SnappyCompressor{}); a != e {
+		t.Errorf("Actual compressor %v, expected %v", a, e)
+	}
+
+	if c.PageSize != 100 || c.NumConns != 3 || c.MaxPreparedStmts != 10 {
+		t.Errorf("Actual page size %v, connections %v, and prepared statements %v, expected 100, 3, and 10", c.PageSize, c.NumConns, c.MaxPreparedStmts)
+	}
+
+	if a, e := o.asyncLimit, 1; a != e {
+		t.Errorf("Actual async limit %v, expected %v", a, e)
+	}
+
+	if a, e := o.schemaPollInterval, time.Minute; a != e {
+		t.Errorf("Actual schema poll interval %v, expected %v", a, e)
+	}
+
+	if a, e := newOptions(nil, nil).cluster.ProtoVersion, 0; a != e {
+		t.Errorf("Actual version %v, expected %v", a, e)
+	}
+}
@@ -0,0 +1,155 @@
+package gockle
+
+import (
+	"fmt"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+	"github.com/kerkerj/gockle/internal/memdb"
+	"github.com/kerkerj/gockle/internal/partitioner"
+)
+
+// writeTarget returns the keyspace and table of an INSERT, UPDATE, or DELETE
+// statement, and the terms that set columns to one value: the values of an
+// INSERT, or the equality relations of a WHERE clause.
+func writeTarget(statement string) (keyspace, table string, terms map[string]cql.Term, ok bool) {
+	var s, err = cql.Parse(statement)
+
+	if err != nil {
+		return "", "", nil, false
+	}
+
+	switch s := s.(type) {
+	case *cql.Insert:
+		terms = map[string]cql.Term{}
+
+		for i, c := range s.Columns {
+			terms[c] = s.Values[i]
+		}
+
+		return s.Keyspace, s.Table, terms, true
+
+	case *cql.Update:
+		return s.Keyspace, s.Table, equalities(s.Where), true
+
+	case *cql.Delete:
+		return s.Keyspace, s.Table, equalities(s.Where), true
+	}
+
+	return "", "", nil, false
+}
+
+// equalities returns the terms of the relations of rs that set a column to one
+// value.
+func equalities(rs []cql.Relation) map[string]cql.Term {
+	var m = map[string]cql.Term{}
+
+	for _, r := range rs {
+		if !r.Token && len(r.Columns) == 1 && r.Op == "=" {
+			m[r.Column()] = r.Value
+		}
+	}
+
+	return m
+}
+
+// partitionValues returns the values of the partition key columns of t from
+// terms and the arguments of their bind markers, and whether all have one.
+func partitionValues(t TableInfo, terms map[string]cql.Term, arguments []interface{}) ([]interface{}, bool) {
+	var vs = make([]interface{}, len(t.PartitionKey))
+
+	for i, n := range t.PartitionKey {
+		var c, _ = t.Column(n)
+
+		switch term := terms[n].(type) {
+		case cql.Marker:
+			if term.Index >= len(arguments) {
+				return nil, false
+			}
+
+			vs[i] = arguments[term.Index]
+
+		case cql.Literal:
+			var v, err = memdb.Literal(c.Type, term)
+
+			if err != nil {
+				return nil, false
+			}
+
+			vs[i] = v
+
+		default:
+			return nil, false
+		}
+	}
+
+	return vs, true
+}
+
+// routingKey serializes values, of the partition key columns of t, like
+// Cassandra does to compute the token.
+func routingKey(t TableInfo, values []interface{}) ([]byte, error) {
+	if l, e := len(values), len(t.PartitionKey); l != e {
+		return nil, fmt.Errorf("gockle: table %v.%v has %v partition key columns, not %v", t.Keyspace, t.Name, e, l)
+	}
+
+	var bs = make([][]byte, len(values))
+
+	for i, n := range t.PartitionKey {
+		var c, _ = t.Column(n)
+		var b, err = gocql.Marshal(keyType(c.Type), values[i])
+
+		if err != nil {
+			return nil, fmt.Errorf("gockle: partition key column %v: %v", n, err)
+		}
+
+		if b == nil {
+			return nil, fmt.Errorf("gockle: partition key column %v is null", n)
+		}
+
+		bs[i] = b
+	}
+
+	return partitioner.Key(bs), nil
+}
+
+// keyVersion is the protocol version whose serialization of collections is the
+// one of partition keys. The types of gocql KeyspaceMetadata have none.
+const keyVersion = 4
+
+// keyType returns t with keyVersion.
+func keyType(t gocql.TypeInfo) gocql.TypeInfo {
+	switch t := t.(type) {
+	case gocql.CollectionType:
+		var c = gocql.CollectionType{NativeType: gocql.NewNativeType(keyVersion, t.Type(), t.Custom()), Elem: keyType(t.Elem)}
+
+		if t.Key != nil {
+			c.Key = keyType(t.Key)
+		}
+
+		return c
+
+	case gocql.TupleTypeInfo:
+		var u = gocql.TupleTypeInfo{NativeType: gocql.NewNativeType(keyVersion, t.Type(), t.Custom())}
+
+		for _, e := range t.Elems {
+			u.Elems = append(u.Elems, keyType(e))
+		}
+
+		return u
+
+	case gocql.UDTTypeInfo:
+		var u = gocql.UDTTypeInfo{NativeType: gocql.NewNativeType(keyVersion, t.Type(), t.Custom()), KeySpace: t.KeySpace, Name: t.Name}
+
+		for _, e := range t.Elements {
+			u.Elements = append(u.Elements, gocql.UDTField{Name: e.Name, Type: keyType(e.Type)})
+		}
+
+		return u
+
+	case gocql.NativeType:
+		return gocql.NewNativeType(keyVersion, t.Type(), t.Custom())
+	}
+
+	return t
+}
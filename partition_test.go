@@ -0,0 +1,88 @@
+package gockle
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+func TestWriteTarget(t *testing.T) {
+	for _, c := range []struct {
+		statement       string
+		keyspace, table string
+		columns         []string
+		ok              bool
+	}{
+		{"insert into k.t (a, b) values (?, 1)", "k", "t", []string{"a", "b"}, true},
+		{"update t set n = 1 where a = ? and b in (1, 2) and c > 1", "", "t", []string{"a"}, true},
+		{"delete from k.t where token(a) = 1 and b = 2", "k", "t", []string{"b"}, true},
+		{"select * from k.t where a = 1", "", "", nil, false},
+		{"invalid", "", "", nil, false},
+	} {
+		var k, tb, terms, ok = writeTarget(c.statement)
+
+		if k != c.keyspace || tb != c.table || ok != c.ok {
+			t.Errorf("Actual %v %v %v, expected %v %v %v for %v", k, tb, ok, c.keyspace, c.table, c.ok, c.statement)
+		}
+
+		var cs []string
+
+		for _, n := range c.columns {
+			if _, ok := terms[n]; ok {
+				cs = append(cs, n)
+			}
+		}
+
+		if a, e := len(terms), len(c.columns); a != e || !reflect.DeepEqual(cs, c.columns) {
+			t.Errorf("Actual terms %v, expected columns %v for %v", terms, c.columns, c.statement)
+		}
+	}
+}
+
+func TestRoutingKey(t *testing.T) {
+	var native = func(typ gocql.Type) gocql.TypeInfo {
+		return gocql.NewNativeType(4, typ, "")
+	}
+
+	var tb = TableInfo{
+		Keyspace:     "k",
+		Name:         "t",
+		PartitionKey: []string{"a", "b"},
+		Columns: []ColumnInfo{
+			{Name: "a", Type: native(gocql.TypeInt)},
+			{Name: "b", Type: native(gocql.TypeText)},
+		},
+	}
+
+	if a, err := routingKey(tb, []interface{}{1, "xy"}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []byte{0, 4, 0, 0, 0, 1, 0, 0, 2, 'x', 'y', 0}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual key %v, expected %v", a, e)
+	}
+
+	for _, vs := range [][]interface{}{{1}, {"x", "y"}, {1, nil}} {
+		if _, err := routingKey(tb, vs); err == nil {
+			t.Errorf("Actual no error, expected error for %v", vs)
+		}
+	}
+
+	tb.PartitionKey = tb.PartitionKey[:1]
+
+	if a, err := routingKey(tb, []interface{}{1}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []byte{0, 0, 0, 1}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual key %v, expected %v", a, e)
+	}
+
+	if vs, ok := partitionValues(tb, map[string]cql.Term{"a": cql.Literal{Value: int64(2), Kind: cql.Integer}}, nil); !ok {
+		t.Error("Actual not ok, expected ok")
+	} else if e := []interface{}{2}; !reflect.DeepEqual(vs, e) {
+		t.Errorf("Actual values %v, expected %v", vs, e)
+	}
+
+	if _, ok := partitionValues(tb, map[string]cql.Term{"a": cql.Marker{Index: 1}}, []interface{}{1}); ok {
+		t.Error("Actual ok, expected not ok")
+	}
+}
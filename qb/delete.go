@@ -0,0 +1,99 @@
+package qb
+
+import (
+	"context"
+	"time"
+
+	"github.com/kerkerj/gockle"
+)
+
+// DeleteBuilder builds a DELETE statement.
+type DeleteBuilder struct {
+	table   string
+	columns []string
+	where   []Cmp
+	using   using
+	ifs     conditions
+}
+
+// Delete returns a DeleteBuilder for table, which may be qualified by a
+// keyspace. It deletes whole rows unless Columns is called.
+func Delete(table string) DeleteBuilder {
+	return DeleteBuilder{table: table}
+}
+
+// Columns adds columns to delete. They may be elements like m['k'].
+func (b DeleteBuilder) Columns(columns ...string) DeleteBuilder {
+	b.columns = appendStrings(b.columns, columns...)
+
+	return b
+}
+
+// Where adds relations to the WHERE clause.
+func (b DeleteBuilder) Where(relations ...Cmp) DeleteBuilder {
+	b.where = appendCmps(b.where, relations)
+
+	return b
+}
+
+// If adds conditions to the IF clause, which makes the statement a lightweight
+// transaction.
+func (b DeleteBuilder) If(conditions ...Cmp) DeleteBuilder {
+	b.ifs.ifs = appendCmps(b.ifs.ifs, conditions)
+
+	return b
+}
+
+// IfExists makes the statement a lightweight transaction that applies only if
+// the row exists. It overrides If.
+func (b DeleteBuilder) IfExists() DeleteBuilder {
+	b.ifs.ifExists = true
+
+	return b
+}
+
+// Timestamp sets the write time of the deletion, in microseconds.
+func (b DeleteBuilder) Timestamp(t time.Time) DeleteBuilder {
+	b.using.timestamp, b.using.hasTimestamp = t.UnixMicro(), true
+
+	return b
+}
+
+// Build implements Builder.
+func (b DeleteBuilder) Build() (string, []interface{}) {
+	var w writer
+
+	w.string("delete ")
+
+	if len(b.columns) > 0 {
+		w.names(b.columns)
+		w.string(" ")
+	}
+
+	w.string("from " + b.table)
+	w.using(b.using)
+	w.cmps("where", b.where)
+	w.conditions(b.ifs)
+
+	return w.build()
+}
+
+// AddTo adds the statement to batch.
+func (b DeleteBuilder) AddTo(batch gockle.Batch) {
+	add(b, batch)
+}
+
+// Exec executes the statement with s.
+func (b DeleteBuilder) Exec(s gockle.Session) error {
+	return exec(b, s)
+}
+
+// ExecContext executes the statement with s and ctx.
+func (b DeleteBuilder) ExecContext(ctx context.Context, s gockle.Session) error {
+	return execContext(ctx, b, s)
+}
+
+// Query returns the Query of s for the statement.
+func (b DeleteBuilder) Query(s gockle.Session) gockle.Query {
+	return query(b, s)
+}
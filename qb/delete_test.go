@@ -0,0 +1,29 @@
+package qb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDelete(t *testing.T) {
+	for _, c := range []struct {
+		b Builder
+		s string
+		a []interface{}
+	}{
+		{Delete("ks.t").Where(Eq("id", 1)), "delete from ks.t where id = ?", []interface{}{1}},
+		{Delete("t").Columns("n", "m['k']").Where(In("id", 1, 2)).If(Gt("n", 0)), "delete n, m['k'] from t where id in (?, ?) if n > ?", []interface{}{1, 2, 0}},
+		{Delete("t").Where(Eq("id", 1)).IfExists().Timestamp(time.UnixMicro(7)), "delete from t using timestamp 7 where id = ? if exists", []interface{}{1}},
+	} {
+		var s, a = c.b.Build()
+
+		if s != c.s {
+			t.Errorf("Actual statement %v, expected %v", s, c.s)
+		}
+
+		if !reflect.DeepEqual(a, c.a) {
+			t.Errorf("Actual arguments %v, expected %v", a, c.a)
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package qb
+
+import (
+	"context"
+	"time"
+
+	"github.com/kerkerj/gockle"
+)
+
+// InsertBuilder builds an INSERT statement.
+type InsertBuilder struct {
+	table   string
+	columns []string
+	values  []interface{}
+	using   using
+	ifs     conditions
+}
+
+// Insert returns an InsertBuilder for table, which may be qualified by a
+// keyspace.
+func Insert(table string) InsertBuilder {
+	return InsertBuilder{table: table}
+}
+
+// Value adds column with value.
+func (b InsertBuilder) Value(column string, value interface{}) InsertBuilder {
+	b.columns = appendStrings(b.columns, column)
+	b.values = append(b.values[:len(b.values):len(b.values)], value)
+
+	return b
+}
+
+// IfNotExists makes the statement a lightweight transaction that applies only
+// if the row does not exist.
+func (b InsertBuilder) IfNotExists() InsertBuilder {
+	b.ifs.ifNotExists = true
+
+	return b
+}
+
+// TTL sets the time to live of the values, in whole seconds.
+func (b InsertBuilder) TTL(d time.Duration) InsertBuilder {
+	b.using.ttl, b.using.hasTTL = int64(d/time.Second), true
+
+	return b
+}
+
+// Timestamp sets the write time of the values, in microseconds.
+func (b InsertBuilder) Timestamp(t time.Time) InsertBuilder {
+	b.using.timestamp, b.using.hasTimestamp = t.UnixMicro(), true
+
+	return b
+}
+
+// Build implements Builder.
+func (b InsertBuilder) Build() (string, []interface{}) {
+	var w writer
+
+	w.string("insert into " + b.table + " (")
+	w.names(b.columns)
+	w.string(") values (")
+	w.values(b.values)
+	w.string(")")
+	w.conditions(b.ifs)
+	w.using(b.using)
+
+	return w.build()
+}
+
+// AddTo adds the statement to batch.
+func (b InsertBuilder) AddTo(batch gockle.Batch) {
+	add(b, batch)
+}
+
+// Exec executes the statement with s.
+func (b InsertBuilder) Exec(s gockle.Session) error {
+	return exec(b, s)
+}
+
+// ExecContext executes the statement with s and ctx.
+func (b InsertBuilder) ExecContext(ctx context.Context, s gockle.Session) error {
+	return execContext(ctx, b, s)
+}
+
+// Query returns the Query of s for the statement.
+func (b InsertBuilder) Query(s gockle.Session) gockle.Query {
+	return query(b, s)
+}
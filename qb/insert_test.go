@@ -0,0 +1,33 @@
+package qb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestInsert(t *testing.T) {
+	var ts = time.UnixMicro(1500)
+
+	for _, c := range []struct {
+		b Builder
+		s string
+		a []interface{}
+	}{
+		{Insert("ks.t").Value("id", 1).Value("n", 2), "insert into ks.t (id, n) values (?, ?)", []interface{}{1, 2}},
+		{Insert("t").Value("id", 1).Value("at", Fn("now")).IfNotExists(), "insert into t (id, at) values (?, now()) if not exists", []interface{}{1}},
+		{Insert("t").Value("id", 1).TTL(time.Minute), "insert into t (id) values (?) using ttl 60", []interface{}{1}},
+		{Insert("t").Value("id", 1).TTL(time.Hour).Timestamp(ts), "insert into t (id) values (?) using ttl 3600 and timestamp 1500", []interface{}{1}},
+		{Insert("t").Value("id", 1).Timestamp(ts), "insert into t (id) values (?) using timestamp 1500", []interface{}{1}},
+	} {
+		var s, a = c.b.Build()
+
+		if s != c.s {
+			t.Errorf("Actual statement %v, expected %v", s, c.s)
+		}
+
+		if !reflect.DeepEqual(a, c.a) {
+			t.Errorf("Actual arguments %v, expected %v", a, c.a)
+		}
+	}
+}
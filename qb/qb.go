@@ -0,0 +1,253 @@
+// Package qb builds CQL statements for gockle. Builders render a statement with
+// bind markers and the arguments for them in order, and run it through a
+// gockle.Session or add it to a gockle.Batch.
+//
+// Builders are values. Their methods return changed copies, so a partial
+// statement can be shared and extended.
+package qb
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/kerkerj/gockle"
+)
+
+// Builder renders a CQL statement and its arguments.
+type Builder interface {
+	// Build returns the statement and its arguments.
+	Build() (string, []interface{})
+}
+
+var (
+	_ Builder = DeleteBuilder{}
+	_ Builder = InsertBuilder{}
+	_ Builder = SelectBuilder{}
+	_ Builder = UpdateBuilder{}
+)
+
+// Func is a CQL function call, like token(?) or now(). It can be used wherever
+// a value can. Its arguments are values too.
+type Func struct {
+	Name string
+	Args []interface{}
+}
+
+// Fn returns a Func for name and args.
+func Fn(name string, args ...interface{}) Func {
+	return Func{Name: name, Args: args}
+}
+
+// Token returns the expression token(columns) for use as the column of a Cmp.
+// Compare it to the token of values with Fn("token", values...).
+func Token(columns ...string) string {
+	return "token(" + strings.Join(columns, ", ") + ")"
+}
+
+// Cmp is a relation in a WHERE clause or a condition in an IF clause.
+type Cmp struct {
+	column string
+	op     string
+	values []interface{}
+	list   bool
+}
+
+// Eq returns column = value.
+func Eq(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: "=", values: []interface{}{value}}
+}
+
+// Ne returns column != value. It is only valid in IF clauses.
+func Ne(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: "!=", values: []interface{}{value}}
+}
+
+// Lt returns column < value.
+func Lt(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: "<", values: []interface{}{value}}
+}
+
+// Lte returns column <= value.
+func Lte(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: "<=", values: []interface{}{value}}
+}
+
+// Gt returns column > value.
+func Gt(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: ">", values: []interface{}{value}}
+}
+
+// Gte returns column >= value.
+func Gte(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: ">=", values: []interface{}{value}}
+}
+
+// In returns column in (values).
+func In(column string, values ...interface{}) Cmp {
+	return Cmp{column: column, op: "in", values: values, list: true}
+}
+
+// Contains returns column contains value.
+func Contains(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: "contains", values: []interface{}{value}}
+}
+
+// ContainsKey returns column contains key value.
+func ContainsKey(column string, value interface{}) Cmp {
+	return Cmp{column: column, op: "contains key", values: []interface{}{value}}
+}
+
+// Order is the order of an ORDER BY column.
+type Order bool
+
+// Orders.
+const (
+	Asc  Order = false
+	Desc Order = true
+)
+
+// writer accumulates a statement and its arguments.
+type writer struct {
+	b    strings.Builder
+	args []interface{}
+}
+
+func (w *writer) string(s string) {
+	w.b.WriteString(s)
+}
+
+func (w *writer) names(ns []string) {
+	w.string(strings.Join(ns, ", "))
+}
+
+func (w *writer) value(v interface{}) {
+	var f, ok = v.(Func)
+
+	if !ok {
+		w.string("?")
+		w.args = append(w.args, v)
+
+		return
+	}
+
+	w.string(f.Name + "(")
+	w.values(f.Args)
+	w.string(")")
+}
+
+func (w *writer) values(vs []interface{}) {
+	for i, v := range vs {
+		if i > 0 {
+			w.string(", ")
+		}
+
+		w.value(v)
+	}
+}
+
+func (w *writer) cmps(keyword string, cs []Cmp) {
+	for i, c := range cs {
+		if i == 0 {
+			w.string(" " + keyword + " ")
+		} else {
+			w.string(" and ")
+		}
+
+		w.string(c.column + " " + c.op + " ")
+
+		if c.list {
+			w.string("(")
+			w.values(c.values)
+			w.string(")")
+		} else {
+			w.value(c.values[0])
+		}
+	}
+}
+
+func (w *writer) int(n int64) {
+	w.string(strconv.FormatInt(n, 10))
+}
+
+func (w *writer) build() (string, []interface{}) {
+	return w.b.String(), w.args
+}
+
+// using holds the USING options of a statement.
+type using struct {
+	ttl          int64
+	hasTTL       bool
+	timestamp    int64
+	hasTimestamp bool
+}
+
+func (w *writer) using(u using) {
+	switch {
+	case u.hasTTL && u.hasTimestamp:
+		w.string(" using ttl ")
+		w.int(u.ttl)
+		w.string(" and timestamp ")
+		w.int(u.timestamp)
+
+	case u.hasTTL:
+		w.string(" using ttl ")
+		w.int(u.ttl)
+
+	case u.hasTimestamp:
+		w.string(" using timestamp ")
+		w.int(u.timestamp)
+	}
+}
+
+// conditions holds the IF clause of a statement.
+type conditions struct {
+	ifs         []Cmp
+	ifExists    bool
+	ifNotExists bool
+}
+
+func (w *writer) conditions(c conditions) {
+	switch {
+	case c.ifNotExists:
+		w.string(" if not exists")
+
+	case c.ifExists:
+		w.string(" if exists")
+
+	default:
+		w.cmps("if", c.ifs)
+	}
+}
+
+func appendCmps(cs []Cmp, more []Cmp) []Cmp {
+	return append(cs[:len(cs):len(cs)], more...)
+}
+
+func appendStrings(ss []string, more ...string) []string {
+	return append(ss[:len(ss):len(ss)], more...)
+}
+
+func add(b Builder, batch gockle.Batch) {
+	var s, args = b.Build()
+
+	batch.Add(s, args...)
+}
+
+func exec(b Builder, s gockle.Session) error {
+	var st, args = b.Build()
+
+	return s.Exec(st, args...)
+}
+
+func execContext(ctx context.Context, b Builder, s gockle.Session) error {
+	var st, args = b.Build()
+
+	return s.ExecContext(ctx, st, args...)
+}
+
+func query(b Builder, s gockle.Session) gockle.Query {
+	var st, args = b.Build()
+
+	return s.Query(st, args...)
+}
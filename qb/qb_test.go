@@ -0,0 +1,65 @@
+package qb
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kerkerj/gockle"
+)
+
+func TestSession(t *testing.T) {
+	var s = gockle.NewMemorySession()
+
+	defer s.Close()
+
+	for _, st := range []string{
+		"create keyspace ks with replication = {'class': 'SimpleStrategy', 'replication_factor': 1}",
+		"create table ks.t (id int primary key, n int, s set<text>)",
+	} {
+		if err := s.Exec(st); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	if err := Insert("ks.t").Value("id", 1).Value("n", 2).Exec(s); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var b = s.Batch(gockle.BatchLogged)
+
+	Insert("ks.t").Value("id", 2).Value("n", 3).AddTo(b)
+	Update("ks.t").Add("s", []string{"a"}).Where(Eq("id", 1)).AddTo(b)
+	Delete("ks.t").Where(Eq("id", 3)).AddTo(b)
+
+	if err := b.Exec(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := Update("ks.t").Set("n", 4).Where(Eq("id", 2)).ExecContext(context.Background(), s); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var rows, err = gockle.ScanAll[struct {
+		ID int
+		N  int
+		S  []string
+	}](Select("ks.t").Where(In("id", 1, 2)).Query(s).Iter())
+
+	if err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(rows) != 2 || rows[0].N != 2 || len(rows[0].S) != 1 || rows[1].N != 4 {
+		t.Errorf("Actual rows %v, expected 2 updated rows", rows)
+	}
+
+	if err := Delete("ks.t").Where(Eq("id", 1)).Exec(s); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var st, args = Insert("ks.t").Value("id", 2).IfNotExists().Build()
+
+	if applied, err := s.ScanMapTx(st, map[string]interface{}{}, args...); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if applied {
+		t.Error("Actual applied true, expected false")
+	}
+}
@@ -0,0 +1,126 @@
+package qb
+
+import (
+	"github.com/kerkerj/gockle"
+)
+
+// SelectBuilder builds a SELECT statement.
+type SelectBuilder struct {
+	table             string
+	columns           []string
+	distinct          bool
+	where             []Cmp
+	orderBy           []string
+	limit             int
+	perPartitionLimit int
+	allowFiltering    bool
+}
+
+// Select returns a SelectBuilder for table, which may be qualified by a
+// keyspace. It selects all columns unless Columns is called.
+func Select(table string) SelectBuilder {
+	return SelectBuilder{table: table}
+}
+
+// Columns adds columns to select. They may be selectors like count(*) or
+// writetime(n).
+func (b SelectBuilder) Columns(columns ...string) SelectBuilder {
+	b.columns = appendStrings(b.columns, columns...)
+
+	return b
+}
+
+// Distinct selects distinct partition keys.
+func (b SelectBuilder) Distinct() SelectBuilder {
+	b.distinct = true
+
+	return b
+}
+
+// Where adds relations to the WHERE clause.
+func (b SelectBuilder) Where(relations ...Cmp) SelectBuilder {
+	b.where = appendCmps(b.where, relations)
+
+	return b
+}
+
+// OrderBy adds column in order to the ORDER BY clause.
+func (b SelectBuilder) OrderBy(column string, order Order) SelectBuilder {
+	if order == Desc {
+		column += " desc"
+	} else {
+		column += " asc"
+	}
+
+	b.orderBy = appendStrings(b.orderBy, column)
+
+	return b
+}
+
+// Limit limits the rows to n. Zero means no limit.
+func (b SelectBuilder) Limit(n int) SelectBuilder {
+	b.limit = n
+
+	return b
+}
+
+// PerPartitionLimit limits the rows of each partition to n. Zero means no
+// limit.
+func (b SelectBuilder) PerPartitionLimit(n int) SelectBuilder {
+	b.perPartitionLimit = n
+
+	return b
+}
+
+// AllowFiltering allows filtering.
+func (b SelectBuilder) AllowFiltering() SelectBuilder {
+	b.allowFiltering = true
+
+	return b
+}
+
+// Build implements Builder.
+func (b SelectBuilder) Build() (string, []interface{}) {
+	var w writer
+
+	w.string("select ")
+
+	if b.distinct {
+		w.string("distinct ")
+	}
+
+	if len(b.columns) == 0 {
+		w.string("*")
+	} else {
+		w.names(b.columns)
+	}
+
+	w.string(" from " + b.table)
+	w.cmps("where", b.where)
+
+	if len(b.orderBy) > 0 {
+		w.string(" order by ")
+		w.names(b.orderBy)
+	}
+
+	if b.perPartitionLimit > 0 {
+		w.string(" per partition limit ")
+		w.int(int64(b.perPartitionLimit))
+	}
+
+	if b.limit > 0 {
+		w.string(" limit ")
+		w.int(int64(b.limit))
+	}
+
+	if b.allowFiltering {
+		w.string(" allow filtering")
+	}
+
+	return w.build()
+}
+
+// Query returns the Query of s for the statement.
+func (b SelectBuilder) Query(s gockle.Session) gockle.Query {
+	return query(b, s)
+}
@@ -0,0 +1,37 @@
+package qb
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSelect(t *testing.T) {
+	var base = Select("ks.t").Where(Eq("id", 1))
+
+	for _, c := range []struct {
+		b Builder
+		s string
+		a []interface{}
+	}{
+		{Select("ks.t"), "select * from ks.t", nil},
+		{base.Columns("id", "count(*)").Distinct(), "select distinct id, count(*) from ks.t where id = ?", []interface{}{1}},
+		{base.Where(Gte("c", 2), Lt("c", 5)).OrderBy("c", Desc).OrderBy("d", Asc).Limit(10), "select * from ks.t where id = ? and c >= ? and c < ? order by c desc, d asc limit 10", []interface{}{1, 2, 5}},
+		{Select("t").Where(In("id", 1, 2, 3), Contains("s", "a")).PerPartitionLimit(2).AllowFiltering(), "select * from t where id in (?, ?, ?) and s contains ? per partition limit 2 allow filtering", []interface{}{1, 2, 3, "a"}},
+		{Select("t").Where(Gt(Token("a", "b"), Fn("token", 1, 2)), Lte(Token("a", "b"), int64(9))), "select * from t where token(a, b) > token(?, ?) and token(a, b) <= ?", []interface{}{1, 2, int64(9)}},
+		{Select("t").Where(ContainsKey("m", "k"), In("id")), "select * from t where m contains key ? and id in ()", []interface{}{"k"}},
+	} {
+		var s, a = c.b.Build()
+
+		if s != c.s {
+			t.Errorf("Actual statement %v, expected %v", s, c.s)
+		}
+
+		if !reflect.DeepEqual(a, c.a) {
+			t.Errorf("Actual arguments %v, expected %v", a, c.a)
+		}
+	}
+
+	if s, _ := base.Build(); s != "select * from ks.t where id = ?" {
+		t.Errorf("Actual statement %v, expected base unchanged", s)
+	}
+}
@@ -0,0 +1,168 @@
+package qb
+
+import (
+	"context"
+	"time"
+
+	"github.com/kerkerj/gockle"
+)
+
+// assignment is an assignment in a SET clause.
+type assignment struct {
+	column string
+	op     string
+	key    interface{}
+	value  interface{}
+}
+
+// UpdateBuilder builds an UPDATE statement.
+type UpdateBuilder struct {
+	table string
+	set   []assignment
+	where []Cmp
+	using using
+	ifs   conditions
+}
+
+// Update returns an UpdateBuilder for table, which may be qualified by a
+// keyspace.
+func Update(table string) UpdateBuilder {
+	return UpdateBuilder{table: table}
+}
+
+func (b UpdateBuilder) assign(a assignment) UpdateBuilder {
+	b.set = append(b.set[:len(b.set):len(b.set)], a)
+
+	return b
+}
+
+// Set sets column to value.
+func (b UpdateBuilder) Set(column string, value interface{}) UpdateBuilder {
+	return b.assign(assignment{column: column, op: "=", value: value})
+}
+
+// SetKey sets the element of map column at key, or of list column at index
+// key, to value.
+func (b UpdateBuilder) SetKey(column string, key, value interface{}) UpdateBuilder {
+	return b.assign(assignment{column: column, op: "[]", key: key, value: value})
+}
+
+// Add adds value to column. Value is a number for counters, a set of elements
+// for sets, or a map of entries for maps.
+func (b UpdateBuilder) Add(column string, value interface{}) UpdateBuilder {
+	return b.assign(assignment{column: column, op: "+", value: value})
+}
+
+// Append appends the elements of list value to list column.
+func (b UpdateBuilder) Append(column string, value interface{}) UpdateBuilder {
+	return b.Add(column, value)
+}
+
+// Prepend prepends the elements of list value to list column.
+func (b UpdateBuilder) Prepend(column string, value interface{}) UpdateBuilder {
+	return b.assign(assignment{column: column, op: "prepend", value: value})
+}
+
+// Remove removes value from column. Value is a number for counters, a set of
+// elements for sets and lists, or a set of keys for maps.
+func (b UpdateBuilder) Remove(column string, value interface{}) UpdateBuilder {
+	return b.assign(assignment{column: column, op: "-", value: value})
+}
+
+// Where adds relations to the WHERE clause.
+func (b UpdateBuilder) Where(relations ...Cmp) UpdateBuilder {
+	b.where = appendCmps(b.where, relations)
+
+	return b
+}
+
+// If adds conditions to the IF clause, which makes the statement a lightweight
+// transaction.
+func (b UpdateBuilder) If(conditions ...Cmp) UpdateBuilder {
+	b.ifs.ifs = appendCmps(b.ifs.ifs, conditions)
+
+	return b
+}
+
+// IfExists makes the statement a lightweight transaction that applies only if
+// the row exists. It overrides If.
+func (b UpdateBuilder) IfExists() UpdateBuilder {
+	b.ifs.ifExists = true
+
+	return b
+}
+
+// TTL sets the time to live of the values, in whole seconds.
+func (b UpdateBuilder) TTL(d time.Duration) UpdateBuilder {
+	b.using.ttl, b.using.hasTTL = int64(d/time.Second), true
+
+	return b
+}
+
+// Timestamp sets the write time of the values, in microseconds.
+func (b UpdateBuilder) Timestamp(t time.Time) UpdateBuilder {
+	b.using.timestamp, b.using.hasTimestamp = t.UnixMicro(), true
+
+	return b
+}
+
+// Build implements Builder.
+func (b UpdateBuilder) Build() (string, []interface{}) {
+	var w writer
+
+	w.string("update " + b.table)
+	w.using(b.using)
+	w.string(" set ")
+
+	for i, a := range b.set {
+		if i > 0 {
+			w.string(", ")
+		}
+
+		switch a.op {
+		case "=":
+			w.string(a.column + " = ")
+			w.value(a.value)
+
+		case "[]":
+			w.string(a.column + "[")
+			w.value(a.key)
+			w.string("] = ")
+			w.value(a.value)
+
+		case "prepend":
+			w.string(a.column + " = ")
+			w.value(a.value)
+			w.string(" + " + a.column)
+
+		default:
+			w.string(a.column + " = " + a.column + " " + a.op + " ")
+			w.value(a.value)
+		}
+	}
+
+	w.cmps("where", b.where)
+	w.conditions(b.ifs)
+
+	return w.build()
+}
+
+// AddTo adds the statement to batch.
+func (b UpdateBuilder) AddTo(batch gockle.Batch) {
+	add(b, batch)
+}
+
+// Exec executes the statement with s.
+func (b UpdateBuilder) Exec(s gockle.Session) error {
+	return exec(b, s)
+}
+
+// ExecContext executes the statement with s and ctx.
+func (b UpdateBuilder) ExecContext(ctx context.Context, s gockle.Session) error {
+	return execContext(ctx, b, s)
+}
+
+// Query returns the Query of s for the statement.
+func (b UpdateBuilder) Query(s gockle.Session) gockle.Query {
+	return query(b, s)
+}
@@ -0,0 +1,33 @@
+package qb
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestUpdate(t *testing.T) {
+	var id = Eq("id", 1)
+
+	for _, c := range []struct {
+		b Builder
+		s string
+		a []interface{}
+	}{
+		{Update("ks.t").Set("n", 2).Where(id), "update ks.t set n = ? where id = ?", []interface{}{2, 1}},
+		{Update("t").Add("c", 1).Remove("s", []string{"a"}).Where(id), "update t set c = c + ?, s = s - ? where id = ?", []interface{}{1, []string{"a"}, 1}},
+		{Update("t").Append("l", []int{3}).Prepend("l", []int{0}).Where(id), "update t set l = l + ?, l = ? + l where id = ?", []interface{}{[]int{3}, []int{0}, 1}},
+		{Update("t").SetKey("m", "k", "v").Where(id).If(Eq("n", 2), Ne("o", 3)), "update t set m[?] = ? where id = ? if n = ? and o != ?", []interface{}{"k", "v", 1, 2, 3}},
+		{Update("t").Set("n", 2).Where(id).IfExists().TTL(time.Second), "update t using ttl 1 set n = ? where id = ? if exists", []interface{}{2, 1}},
+	} {
+		var s, a = c.b.Build()
+
+		if s != c.s {
+			t.Errorf("Actual statement %v, expected %v", s, c.s)
+		}
+
+		if !reflect.DeepEqual(a, c.a) {
+			t.Errorf("Actual arguments %v, expected %v", a, c.a)
+		}
+	}
+}
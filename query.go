@@ -23,11 +23,45 @@ type Query interface {
 	// point in time. Setting this will disable to query paging for this query, and
 	// must be used for all subsequent pages.
 	PageState(state []byte) Query
-	// Exec executes the query without returning any rows.
+	// Idempotent marks the query as safe to run more than once, so that it can
+	// be retried after errors that leave its outcome unknown. Counter updates,
+	// like c = c + 1, are never idempotent, even if marked.
+	Idempotent(value bool) Query
+	// SerialConsistency sets the consistency level for the serial phase of
+	// conditional updates. It is ignored for other queries.
+	SerialConsistency(c gocql.SerialConsistency) Query
+	// WithTimestamp sets the timestamp of the query in microseconds since the
+	// epoch, instead of the one made by the client or the coordinator.
+	WithTimestamp(timestamp int64) Query
+	// DefaultTimestamp sets whether the client sends the time of the query as
+	// its timestamp, instead of the coordinator making one. It requires protocol
+	// version 3 or later.
+	DefaultTimestamp(enable bool) Query
+	// RetryPolicy sets the gocql retry policy of the query.
+	RetryPolicy(r gocql.RetryPolicy) Query
+	// Trace enables tracing of the query with t.
+	Trace(t gocql.Tracer) Query
+	// Observer sets an observer that is called after each attempt to run the
+	// query.
+	Observer(o gocql.QueryObserver) Query
+	// NoSkipMetadata asks for the result metadata along with the rows, even if
+	// it was prepared with the statement.
+	NoSkipMetadata() Query
+	// RoutingKey sets the routing key, instead of the one computed from the
+	// partition key values, to choose the replicas that the query is sent to.
+	RoutingKey(key []byte) Query
+	// Bind replaces the values of the bind markers of the query.
+	Bind(values ...interface{}) Query
+	// Exec executes the query without returning any rows. If the query is
+	// conditional and not applied, the error matches ErrNotApplied with a
+	// context from ReportNotApplied.
 	Exec() error
 	// Iter executes the query and returns an iterator capable of iterating
 	// over all results.
 	Iter() Iterator
+	// IterAsync is like Iter but runs in the background like
+	// Session.ExecAsync. The Iterator of the Future reports the errors.
+	IterAsync() *Future
 	// MapScan executes the query, copies the columns of the first selected
 	// row into the map pointed at by m and discards the rest. If no rows
 	// were selected, ErrNotFound is returned.
@@ -36,6 +70,14 @@ type Query interface {
 	// row into the values pointed at by dest and discards the rest. If no rows
 	// were selected, ErrNotFound is returned.
 	Scan(dest ...interface{}) error
+	// ScanStruct executes the query, copies the columns of the first selected
+	// row into the struct pointed at by dest and discards the rest, like
+	// Iterator.StructScan. If no rows were selected, ErrNotFound is returned.
+	ScanStruct(dest interface{}) error
+	// ScanStructSlice executes the query and copies the columns of all the
+	// selected rows into the slice of structs or struct pointers pointed at by
+	// dest, like Iterator.StructScan.
+	ScanStructSlice(dest interface{}) error
 	// Release releases a query back into a pool of queries. Released Queries
 	// cannot be reused.
 	//
@@ -52,39 +94,172 @@ var (
 )
 
 type query struct {
-	q *gocql.Query
+	q     *gocql.Query
+	limit limiter
 }
 
 func (q query) Consistency(c gocql.Consistency) Query {
-	return &query{q: q.q.Consistency(c)}
+	return &query{q: q.q.Consistency(c), limit: q.limit}
 }
 
 func (q query) PageSize(n int) Query {
-	return &query{q: q.q.PageSize(n)}
+	return &query{q: q.q.PageSize(n), limit: q.limit}
 }
 
 func (q query) WithContext(ctx context.Context) Query {
-	return &query{q: q.q.WithContext(ctx)}
+	return &query{q: q.q.WithContext(ctx), limit: q.limit}
 }
 
 func (q query) PageState(state []byte) Query {
-	return &query{q: q.q.PageState(state)}
+	return &query{q: q.q.PageState(state), limit: q.limit}
 }
 
+func (q query) Idempotent(value bool) Query {
+	return &query{q: q.q.Idempotent(value), limit: q.limit}
+}
+
+func (q query) SerialConsistency(c gocql.SerialConsistency) Query {
+	return &query{q: q.q.SerialConsistency(c), limit: q.limit}
+}
+
+func (q query) WithTimestamp(timestamp int64) Query {
+	return &query{q: q.q.WithTimestamp(timestamp), limit: q.limit}
+}
+
+func (q query) DefaultTimestamp(enable bool) Query {
+	return &query{q: q.q.DefaultTimestamp(enable), limit: q.limit}
+}
+
+func (q query) RetryPolicy(r gocql.RetryPolicy) Query {
+	return &query{q: q.q.RetryPolicy(r), limit: q.limit}
+}
+
+func (q query) Trace(t gocql.Tracer) Query {
+	return &query{q: q.q.Trace(t), limit: q.limit}
+}
+
+func (q query) Observer(o gocql.QueryObserver) Query {
+	return &query{q: q.q.Observer(o), limit: q.limit}
+}
+
+func (q query) NoSkipMetadata() Query {
+	return &query{q: q.q.NoSkipMetadata(), limit: q.limit}
+}
+
+func (q query) RoutingKey(key []byte) Query {
+	return &query{q: q.q.RoutingKey(key), limit: q.limit}
+}
+
+func (q query) Bind(values ...interface{}) Query {
+	return &query{q: q.q.Bind(values...), limit: q.limit}
+}
+
+// error returns err as an *Error for q and the coordinator of i, which it
+// observes.
+func (q query) error(err error, i *gocql.Iter) error {
+	var c = coordinator(i.Host())
+
+	observeCoordinator(q.q.Context(), c)
+
+	return newError(err, q.q.Statement(), c)
+}
+
+// Exec returns an error of kind ErrNotApplied for a conditional statement
+// that is not applied, with a context from ReportNotApplied. Only then, like
+// gocql.Query.MapScanCAS, it asks for the result metadata, because that of
+// prepared conditional statements does not match their rows.
 func (q query) Exec() error {
-	return q.q.Exec()
+	if !reportNotApplied(q.q.Context()) || !conditional(q.q.Statement()) {
+		var i = q.q.Iter()
+
+		return q.error(i.Close(), i)
+	}
+
+	var i = q.q.NoSkipMetadata().Iter()
+	var applied = true
+
+	if cs := i.Columns(); len(cs) > 0 && cs[0].Name == ColumnApplied {
+		var m = map[string]interface{}{}
+
+		if i.MapScan(m) {
+			applied, _ = m[ColumnApplied].(bool)
+		}
+	}
+
+	if err := q.error(i.Close(), i); err != nil {
+		return err
+	}
+
+	if !applied {
+		return notApplied(q.q.Statement(), coordinator(i.Host()))
+	}
+
+	return nil
 }
 
 func (q query) Iter() Iterator {
-	return &iterator{i: q.q.Iter()}
+	var i = q.q.Iter()
+
+	observeCoordinator(q.q.Context(), coordinator(i.Host()))
+
+	return &iterator{i: i, statement: q.q.Statement()}
+}
+
+func (q query) IterAsync() *Future {
+	return q.limit.iter(q.q.Context(), q.Iter)
 }
 
 func (q query) MapScan(m map[string]interface{}) error {
-	return q.q.MapScan(m)
+	return q.first(func(i *gocql.Iter) {
+		i.MapScan(m)
+	})
+}
+
+// mapScanCAS is like gocql.Query.MapScanCAS.
+func (q query) mapScanCAS(m map[string]interface{}) (bool, error) {
+	var applied bool
+
+	q.q.NoSkipMetadata()
+
+	var err = q.first(func(i *gocql.Iter) {
+		i.MapScan(m)
+		applied, _ = m[ColumnApplied].(bool)
+		delete(m, ColumnApplied)
+	})
+
+	return applied, err
 }
 
 func (q query) Scan(dest ...interface{}) error {
-	return q.q.Scan(dest...)
+	return q.first(func(i *gocql.Iter) {
+		i.Scan(dest...)
+	})
+}
+
+// first runs q and calls scan for the first row. It returns ErrNotFound if
+// there is none.
+func (q query) first(scan func(i *gocql.Iter)) error {
+	var i = q.q.Iter()
+
+	if i.NumRows() == 0 {
+		if err := i.Close(); err != nil {
+			return q.error(err, i)
+		}
+
+		return ErrNotFound
+	}
+
+	scan(i)
+
+	return q.error(i.Close(), i)
+}
+
+func (q query) ScanStruct(dest interface{}) error {
+	return scanStruct(q.Iter(), dest)
+}
+
+func (q query) ScanStructSlice(dest interface{}) error {
+	return scanStructSlice(q.Iter(), dest)
 }
 
 func (q query) Release() {
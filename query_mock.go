@@ -11,6 +11,24 @@ type QueryMock struct {
 	mock.Mock
 }
 
+// Bind provides a mock function with given fields: values
+func (_m *QueryMock) Bind(values ...interface{}) Query {
+	var _ca []interface{}
+	_ca = append(_ca, values...)
+	ret := _m.Called(_ca...)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(...interface{}) Query); ok {
+		r0 = rf(values...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
 // Consistency provides a mock function with given fields: c
 func (_m *QueryMock) Consistency(c gocql.Consistency) Query {
 	ret := _m.Called(c)
@@ -27,6 +45,22 @@ func (_m *QueryMock) Consistency(c gocql.Consistency) Query {
 	return r0
 }
 
+// DefaultTimestamp provides a mock function with given fields: enable
+func (_m *QueryMock) DefaultTimestamp(enable bool) Query {
+	ret := _m.Called(enable)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(bool) Query); ok {
+		r0 = rf(enable)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
 // Exec provides a mock function with given fields:
 func (_m *QueryMock) Exec() error {
 	ret := _m.Called()
@@ -41,6 +75,22 @@ func (_m *QueryMock) Exec() error {
 	return r0
 }
 
+// Idempotent provides a mock function with given fields: value
+func (_m *QueryMock) Idempotent(value bool) Query {
+	ret := _m.Called(value)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(bool) Query); ok {
+		r0 = rf(value)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
 // Iter provides a mock function with given fields:
 func (_m *QueryMock) Iter() Iterator {
 	ret := _m.Called()
@@ -57,6 +107,22 @@ func (_m *QueryMock) Iter() Iterator {
 	return r0
 }
 
+// IterAsync provides a mock function with given fields:
+func (_m *QueryMock) IterAsync() *Future {
+	ret := _m.Called()
+
+	var r0 *Future
+	if rf, ok := ret.Get(0).(func() *Future); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Future)
+		}
+	}
+
+	return r0
+}
+
 // MapScan provides a mock function with given fields: m
 func (_m *QueryMock) MapScan(m map[string]interface{}) error {
 	ret := _m.Called(m)
@@ -71,6 +137,38 @@ func (_m *QueryMock) MapScan(m map[string]interface{}) error {
 	return r0
 }
 
+// NoSkipMetadata provides a mock function with given fields:
+func (_m *QueryMock) NoSkipMetadata() Query {
+	ret := _m.Called()
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func() Query); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
+// Observer provides a mock function with given fields: o
+func (_m *QueryMock) Observer(o gocql.QueryObserver) Query {
+	ret := _m.Called(o)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(gocql.QueryObserver) Query); ok {
+		r0 = rf(o)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
 // PageSize provides a mock function with given fields: n
 func (_m *QueryMock) PageSize(n int) Query {
 	ret := _m.Called(n)
@@ -108,6 +206,38 @@ func (_m *QueryMock) Release() {
 	_m.Called()
 }
 
+// RetryPolicy provides a mock function with given fields: r
+func (_m *QueryMock) RetryPolicy(r gocql.RetryPolicy) Query {
+	ret := _m.Called(r)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(gocql.RetryPolicy) Query); ok {
+		r0 = rf(r)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
+// RoutingKey provides a mock function with given fields: key
+func (_m *QueryMock) RoutingKey(key []byte) Query {
+	ret := _m.Called(key)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func([]byte) Query); ok {
+		r0 = rf(key)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
 // Scan provides a mock function with given fields: dest
 func (_m *QueryMock) Scan(dest ...interface{}) error {
 	var _ca []interface{}
@@ -124,6 +254,66 @@ func (_m *QueryMock) Scan(dest ...interface{}) error {
 	return r0
 }
 
+// ScanStruct provides a mock function with given fields: dest
+func (_m *QueryMock) ScanStruct(dest interface{}) error {
+	ret := _m.Called(dest)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(interface{}) error); ok {
+		r0 = rf(dest)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScanStructSlice provides a mock function with given fields: dest
+func (_m *QueryMock) ScanStructSlice(dest interface{}) error {
+	ret := _m.Called(dest)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(interface{}) error); ok {
+		r0 = rf(dest)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SerialConsistency provides a mock function with given fields: c
+func (_m *QueryMock) SerialConsistency(c gocql.SerialConsistency) Query {
+	ret := _m.Called(c)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(gocql.SerialConsistency) Query); ok {
+		r0 = rf(c)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
+// Trace provides a mock function with given fields: t
+func (_m *QueryMock) Trace(t gocql.Tracer) Query {
+	ret := _m.Called(t)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(gocql.Tracer) Query); ok {
+		r0 = rf(t)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
+
 // WithContext provides a mock function with given fields: ctx
 func (_m *QueryMock) WithContext(ctx context.Context) Query {
 	ret := _m.Called(ctx)
@@ -140,3 +330,18 @@ func (_m *QueryMock) WithContext(ctx context.Context) Query {
 	return r0
 }
 
+// WithTimestamp provides a mock function with given fields: timestamp
+func (_m *QueryMock) WithTimestamp(timestamp int64) Query {
+	ret := _m.Called(timestamp)
+
+	var r0 Query
+	if rf, ok := ret.Get(0).(func(int64) Query); ok {
+		r0 = rf(timestamp)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Query)
+		}
+	}
+
+	return r0
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"reflect"
 	"testing"
+
+	"github.com/gocql/gocql"
 )
 
 func TestQuery(t *testing.T) {
@@ -51,3 +53,71 @@ func TestQuery(t *testing.T) {
 		t.Errorf("Actual error %v, expected no error", err)
 	}
 }
+
+type queryObserver func(ctx context.Context, q gocql.ObservedQuery)
+
+func (o queryObserver) ObserveQuery(ctx context.Context, q gocql.ObservedQuery) {
+	o(ctx, q)
+}
+
+func TestQueryOptions(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	for _, q := range []string{ksDropIf, ksCreate, tabCreate} {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	defer s.Exec(ksDrop)
+
+	testQueryOptions(t, s)
+}
+
+func TestMemoryQueryOptions(t *testing.T) {
+	var s = newMemorySession(t)
+
+	defer s.Close()
+
+	testQueryOptions(t, s)
+}
+
+func testQueryOptions(t *testing.T, s Session) {
+	var observed []gocql.ObservedQuery
+
+	var q = s.Query("insert into gockle_test.test (id, n) values (?, ?)", 1, 2).
+		SerialConsistency(gocql.LocalSerial).
+		WithTimestamp(1000).
+		DefaultTimestamp(true).
+		RetryPolicy(&gocql.SimpleRetryPolicy{NumRetries: 1}).
+		NoSkipMetadata().
+		RoutingKey([]byte{0, 0, 0, 1}).
+		Observer(queryObserver(func(ctx context.Context, q gocql.ObservedQuery) {
+			observed = append(observed, q)
+		})).
+		Bind(3, 4)
+
+	if err := q.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if a, e := len(observed), 1; a != e {
+		t.Fatalf("Actual observed queries %v, expected %v", a, e)
+	}
+
+	if o := observed[0]; o.Err != nil {
+		t.Errorf("Actual error %v, expected no error", o.Err)
+	} else if a, e := o.Values, []interface{}{3, 4}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual values %v, expected %v", a, e)
+	}
+
+	var m = map[string]interface{}{}
+
+	if err := s.Query("select * from gockle_test.test where id = ?").Bind(3).MapScan(m); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := (map[string]interface{}{"id": 3, "n": 4}); !reflect.DeepEqual(m, e) {
+		t.Errorf("Actual row %v, expected %v", m, e)
+	}
+}
@@ -0,0 +1,850 @@
+package gockle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+// RecordingSession is a Session that records the calls made through it to the
+// wrapped Session, with their statements, arguments, paging states, results,
+// and errors, to save them to a golden file that a ReplaySession serves. Calls
+// are recorded in the order they start, and the calls of an Iterator with the
+// call that made it. Its methods are safe for concurrent use.
+//
+// Values are saved as JSON. Those without a destination of their own type, like
+// the values of maps and Scan into *interface{}, are saved with their Go type,
+// which must be a type gocql returns: a number, bool, string, []byte,
+// time.Time, time.Duration, gocql.UUID, gocql.Duration, *big.Int, *inf.Dec,
+// net.IP, or a slice or map of them. Structs are saved as JSON alone, so only
+// their exported fields are replayed.
+type RecordingSession struct {
+	Session
+
+	path string
+
+	mu    sync.Mutex
+	calls []*recordedCall
+}
+
+// NewRecordingSession returns a new RecordingSession that records the calls to
+// s and saves them to the golden file path.
+func NewRecordingSession(s Session, path string) *RecordingSession {
+	var r = &RecordingSession{path: path}
+
+	r.Session = Chain(s, r.record)
+
+	return r
+}
+
+func (r *RecordingSession) asyncLimiter() limiter {
+	return limiterOf(r.Session)
+}
+
+// Close saves the golden file and closes the wrapped Session. It ignores the
+// error of saving; call Save first to check it.
+func (r *RecordingSession) Close() {
+	r.Save()
+	r.Session.Close()
+}
+
+// Save writes the calls recorded so far to the golden file.
+func (r *RecordingSession) Save() error {
+	r.mu.Lock()
+	var b, err = json.MarshalIndent(r.calls, "", "\t")
+	r.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(r.path, append(b, '\n'), 0o644)
+}
+
+func (r *RecordingSession) record(next Handler) Handler {
+	return func(ctx context.Context, o *Operation) error {
+		if o.Kind == OperationIterClose {
+			var err = next(ctx, o)
+
+			if i, ok := o.Iterator.(*recordingIterator); ok {
+				i.event(recordedEvent{Method: "Close", Error: encodeError(err)})
+			}
+
+			return err
+		}
+
+		var c = &recordedCall{recordedRequest: newRecordedRequest(o)}
+
+		r.mu.Lock()
+		r.calls = append(r.calls, c)
+		r.mu.Unlock()
+
+		var err = next(ctx, o)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		c.record(o, err)
+
+		if o.Kind == OperationIter && err == nil && o.Iterator != nil {
+			o.Iterator = &recordingIterator{Iterator: o.Iterator, r: r, c: c}
+		}
+
+		return err
+	}
+}
+
+// recordedRequest is what a call asks for, which a ReplaySession matches.
+type recordedRequest struct {
+	Kind      string          `json:"kind"`
+	Statement string          `json:"statement,omitempty"`
+	Arguments []recordedValue `json:"arguments,omitempty"`
+	Keyspace  string          `json:"keyspace,omitempty"`
+	Table     string          `json:"table,omitempty"`
+	BatchKind BatchKind       `json:"batchKind,omitempty"`
+	Batch     []recordedEntry `json:"batch,omitempty"`
+	PageSize  int             `json:"pageSize,omitempty"`
+	PageState []byte          `json:"pageState,omitempty"`
+}
+
+func newRecordedRequest(o *Operation) recordedRequest {
+	var r = recordedRequest{
+		Kind:      o.Kind.String(),
+		Statement: o.Statement,
+		Arguments: encodeValues(o.Arguments),
+		Keyspace:  o.Keyspace,
+		Table:     o.Table,
+		BatchKind: o.BatchKind,
+		PageSize:  o.PageSize,
+		PageState: o.PageState,
+	}
+
+	for _, e := range o.Batch {
+		r.Batch = append(r.Batch, recordedEntry{Statement: e.Statement, Arguments: encodeValues(e.Arguments)})
+	}
+
+	return r
+}
+
+// String returns r as compact JSON.
+func (r recordedRequest) String() string {
+	var b, _ = json.Marshal(r)
+
+	return string(b)
+}
+
+// recordedEntry is a BatchEntry.
+type recordedEntry struct {
+	Statement string          `json:"statement"`
+	Arguments []recordedValue `json:"arguments,omitempty"`
+}
+
+// recordedCall is a call with its results. Only the results of its Kind are
+// set.
+type recordedCall struct {
+	recordedRequest
+
+	Error    *recordedError             `json:"error,omitempty"`
+	Results  []recordedValue            `json:"results,omitempty"`
+	Map      map[string]recordedValue   `json:"map,omitempty"`
+	Struct   json.RawMessage            `json:"struct,omitempty"`
+	Applied  bool                       `json:"applied,omitempty"`
+	Rows     []map[string]recordedValue `json:"rows,omitempty"`
+	Metadata json.RawMessage            `json:"metadata,omitempty"`
+	Iterator []recordedEvent            `json:"iterator,omitempty"`
+}
+
+// record records the results of o and err.
+func (c *recordedCall) record(o *Operation, err error) {
+	c.Error = encodeError(err)
+
+	switch o.Kind {
+	case OperationScan:
+		c.Results = encodeDestinations(o.Results)
+
+	case OperationScanMap, OperationScanMapTx:
+		c.Map = encodeMap(o.Map)
+
+	case OperationScanStruct, OperationScanStructSlice:
+		c.Struct, _ = json.Marshal(o.Struct)
+
+	case OperationScanMapSlice, OperationBatchExecTx:
+		c.Rows = encodeRows(o.Rows)
+	}
+
+	if v, ok := operationMetadata(o); ok {
+		c.Metadata, _ = json.Marshal(encodeMetadata(v))
+	}
+
+	c.Applied = o.Applied
+}
+
+// recordedEvent is a call to an Iterator with its results.
+type recordedEvent struct {
+	Method    string                     `json:"method"`
+	More      bool                       `json:"more,omitempty"`
+	Values    []recordedValue            `json:"values,omitempty"`
+	Map       map[string]recordedValue   `json:"map,omitempty"`
+	Struct    json.RawMessage            `json:"struct,omitempty"`
+	PageState []byte                     `json:"pageState,omitempty"`
+	Rows      []map[string]recordedValue `json:"rows,omitempty"`
+	Error     *recordedError             `json:"error,omitempty"`
+}
+
+// recordingIterator records the calls to Iterator in c.
+type recordingIterator struct {
+	Iterator
+
+	r *RecordingSession
+	c *recordedCall
+}
+
+func (i *recordingIterator) event(e recordedEvent) {
+	i.r.mu.Lock()
+	i.c.Iterator = append(i.c.Iterator, e)
+	i.r.mu.Unlock()
+}
+
+func (i *recordingIterator) Scan(results ...interface{}) bool {
+	var more = i.Iterator.Scan(results...)
+	var e = recordedEvent{Method: "Scan", More: more}
+
+	if more {
+		e.Values = encodeDestinations(results)
+	}
+
+	i.event(e)
+
+	return more
+}
+
+func (i *recordingIterator) ScanMap(results map[string]interface{}) bool {
+	var more = i.Iterator.ScanMap(results)
+	var e = recordedEvent{Method: "ScanMap", More: more}
+
+	if more {
+		e.Map = encodeMap(results)
+	}
+
+	i.event(e)
+
+	return more
+}
+
+func (i *recordingIterator) StructScan(dest interface{}) bool {
+	var more = i.Iterator.StructScan(dest)
+	var e = recordedEvent{Method: "StructScan", More: more}
+
+	if more {
+		e.Struct, _ = json.Marshal(dest)
+	}
+
+	i.event(e)
+
+	return more
+}
+
+func (i *recordingIterator) WillSwitchPage() bool {
+	var more = i.Iterator.WillSwitchPage()
+
+	i.event(recordedEvent{Method: "WillSwitchPage", More: more})
+
+	return more
+}
+
+func (i *recordingIterator) PageState() []byte {
+	var s = i.Iterator.PageState()
+
+	i.event(recordedEvent{Method: "PageState", PageState: s})
+
+	return s
+}
+
+func (i *recordingIterator) SliceMap() ([]map[string]interface{}, error) {
+	var ms, err = i.Iterator.SliceMap()
+
+	i.event(recordedEvent{Method: "SliceMap", Rows: encodeRows(ms), Error: encodeError(err)})
+
+	return ms, err
+}
+
+// recordedError is an error. Its Type is that of errorType.
+type recordedError struct {
+	Type        string `json:"type"`
+	Message     string `json:"message"`
+	Statement   string `json:"statement,omitempty"`
+	Coordinator string `json:"coordinator,omitempty"`
+}
+
+// errorKinds are the Kinds of *Error.
+var errorKinds = []error{ErrKeyspaceNotFound, ErrTableNotFound, ErrTimeout, ErrUnavailable, ErrOverloaded, ErrSyntax, ErrInvalid, ErrUnauthorized, ErrNotApplied}
+
+func encodeError(err error) *recordedError {
+	if err == nil {
+		return nil
+	}
+
+	var r = &recordedError{Type: errorType(err), Message: err.Error()}
+	var e *Error
+
+	if errors.As(err, &e) {
+		r.Statement, r.Coordinator = e.Statement, e.Coordinator
+	}
+
+	return r
+}
+
+// error returns an error with the message of e that matches the errors the
+// recorded error matched with errors.Is.
+func (e *recordedError) error() error {
+	if e == nil {
+		return nil
+	}
+
+	switch e.Type {
+	case "not_found":
+		return wrappedError{message: e.Message, err: ErrNotFound}
+
+	case "canceled":
+		return wrappedError{message: e.Message, err: context.Canceled}
+
+	case "deadline_exceeded":
+		return wrappedError{message: e.Message, err: context.DeadlineExceeded}
+
+	case "other":
+		return errors.New(e.Message)
+	}
+
+	var err = &Error{Statement: e.Statement, Coordinator: e.Coordinator, Err: errors.New(e.Message)}
+
+	for _, k := range errorKinds {
+		if errorType(&Error{Kind: k}) == e.Type {
+			err.Kind = k
+		}
+	}
+
+	return err
+}
+
+// recordedValue is a value with its Go type, or with an empty Type if it is
+// not a type gocql returns. Maps with string keys and slices of interface{}
+// have values of recordedValue.
+type recordedValue struct {
+	Type  string          `json:"type,omitempty"`
+	Value json.RawMessage `json:"value"`
+}
+
+// recordedTypes are the Go types gocql returns by name, other than slices and
+// maps.
+var recordedTypes = map[string]reflect.Type{}
+
+func init() {
+	for _, v := range []interface{}{
+		false, int(0), int8(0), int16(0), int32(0), int64(0), uint(0), uint8(0), uint16(0), uint32(0), uint64(0),
+		float32(0), float64(0), "", time.Time{}, time.Duration(0), gocql.UUID{}, gocql.Duration{}, (*big.Int)(nil),
+		(*inf.Dec)(nil), net.IP(nil),
+	} {
+		var t = reflect.TypeOf(v)
+
+		recordedTypes[t.String()] = t
+	}
+}
+
+// recordedType returns the Go type named name by reflect.Type.String.
+func recordedType(name string) (reflect.Type, bool) {
+	if t, ok := recordedTypes[name]; ok {
+		return t, true
+	}
+
+	if e, ok := strings.CutPrefix(name, "[]"); ok {
+		var t, ok = recordedType(e)
+
+		if !ok {
+			return nil, false
+		}
+
+		return reflect.SliceOf(t), true
+	}
+
+	if kv, ok := strings.CutPrefix(name, "map["); ok {
+		var k, v, _ = strings.Cut(kv, "]")
+		var kt, kok = recordedType(k)
+		var vt, vok = recordedType(v)
+
+		if !kok || !vok || !kt.Comparable() {
+			return nil, false
+		}
+
+		return reflect.MapOf(kt, vt), true
+	}
+
+	return nil, false
+}
+
+const (
+	recordedNil   = "nil"
+	recordedMap   = "map[string]interface {}"
+	recordedSlice = "[]interface {}"
+)
+
+func encodeValue(v interface{}) recordedValue {
+	var r recordedValue
+
+	switch v := v.(type) {
+	case nil:
+		return recordedValue{Type: recordedNil, Value: json.RawMessage("null")}
+
+	case map[string]interface{}:
+		r.Type = recordedMap
+		r.Value, _ = json.Marshal(encodeMap(v))
+
+		return r
+
+	case []interface{}:
+		r.Type = recordedSlice
+		r.Value, _ = json.Marshal(encodeValues(v))
+
+		return r
+	}
+
+	var err error
+
+	if r.Value, err = json.Marshal(v); err != nil {
+		r.Value, _ = json.Marshal(fmt.Sprint(v))
+
+		return r
+	}
+
+	if _, ok := recordedType(reflect.TypeOf(v).String()); ok {
+		r.Type = reflect.TypeOf(v).String()
+	}
+
+	return r
+}
+
+func encodeValues(vs []interface{}) []recordedValue {
+	var rs []recordedValue
+
+	for _, v := range vs {
+		rs = append(rs, encodeValue(v))
+	}
+
+	return rs
+}
+
+func encodeMap(m map[string]interface{}) map[string]recordedValue {
+	if m == nil {
+		return nil
+	}
+
+	var r = map[string]recordedValue{}
+
+	for k, v := range m {
+		r[k] = encodeValue(v)
+	}
+
+	return r
+}
+
+func encodeRows(ms []map[string]interface{}) []map[string]recordedValue {
+	var rs []map[string]recordedValue
+
+	for _, m := range ms {
+		rs = append(rs, encodeMap(m))
+	}
+
+	return rs
+}
+
+// encodeDestinations encodes the values that dests point to. A nil
+// destination is a nil value.
+func encodeDestinations(dests []interface{}) []recordedValue {
+	var rs []recordedValue
+
+	for _, d := range dests {
+		var v = reflect.ValueOf(d)
+
+		if v.Kind() != reflect.Pointer || v.IsNil() {
+			rs = append(rs, encodeValue(nil))
+		} else {
+			rs = append(rs, encodeValue(v.Elem().Interface()))
+		}
+	}
+
+	return rs
+}
+
+// value returns the value of r.
+func (r recordedValue) value() (interface{}, error) {
+	switch r.Type {
+	case recordedNil:
+		return nil, nil
+
+	case recordedMap:
+		var m map[string]recordedValue
+
+		if err := json.Unmarshal(r.Value, &m); err != nil {
+			return nil, err
+		}
+
+		return decodeMap(m)
+
+	case recordedSlice:
+		var rs []recordedValue
+
+		if err := json.Unmarshal(r.Value, &rs); err != nil {
+			return nil, err
+		}
+
+		var vs = make([]interface{}, len(rs))
+
+		for i, r := range rs {
+			var err error
+
+			if vs[i], err = r.value(); err != nil {
+				return nil, err
+			}
+		}
+
+		return vs, nil
+	}
+
+	var t, ok = recordedType(r.Type)
+
+	if !ok {
+		return nil, fmt.Errorf("gockle: cannot replay value %s of unknown type", r.Value)
+	}
+
+	var p = reflect.New(t)
+
+	if err := json.Unmarshal(r.Value, p.Interface()); err != nil {
+		return nil, err
+	}
+
+	return p.Elem().Interface(), nil
+}
+
+// decode puts the value of r in dest, a pointer. A nil dest skips the value.
+func (r recordedValue) decode(dest interface{}) error {
+	var d = reflect.ValueOf(dest)
+
+	if d.Kind() != reflect.Pointer || d.IsNil() {
+		return nil
+	}
+
+	if d.Elem().Kind() != reflect.Interface {
+		return json.Unmarshal(r.Value, dest)
+	}
+
+	var v, err = r.value()
+
+	if err != nil {
+		return err
+	}
+
+	if v == nil {
+		d.Elem().SetZero()
+	} else {
+		d.Elem().Set(reflect.ValueOf(v))
+	}
+
+	return nil
+}
+
+func decodeMap(m map[string]recordedValue) (map[string]interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+
+	var r = map[string]interface{}{}
+
+	for k, v := range m {
+		var err error
+
+		if r[k], err = v.value(); err != nil {
+			return nil, err
+		}
+	}
+
+	return r, nil
+}
+
+func decodeRows(rs []map[string]recordedValue) ([]map[string]interface{}, error) {
+	var ms []map[string]interface{}
+
+	for _, r := range rs {
+		var m, err = decodeMap(r)
+
+		if err != nil {
+			return nil, err
+		}
+
+		ms = append(ms, m)
+	}
+
+	return ms, nil
+}
+
+// operationMetadata returns the result field of o if o is for metadata.
+func operationMetadata(o *Operation) (reflect.Value, bool) {
+	switch o.Kind {
+	case OperationColumns:
+		return reflect.ValueOf(&o.Columns).Elem(), true
+
+	case OperationTables:
+		return reflect.ValueOf(&o.Tables).Elem(), true
+
+	case OperationKeyspace:
+		return reflect.ValueOf(&o.KeyspaceInfo).Elem(), true
+
+	case OperationTable:
+		return reflect.ValueOf(&o.TableInfo).Elem(), true
+	}
+
+	return reflect.Value{}, false
+}
+
+var typeInfoType = reflect.TypeOf((*gocql.TypeInfo)(nil)).Elem()
+
+// encodeMetadata returns v, metadata like TableInfo, to marshal, with its
+// gocql types as recordedGocqlTypes and its other interface values as
+// recordedValues.
+func encodeMetadata(v reflect.Value) interface{} {
+	switch {
+	case v.Type() == typeInfoType:
+		if v.IsNil() {
+			return nil
+		}
+
+		return encodeGocqlType(v.Interface().(gocql.TypeInfo))
+
+	case v.Kind() == reflect.Interface:
+		return encodeValue(v.Interface())
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var m = map[string]interface{}{}
+
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				m[f.Name] = encodeMetadata(v.Field(i))
+			}
+		}
+
+		return m
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return nil
+		}
+
+		var s = make([]interface{}, v.Len())
+
+		for i := range s {
+			s[i] = encodeMetadata(v.Index(i))
+		}
+
+		return s
+
+	case reflect.Map:
+		if v.IsNil() {
+			return nil
+		}
+
+		var m = map[string]interface{}{}
+
+		for _, k := range v.MapKeys() {
+			m[k.String()] = encodeMetadata(v.MapIndex(k))
+		}
+
+		return m
+	}
+
+	return v.Interface()
+}
+
+// decodeMetadata puts the metadata encoded by encodeMetadata in b in v, which
+// is settable.
+func decodeMetadata(b json.RawMessage, v reflect.Value) error {
+	if len(b) == 0 || string(b) == "null" {
+		return nil
+	}
+
+	switch {
+	case v.Type() == typeInfoType:
+		var t recordedGocqlType
+
+		if err := json.Unmarshal(b, &t); err != nil {
+			return err
+		}
+
+		v.Set(reflect.ValueOf(t.typeInfo()))
+
+		return nil
+
+	case v.Kind() == reflect.Interface:
+		var r recordedValue
+
+		if err := json.Unmarshal(b, &r); err != nil {
+			return err
+		}
+
+		var x, err = r.value()
+
+		if err == nil && x != nil {
+			v.Set(reflect.ValueOf(x))
+		}
+
+		return err
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var m map[string]json.RawMessage
+
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+
+		for i := 0; i < v.NumField(); i++ {
+			if f := v.Type().Field(i); f.IsExported() {
+				if err := decodeMetadata(m[f.Name], v.Field(i)); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+
+	case reflect.Slice:
+		var s []json.RawMessage
+
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+
+		v.Set(reflect.MakeSlice(v.Type(), len(s), len(s)))
+
+		for i, e := range s {
+			if err := decodeMetadata(e, v.Index(i)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+
+	case reflect.Map:
+		var m map[string]json.RawMessage
+
+		if err := json.Unmarshal(b, &m); err != nil {
+			return err
+		}
+
+		v.Set(reflect.MakeMap(v.Type()))
+
+		for k, e := range m {
+			var x = reflect.New(v.Type().Elem()).Elem()
+
+			if err := decodeMetadata(e, x); err != nil {
+				return err
+			}
+
+			v.SetMapIndex(reflect.ValueOf(k).Convert(v.Type().Key()), x)
+		}
+
+		return nil
+	}
+
+	return json.Unmarshal(b, v.Addr().Interface())
+}
+
+// recordedGocqlType is a gocql.TypeInfo.
+type recordedGocqlType struct {
+	Type     gocql.Type          `json:"type"`
+	Version  byte                `json:"version,omitempty"`
+	Custom   string              `json:"custom,omitempty"`
+	Key      *recordedGocqlType  `json:"key,omitempty"`
+	Elem     *recordedGocqlType  `json:"elem,omitempty"`
+	Elems    []recordedGocqlType `json:"elems,omitempty"`
+	Keyspace string              `json:"keyspace,omitempty"`
+	Name     string              `json:"name,omitempty"`
+	Fields   []recordedGocqlType `json:"fields,omitempty"`
+
+	// Field is the name of a field of a user-defined type.
+	Field string `json:"field,omitempty"`
+}
+
+func encodeGocqlType(t gocql.TypeInfo) *recordedGocqlType {
+	if t == nil {
+		return nil
+	}
+
+	var r = &recordedGocqlType{Type: t.Type(), Version: t.Version(), Custom: t.Custom()}
+
+	switch t := t.(type) {
+	case gocql.CollectionType:
+		r.Key, r.Elem = encodeGocqlType(t.Key), encodeGocqlType(t.Elem)
+
+	case gocql.TupleTypeInfo:
+		for _, e := range t.Elems {
+			r.Elems = append(r.Elems, *encodeGocqlType(e))
+		}
+
+	case gocql.UDTTypeInfo:
+		r.Keyspace, r.Name = t.KeySpace, t.Name
+
+		for _, f := range t.Elements {
+			var e = encodeGocqlType(f.Type)
+
+			e.Field = f.Name
+			r.Fields = append(r.Fields, *e)
+		}
+	}
+
+	return r
+}
+
+func (r *recordedGocqlType) typeInfo() gocql.TypeInfo {
+	if r == nil {
+		return nil
+	}
+
+	var n = gocql.NewNativeType(r.Version, r.Type, r.Custom)
+
+	switch r.Type {
+	case gocql.TypeList, gocql.TypeSet, gocql.TypeMap:
+		return gocql.CollectionType{NativeType: n, Key: r.Key.typeInfo(), Elem: r.Elem.typeInfo()}
+
+	case gocql.TypeTuple:
+		var t = gocql.TupleTypeInfo{NativeType: n}
+
+		for _, e := range r.Elems {
+			t.Elems = append(t.Elems, e.typeInfo())
+		}
+
+		return t
+
+	case gocql.TypeUDT:
+		var t = gocql.UDTTypeInfo{NativeType: n, KeySpace: r.Keyspace, Name: r.Name}
+
+		for _, f := range r.Fields {
+			t.Elements = append(t.Elements, gocql.UDTField{Name: f.Field, Type: f.typeInfo()})
+		}
+
+		return t
+	}
+
+	return n
+}
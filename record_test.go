@@ -0,0 +1,151 @@
+package gockle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+	"gopkg.in/inf.v0"
+)
+
+func TestRecordedValue(t *testing.T) {
+	for _, v := range []interface{}{
+		nil,
+		1,
+		int64(-5),
+		float32(1.5),
+		true,
+		"x",
+		[]byte{1, 2},
+		time.Date(2020, 1, 2, 3, 4, 5, 6, time.UTC),
+		5 * time.Second,
+		gocql.TimeUUID(),
+		gocql.Duration{Months: 1, Days: 2, Nanoseconds: 3},
+		big.NewInt(-7),
+		inf.NewDec(314, 2),
+		net.IPv4(127, 0, 0, 1),
+		[]int{1, 2},
+		map[string][]string{"a": {"b"}},
+		map[gocql.UUID]int{{1}: 1},
+		map[string]interface{}{"a": 1, "b": map[string]interface{}{"c": "d"}},
+		[]interface{}{int16(1), "a"},
+	} {
+		var b, err = json.Marshal(encodeValue(v))
+
+		if err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		var r recordedValue
+
+		if err := json.Unmarshal(b, &r); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		if a, err := r.value(); err != nil {
+			t.Errorf("Actual error %v, expected no error for %v", err, v)
+		} else if !reflect.DeepEqual(a, v) {
+			t.Errorf("Actual value %#v, expected %#v", a, v)
+		}
+	}
+
+	type unknown struct{ A int }
+
+	if _, err := encodeValue(unknown{1}).value(); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	var s string
+	var i interface{}
+
+	if err := encodeValue("x").decode(&s); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if s != "x" {
+		t.Errorf("Actual string %v, expected x", s)
+	}
+
+	if err := encodeValue(int8(1)).decode(&i); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if i != int8(1) {
+		t.Errorf("Actual value %#v, expected 1", i)
+	}
+}
+
+func TestRecordedError(t *testing.T) {
+	for _, c := range []struct {
+		err    error
+		target error
+	}{
+		{ErrNotFound, ErrNotFound},
+		{context.Canceled, context.Canceled},
+		{context.DeadlineExceeded, context.DeadlineExceeded},
+		{errors.New("x"), nil},
+		{&Error{Err: errors.New("x")}, nil},
+		{&Error{Kind: ErrTimeout, Statement: "s", Coordinator: "c", Err: errors.New("x")}, ErrTimeout},
+		{notApplied("s", ""), ErrNotApplied},
+		{tableNotFound("k", "t"), ErrTableNotFound},
+	} {
+		var err = encodeError(c.err).error()
+
+		if a, e := err.Error(), c.err.Error(); a != e {
+			t.Errorf("Actual message %q, expected %q", a, e)
+		}
+
+		if a, e := errorType(err), errorType(c.err); a != e {
+			t.Errorf("Actual error type %v, expected %v", a, e)
+		}
+
+		if c.target != nil && !errors.Is(err, c.target) {
+			t.Errorf("Actual error %v, expected %v", err, c.target)
+		}
+
+		if a, e := errorCoordinator(err), errorCoordinator(c.err); a != e {
+			t.Errorf("Actual coordinator %v, expected %v", a, e)
+		}
+	}
+
+	if encodeError(nil).error() != nil {
+		t.Error("Actual error, expected no error")
+	}
+}
+
+func TestRecordedMetadata(t *testing.T) {
+	var s = newMemorySession(t, "create table gockle_test.m (id int, c int, m map<text, frozen<list<int>>>, primary key (id, c)) with clustering order by (c desc)")
+
+	defer s.Close()
+
+	var k, err = s.Keyspace("gockle_test")
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var text = gocql.NewNativeType(4, gocql.TypeVarchar, "")
+
+	k.Types = []UserTypeInfo{{Name: "u", FieldNames: []string{"a"}, FieldTypes: []gocql.TypeInfo{text}}}
+	k.Tables[0].Columns = append(k.Tables[0].Columns,
+		ColumnInfo{Name: "t", Type: gocql.TupleTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeTuple, ""), Elems: []gocql.TypeInfo{text, text}}, Position: -1},
+		ColumnInfo{Name: "u", Type: gocql.UDTTypeInfo{NativeType: gocql.NewNativeType(4, gocql.TypeUDT, ""), KeySpace: "gockle_test", Name: "u", Elements: []gocql.UDTField{{Name: "a", Type: text}}}, Position: -1})
+
+	b, err := json.Marshal(encodeMetadata(reflect.ValueOf(k)))
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var a KeyspaceInfo
+
+	if err := decodeMetadata(b, reflect.ValueOf(&a).Elem()); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if !reflect.DeepEqual(a, k) {
+		t.Errorf("Actual keyspace %#v, expected %#v", a, k)
+	}
+}
@@ -0,0 +1,324 @@
+package gockle
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"iter"
+	"os"
+	"sync"
+)
+
+// ErrReplay is for a call to a ReplaySession that does not match the next
+// recorded call.
+var ErrReplay = errors.New("gockle: replay mismatch")
+
+// ReplaySession is a Session that serves the calls recorded by a
+// RecordingSession in a golden file. Each call must match the next recorded
+// call in kind, statement, arguments, batch statements, page size, and page
+// state, and each call to an Iterator the next recorded call to it; otherwise
+// it fails with ErrReplay. The methods without Middleware, like Token, are
+// those of a new memory Session. Its methods are safe for concurrent use, but
+// concurrent calls must start in the order they were recorded.
+type ReplaySession struct {
+	Session
+
+	mu    sync.Mutex
+	calls []*recordedCall
+	next  int
+	err   error
+}
+
+// NewReplaySession returns a new ReplaySession for the golden file path.
+func NewReplaySession(path string) (*ReplaySession, error) {
+	var b, err = os.ReadFile(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var r = &ReplaySession{}
+
+	if err := json.Unmarshal(b, &r.calls); err != nil {
+		return nil, fmt.Errorf("gockle: golden file %v: %w", path, err)
+	}
+
+	r.Session = Chain(NewMemorySession(), r.replay)
+
+	return r, nil
+}
+
+func (r *ReplaySession) asyncLimiter() limiter {
+	return limiterOf(r.Session)
+}
+
+// Err returns the first mismatch, or an error if recorded calls were not made.
+func (r *ReplaySession) Err() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.err != nil {
+		return r.err
+	}
+
+	if n := len(r.calls) - r.next; n > 0 {
+		return fmt.Errorf("%w: %v recorded calls not made, the first %v", ErrReplay, n, r.calls[r.next].recordedRequest)
+	}
+
+	return nil
+}
+
+// mismatch returns an ErrReplay for format and arguments, and remembers the
+// first. r.mu is locked.
+func (r *ReplaySession) mismatch(format string, arguments ...interface{}) error {
+	var err = fmt.Errorf("%w: "+format, append([]interface{}{ErrReplay}, arguments...)...)
+
+	if r.err == nil {
+		r.err = err
+	}
+
+	return err
+}
+
+func (r *ReplaySession) replay(next Handler) Handler {
+	return func(ctx context.Context, o *Operation) error {
+		if i, ok := o.Iterator.(*replayIterator); ok && o.Kind == OperationIterClose {
+			return i.Close()
+		}
+
+		var c, err = r.take(o)
+
+		if err != nil {
+			return err
+		}
+
+		return c.replay(o, r)
+	}
+}
+
+// take returns the next recorded call if it matches o.
+func (r *ReplaySession) take(o *Operation) (*recordedCall, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var a = newRecordedRequest(o)
+
+	if r.next == len(r.calls) {
+		return nil, r.mismatch("call %v: unexpected %v", r.next+1, a)
+	}
+
+	var c = r.calls[r.next]
+
+	if e := c.recordedRequest; e.String() != a.String() {
+		return nil, r.mismatch("call %v: expected %v, actual %v", r.next+1, e, a)
+	}
+
+	r.next++
+
+	return c, nil
+}
+
+// replay sets the results of o to those of c and returns the error of c.
+func (c *recordedCall) replay(o *Operation, r *ReplaySession) error {
+	var err error
+
+	switch o.Kind {
+	case OperationScan:
+		for i, v := range c.Results {
+			if i < len(o.Results) && err == nil {
+				err = v.decode(o.Results[i])
+			}
+		}
+
+	case OperationScanMap, OperationScanMapTx:
+		err = replayMap(c.Map, o.Map)
+
+	case OperationScanStruct, OperationScanStructSlice:
+		if len(c.Struct) > 0 && c.Error == nil {
+			err = json.Unmarshal(c.Struct, o.Struct)
+		}
+
+	case OperationScanMapSlice, OperationBatchExecTx:
+		o.Rows, err = decodeRows(c.Rows)
+
+	case OperationIter:
+		o.Iterator = &replayIterator{r: r, c: c}
+	}
+
+	if v, ok := operationMetadata(o); ok && err == nil {
+		err = decodeMetadata(c.Metadata, v)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	o.Applied = c.Applied
+
+	return c.Error.error()
+}
+
+// replayMap puts the values of rm in m.
+func replayMap(rm map[string]recordedValue, m map[string]interface{}) error {
+	var vs, err = decodeMap(rm)
+
+	if err != nil {
+		return err
+	}
+
+	for k, v := range vs {
+		m[k] = v
+	}
+
+	return nil
+}
+
+// replayIterator serves the recorded calls to the Iterator of c.
+type replayIterator struct {
+	r *ReplaySession
+	c *recordedCall
+
+	next int
+	err  error
+}
+
+// event returns the next recorded call if it is to method, and whether it is.
+func (i *replayIterator) event(method string) (recordedEvent, bool) {
+	i.r.mu.Lock()
+	defer i.r.mu.Unlock()
+
+	var e recordedEvent
+
+	if i.next < len(i.c.Iterator) {
+		e = i.c.Iterator[i.next]
+	}
+
+	if e.Method != method {
+		var err error
+
+		if e.Method == "" {
+			err = i.r.mismatch("Iterator of %v: unexpected %v", i.c.recordedRequest, method)
+		} else {
+			err = i.r.mismatch("Iterator of %v: expected %v, actual %v", i.c.recordedRequest, e.Method, method)
+		}
+
+		if i.err == nil {
+			i.err = err
+		}
+
+		return e, false
+	}
+
+	i.next++
+
+	return e, true
+}
+
+// fail remembers err and returns false.
+func (i *replayIterator) fail(err error) bool {
+	if err != nil && i.err == nil {
+		i.err = err
+	}
+
+	return false
+}
+
+func (i *replayIterator) All() iter.Seq2[map[string]interface{}, error] {
+	return all(i)
+}
+
+// Close returns the first mismatch of the Iterator, or the recorded error.
+func (i *replayIterator) Close() error {
+	var e, ok = i.event("Close")
+
+	if i.err != nil {
+		return i.err
+	}
+
+	if !ok {
+		return nil
+	}
+
+	return e.Error.error()
+}
+
+func (i *replayIterator) Scan(results ...interface{}) bool {
+	var e, ok = i.event("Scan")
+
+	if !ok {
+		return false
+	}
+
+	for n, v := range e.Values {
+		if n < len(results) {
+			if err := v.decode(results[n]); err != nil {
+				return i.fail(err)
+			}
+		}
+	}
+
+	return e.More
+}
+
+func (i *replayIterator) ScanMap(results map[string]interface{}) bool {
+	var e, ok = i.event("ScanMap")
+
+	if !ok {
+		return false
+	}
+
+	if err := replayMap(e.Map, results); err != nil {
+		return i.fail(err)
+	}
+
+	return e.More
+}
+
+func (i *replayIterator) StructScan(dest interface{}) bool {
+	var e, ok = i.event("StructScan")
+
+	if !ok {
+		return false
+	}
+
+	if len(e.Struct) > 0 {
+		if err := json.Unmarshal(e.Struct, dest); err != nil {
+			return i.fail(err)
+		}
+	}
+
+	return e.More
+}
+
+func (i *replayIterator) WillSwitchPage() bool {
+	var e, ok = i.event("WillSwitchPage")
+
+	return ok && e.More
+}
+
+func (i *replayIterator) PageState() []byte {
+	var e, ok = i.event("PageState")
+
+	if !ok {
+		return nil
+	}
+
+	return e.PageState
+}
+
+func (i *replayIterator) SliceMap() ([]map[string]interface{}, error) {
+	var e, ok = i.event("SliceMap")
+
+	if !ok {
+		return nil, i.err
+	}
+
+	var ms, err = decodeRows(e.Rows)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return ms, e.Error.error()
+}
@@ -0,0 +1,186 @@
+package gockle
+
+import (
+	"errors"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// replayed makes calls through s and returns their results.
+func replayed(s Session) []interface{} {
+	type row struct {
+		ID int `cql:"id"`
+		N  int
+	}
+
+	var rs []interface{}
+	var add = func(vs ...interface{}) {
+		for _, v := range vs {
+			if err, ok := v.(error); ok {
+				v = errorType(err) + ": " + err.Error()
+			}
+
+			rs = append(rs, v)
+		}
+	}
+
+	var insert = "insert into gockle_test.test (id, n) values (?, ?)"
+
+	add(s.Exec(insert, 1, 2))
+	add(s.Exec(insert, 2, 3))
+	add(s.Exec("insert into gockle_test.missing (id) values (1)"))
+
+	var n int
+
+	add(s.Scan("select n from gockle_test.test where id = ?", []interface{}{&n}, 1), n)
+	add(s.Scan("select n from gockle_test.test where id = ?", []interface{}{&n}, 9))
+
+	var m = map[string]interface{}{}
+
+	add(s.ScanMap("select * from gockle_test.test where id = ?", m, 2), m)
+	add(s.ScanMapSlice("select * from gockle_test.test"))
+
+	m = map[string]interface{}{}
+
+	var applied, err = s.ScanMapTx(insert+" if not exists", m, 1, 5)
+
+	add(applied, err, m)
+
+	var r row
+
+	add(s.ScanStruct("select * from gockle_test.test where id = ?", &r, 1), r)
+
+	var rows []row
+
+	add(s.ScanStructSlice("select * from gockle_test.test", &rows), rows)
+
+	var i = s.Query("select * from gockle_test.test").PageSize(1).Iter()
+
+	add(i.Scan(&r.ID, &r.N), r, i.WillSwitchPage())
+
+	var state = i.PageState()
+
+	add(state, i.Close())
+
+	i = s.Query("select * from gockle_test.test").PageSize(1).PageState(state).Iter()
+
+	add(i.SliceMap())
+	add(i.Close())
+
+	for m, err := range s.ScanIterator("select * from gockle_test.test").All() {
+		add(m, err)
+	}
+
+	var b = s.Batch(BatchLogged)
+
+	b.Add(insert, 3, 4)
+	b.Add("update gockle_test.test set n = ? where id = ?", 5, 1)
+
+	add(b.Exec())
+	add(s.Tables("gockle_test"))
+	add(s.Columns("gockle_test", "test"))
+	add(s.Table("gockle_test", "test"))
+
+	return rs
+}
+
+func TestReplay(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "golden.json")
+	var r = NewRecordingSession(newMemorySession(t), path)
+	var expected = replayed(r)
+
+	r.Close()
+
+	var s, err = NewReplaySession(path)
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	defer s.Close()
+
+	if a := replayed(s); !reflect.DeepEqual(a, expected) {
+		t.Errorf("Actual results %v, expected %v", a, expected)
+	}
+
+	if err := s.Err(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+}
+
+func TestReplayMismatch(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "golden.json")
+	var r = NewRecordingSession(newMemorySession(t, rowInsert), path)
+	var i = r.ScanIterator("select * from gockle_test.test")
+
+	i.ScanMap(map[string]interface{}{})
+	i.Close()
+	r.Exec("delete from gockle_test.test where id = ?", 1)
+	r.Exec("delete from gockle_test.test where id = ?", 2)
+
+	if err := r.Save(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var s, err = NewReplaySession(path)
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	i = s.ScanIterator("select * from gockle_test.test")
+
+	if i.Scan() {
+		t.Error("Actual more, expected no more")
+	}
+
+	if err := i.Close(); !errors.Is(err, ErrReplay) {
+		t.Errorf("Actual error %v, expected %v", err, ErrReplay)
+	}
+
+	if err := s.Exec("delete from gockle_test.test where id = ?", 2); !errors.Is(err, ErrReplay) {
+		t.Errorf("Actual error %v, expected %v", err, ErrReplay)
+	}
+
+	if err := s.Exec("delete from gockle_test.test where id = ?", 1); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Err(); !errors.Is(err, ErrReplay) {
+		t.Errorf("Actual error %v, expected %v", err, ErrReplay)
+	}
+
+	s, _ = NewReplaySession(path)
+
+	s.ScanIterator("select * from gockle_test.test").SliceMap()
+
+	if err := s.Err(); !errors.Is(err, ErrReplay) {
+		t.Errorf("Actual error %v, expected %v", err, ErrReplay)
+	}
+
+	s, _ = NewReplaySession(path)
+
+	i = s.ScanIterator("select * from gockle_test.test")
+	i.ScanMap(map[string]interface{}{})
+	i.Close()
+
+	if err := s.Err(); err == nil {
+		t.Error("Actual no error, expected error for calls not made")
+	}
+
+	s.Exec("delete from gockle_test.test where id = ?", 1)
+	s.Exec("delete from gockle_test.test where id = ?", 2)
+
+	if err := s.Err(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Exec("truncate gockle_test.test"); !errors.Is(err, ErrReplay) {
+		t.Errorf("Actual error %v, expected %v", err, ErrReplay)
+	}
+
+	if _, err := NewReplaySession(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
@@ -0,0 +1,335 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// ErrorClass is the class of an error for retry decisions.
+type ErrorClass int
+
+// Error classes.
+const (
+	// ErrorClassOther is any error not in another class. It is not retried.
+	ErrorClassOther ErrorClass = iota
+
+	// ErrorClassReadTimeout is a read timeout reported by the coordinator.
+	ErrorClassReadTimeout
+
+	// ErrorClassWriteTimeout is a write timeout reported by the coordinator.
+	// The write may have been applied.
+	ErrorClassWriteTimeout
+
+	// ErrorClassUnavailable is the coordinator reporting too few live replicas.
+	// The statement was not applied.
+	ErrorClassUnavailable
+
+	// ErrorClassOverloaded is the coordinator rejecting the statement as
+	// overloaded or bootstrapping. The statement was not applied.
+	ErrorClassOverloaded
+
+	// ErrorClassClientTimeout is the driver giving up on a response. The
+	// statement may have been applied.
+	ErrorClassClientTimeout
+)
+
+// ClassifyError returns the class of err.
+func ClassifyError(err error) ErrorClass {
+	var read *gocql.RequestErrReadTimeout
+	var write *gocql.RequestErrWriteTimeout
+	var unavailable *gocql.RequestErrUnavailable
+	var request gocql.RequestError
+
+	switch {
+	case errors.As(err, &read):
+		return ErrorClassReadTimeout
+
+	case errors.As(err, &write):
+		return ErrorClassWriteTimeout
+
+	case errors.As(err, &unavailable):
+		return ErrorClassUnavailable
+
+	case errors.As(err, &request):
+		switch request.Code() {
+		case gocql.ErrCodeReadTimeout:
+			return ErrorClassReadTimeout
+
+		case gocql.ErrCodeWriteTimeout:
+			return ErrorClassWriteTimeout
+
+		case gocql.ErrCodeUnavailable:
+			return ErrorClassUnavailable
+
+		case gocql.ErrCodeOverloaded, gocql.ErrCodeBootstrapping:
+			return ErrorClassOverloaded
+		}
+
+	case errors.Is(err, gocql.ErrTimeoutNoResponse):
+		return ErrorClassClientTimeout
+	}
+
+	return ErrorClassOther
+}
+
+// RetryPolicy decides whether to retry an Operation.
+type RetryPolicy interface {
+	// Retry returns whether to try o again after attempts failed attempts,
+	// the last with err, elapsed after the first started, and how long to wait
+	// first.
+	Retry(o *Operation, err error, attempts int, elapsed time.Duration) (bool, time.Duration)
+}
+
+// Retry returns Middleware that tries Operations again as policy decides,
+// until they succeed, policy gives up, or the context is done. It returns the
+// last error. Iterators are not retried, because their errors come after
+// their rows.
+func Retry(policy RetryPolicy) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			if o.Kind == OperationIter || o.Kind == OperationIterClose {
+				return next(ctx, o)
+			}
+
+			var start = time.Now()
+
+			for attempts := 1; ; attempts++ {
+				var err = next(ctx, o)
+
+				if err == nil {
+					return nil
+				}
+
+				var retry, delay = policy.Retry(o, err, attempts, time.Since(start))
+
+				if !retry {
+					return err
+				}
+
+				var t = time.NewTimer(delay)
+
+				select {
+				case <-t.C:
+
+				case <-ctx.Done():
+					t.Stop()
+
+					return err
+				}
+			}
+		}
+	}
+}
+
+// Backoff returns the delay before retry attempt, starting at 1.
+type Backoff func(attempt int) time.Duration
+
+// FixedBackoff returns a Backoff of d for every attempt.
+func FixedBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration {
+		return d
+	}
+}
+
+// ExponentialBackoff returns a Backoff of base for the first attempt, doubled
+// for each after up to max. Jitter, from 0 to 1, is the fraction of each delay
+// that is random, so that clients retrying together spread out.
+func ExponentialBackoff(base, max time.Duration, jitter float64) Backoff {
+	return func(attempt int) time.Duration {
+		var d = base
+
+		for i := 1; i < attempt && d < max; i++ {
+			d *= 2
+		}
+
+		if d > max {
+			d = max
+		}
+
+		return d - time.Duration(jitter*rand.Float64()*float64(d))
+	}
+}
+
+// RetryDecision is what a ClassPolicy does for an error class.
+type RetryDecision int
+
+// Retry decisions.
+const (
+	// RetryNever does not retry.
+	RetryNever RetryDecision = iota
+
+	// RetryIdempotent retries idempotent Operations: SELECT statements and
+	// queries marked with Query.Idempotent. Counter batches and updates, like
+	// c = c + 1, are never idempotent.
+	RetryIdempotent
+
+	// RetryAlways retries any Operation, except counter batches and updates
+	// after timeouts. Use it for errors that mean the statement was not applied.
+	RetryAlways
+)
+
+// ClassPolicy is a RetryPolicy with a decision for each error class. The zero
+// value never retries.
+type ClassPolicy struct {
+	// MaxAttempts is the most attempts, including the first. With 0 or 1 no
+	// Operation is retried.
+	MaxAttempts int
+
+	// MaxElapsed, if not zero, is the longest time after the first attempt
+	// starts that another may start.
+	MaxElapsed time.Duration
+
+	// Backoff is the delay before each retry. If nil, there is none.
+	Backoff Backoff
+
+	ReadTimeout   RetryDecision
+	Unavailable   RetryDecision
+	Overloaded    RetryDecision
+	ClientTimeout RetryDecision
+
+	// WriteTimeout is the decision for each write type of a write timeout,
+	// like SIMPLE, BATCH, UNLOGGED_BATCH, BATCH_LOG, COUNTER, or CAS. Other
+	// write types are not retried, and neither is COUNTER.
+	WriteTimeout map[string]RetryDecision
+}
+
+var _ RetryPolicy = &ClassPolicy{}
+
+// DefaultRetryPolicy returns a ClassPolicy of three attempts at most, with
+// exponential backoff from 100ms to 2s. It retries errors that mean the
+// statement was not applied, and timeouts of idempotent Operations. A write
+// timeout after the batch log is written is retried too, because the batch is
+// then applied in full or not at all.
+func DefaultRetryPolicy() *ClassPolicy {
+	return &ClassPolicy{
+		MaxAttempts:   3,
+		Backoff:       ExponentialBackoff(100*time.Millisecond, 2*time.Second, 0.5),
+		ReadTimeout:   RetryIdempotent,
+		Unavailable:   RetryAlways,
+		Overloaded:    RetryAlways,
+		ClientTimeout: RetryIdempotent,
+		WriteTimeout: map[string]RetryDecision{
+			"SIMPLE":         RetryIdempotent,
+			"BATCH":          RetryIdempotent,
+			"UNLOGGED_BATCH": RetryIdempotent,
+			"BATCH_LOG":      RetryAlways,
+		},
+	}
+}
+
+// Retry decides by the class of err.
+func (p *ClassPolicy) Retry(o *Operation, err error, attempts int, elapsed time.Duration) (bool, time.Duration) {
+	if attempts >= p.MaxAttempts {
+		return false, 0
+	}
+
+	var d RetryDecision
+	var class = ClassifyError(err)
+
+	switch class {
+	case ErrorClassReadTimeout:
+		d = p.ReadTimeout
+
+	case ErrorClassWriteTimeout:
+		var w *gocql.RequestErrWriteTimeout
+
+		if errors.As(err, &w) && w.WriteType != "COUNTER" {
+			d = p.WriteTimeout[w.WriteType]
+		}
+
+	case ErrorClassUnavailable:
+		d = p.Unavailable
+
+	case ErrorClassOverloaded:
+		d = p.Overloaded
+
+	case ErrorClassClientTimeout:
+		d = p.ClientTimeout
+	}
+
+	var timeout = class == ErrorClassReadTimeout || class == ErrorClassWriteTimeout || class == ErrorClassClientTimeout
+
+	if d == RetryNever || d == RetryIdempotent && !idempotent(o) || timeout && counter(o) {
+		return false, 0
+	}
+
+	var delay time.Duration
+
+	if p.Backoff != nil {
+		delay = p.Backoff(attempts)
+	}
+
+	if p.MaxElapsed > 0 && elapsed+delay > p.MaxElapsed {
+		return false, 0
+	}
+
+	return true, delay
+}
+
+// counter returns whether o is a counter batch, or has a statement that adds to
+// or subtracts from a column.
+func counter(o *Operation) bool {
+	if (o.Kind == OperationBatchExec || o.Kind == OperationBatchExecTx) && o.BatchKind == BatchCounter {
+		return true
+	}
+
+	var c = increments(o.Statement)
+
+	for _, e := range o.Batch {
+		c = c || increments(e.Statement)
+	}
+
+	return c
+}
+
+// increments returns whether statement is an UPDATE that sets a column to
+// itself plus or minus a value, like c = c + 1 for a counter c.
+func increments(statement string) bool {
+	var ts, err = cql.Lex(statement)
+
+	if err != nil || !ts[0].Is("update") {
+		return false
+	}
+
+	var set bool
+
+	for _, i := range cql.TopLevel(ts) {
+		var t = ts[i]
+
+		switch {
+		case t.Is("set"):
+			set = true
+
+		case t.Is("where"):
+			return false
+
+		case set && t.Is("=") && i > 0 && i+2 < len(ts):
+			var c, v = ts[i-1], ts[i+1]
+
+			if (c.Kind == cql.Ident || c.Kind == cql.QuotedIdent) && v.Kind == c.Kind && v.Text == c.Text && (ts[i+2].Is("+") || ts[i+2].Is("-")) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// idempotent returns whether o can run again with the same outcome.
+func idempotent(o *Operation) bool {
+	switch o.Kind {
+	case OperationColumns, OperationTables, OperationKeyspace, OperationTable:
+		return true
+
+	case OperationBatchExec, OperationBatchExecTx:
+		return o.Idempotent && !counter(o)
+	}
+
+	return o.Idempotent || strings.HasPrefix(strings.ToLower(strings.TrimSpace(o.Statement)), "select")
+}
@@ -0,0 +1,221 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// requestError is a gocql.RequestError with a code.
+type requestError int
+
+func (e requestError) Code() int {
+	return int(e)
+}
+
+func (e requestError) Error() string {
+	return e.Message()
+}
+
+func (e requestError) Message() string {
+	return fmt.Sprintf("code %#x", int(e))
+}
+
+func TestClassifyError(t *testing.T) {
+	for _, c := range []struct {
+		err   error
+		class ErrorClass
+	}{
+		{errors.New("other"), ErrorClassOther},
+		{&gocql.RequestErrReadTimeout{}, ErrorClassReadTimeout},
+		{fmt.Errorf("wrapped: %w", &gocql.RequestErrWriteTimeout{WriteType: "SIMPLE"}), ErrorClassWriteTimeout},
+		{&gocql.RequestErrUnavailable{}, ErrorClassUnavailable},
+		{requestError(gocql.ErrCodeOverloaded), ErrorClassOverloaded},
+		{requestError(gocql.ErrCodeBootstrapping), ErrorClassOverloaded},
+		{requestError(gocql.ErrCodeSyntax), ErrorClassOther},
+		{gocql.ErrTimeoutNoResponse, ErrorClassClientTimeout},
+	} {
+		if a, e := ClassifyError(c.err), c.class; a != e {
+			t.Errorf("Actual class %v for %v, expected %v", a, c.err, e)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	if a, e := FixedBackoff(time.Second)(5), time.Second; a != e {
+		t.Errorf("Actual delay %v, expected %v", a, e)
+	}
+
+	var b = ExponentialBackoff(time.Second, 5*time.Second, 0)
+	var a []time.Duration
+
+	for i := 1; i <= 5; i++ {
+		a = append(a, b(i))
+	}
+
+	if e := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual delays %v, expected %v", a, e)
+	}
+
+	b = ExponentialBackoff(time.Second, 5*time.Second, 0.5)
+
+	for i := 0; i < 100; i++ {
+		if d := b(2); d < time.Second || d > 2*time.Second {
+			t.Fatalf("Actual delay %v, expected 1s to 2s", d)
+		}
+	}
+}
+
+func TestRetry(t *testing.T) {
+	var attempts int
+	var errs []error
+
+	var fail = func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			attempts++
+
+			if len(errs) > 0 {
+				var err = errs[0]
+
+				errs = errs[1:]
+
+				return err
+			}
+
+			return next(ctx, o)
+		}
+	}
+
+	var idempotent = func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			o.Idempotent = true
+
+			return next(ctx, o)
+		}
+	}
+
+	var p = &ClassPolicy{
+		MaxAttempts:  3,
+		Unavailable:  RetryAlways,
+		ReadTimeout:  RetryIdempotent,
+		WriteTimeout: map[string]RetryDecision{"SIMPLE": RetryIdempotent, "BATCH": RetryAlways},
+	}
+
+	var s = Chain(newMemorySession(t, "create table gockle_test.counts (id int primary key, c counter)"), idempotent, Retry(p), fail)
+	var unavailable = &gocql.RequestErrUnavailable{}
+	var simple = &gocql.RequestErrWriteTimeout{WriteType: "SIMPLE"}
+	var counter = &gocql.RequestErrWriteTimeout{WriteType: "COUNTER"}
+	var batch = &gocql.RequestErrWriteTimeout{WriteType: "BATCH"}
+
+	var counterBatch = func() error {
+		var b = s.Batch(BatchCounter)
+
+		b.Add("update gockle_test.counts set c = c + 1 where id = 1")
+
+		return b.Exec()
+	}
+
+	var counterUpdate = func() error {
+		return s.Query("update gockle_test.counts set c = c + 1 where id = 1").Idempotent(true).Exec()
+	}
+
+	for _, c := range []struct {
+		name     string
+		run      func() error
+		errs     []error
+		attempts int
+		err      error
+	}{
+		{"unavailable", func() error { return s.Exec(rowInsert) }, []error{unavailable, unavailable}, 3, nil},
+		{"max attempts", func() error { return s.Exec(rowInsert) }, []error{unavailable, unavailable, unavailable}, 3, unavailable},
+		{"read timeout", func() error { return s.Scan("select * from gockle_test.test", []interface{}{new(int), new(int)}) }, []error{&gocql.RequestErrReadTimeout{}}, 2, nil},
+		{"other", func() error { return s.Exec(rowInsert) }, []error{gocql.ErrNotFound}, 1, gocql.ErrNotFound},
+		{"idempotent query", func() error { return s.Query(rowInsert).Idempotent(true).Exec() }, []error{simple}, 2, nil},
+		{"counter write type", func() error { return s.Query(rowInsert).Idempotent(true).Exec() }, []error{counter}, 1, counter},
+		{"counter batch timeout", counterBatch, []error{batch}, 1, batch},
+		{"counter batch unavailable", counterBatch, []error{unavailable}, 2, nil},
+		{"counter update timeout", counterUpdate, []error{simple}, 1, simple},
+		{"counter update unavailable", counterUpdate, []error{unavailable}, 2, nil},
+	} {
+		attempts, errs = 0, c.errs
+
+		if err := c.run(); err != c.err {
+			t.Errorf("Actual error %v for %v, expected %v", err, c.name, c.err)
+		}
+
+		if attempts != c.attempts {
+			t.Errorf("Actual attempts %v for %v, expected %v", attempts, c.name, c.attempts)
+		}
+	}
+
+	s = Chain(newMemorySession(t), Retry(&ClassPolicy{}), fail)
+
+	attempts, errs = 0, []error{unavailable}
+
+	if err := s.Exec(rowInsert); err != unavailable {
+		t.Errorf("Actual error %v, expected %v", err, unavailable)
+	} else if attempts != 1 {
+		t.Errorf("Actual attempts %v, expected 1", attempts)
+	}
+
+	s = Chain(newMemorySession(t), Retry(p), fail)
+
+	attempts, errs = 0, []error{simple}
+
+	if err := s.Exec(rowInsert); err != simple {
+		t.Errorf("Actual error %v, expected %v", err, simple)
+	} else if attempts != 1 {
+		t.Errorf("Actual attempts %v, expected 1", attempts)
+	}
+
+	p.Backoff, p.MaxElapsed = FixedBackoff(time.Hour), time.Minute
+	attempts, errs = 0, []error{unavailable}
+
+	if err := s.Exec(rowInsert); err != unavailable {
+		t.Errorf("Actual error %v, expected %v", err, unavailable)
+	} else if attempts != 1 {
+		t.Errorf("Actual attempts %v, expected 1", attempts)
+	}
+
+	p.MaxElapsed = 0
+
+	var ctx, cancel = context.WithTimeout(context.Background(), 10*time.Millisecond)
+
+	defer cancel()
+
+	attempts, errs = 0, []error{unavailable}
+
+	if err := s.ExecContext(ctx, rowInsert); err != unavailable {
+		t.Errorf("Actual error %v, expected %v", err, unavailable)
+	} else if attempts != 1 {
+		t.Errorf("Actual attempts %v, expected 1", attempts)
+	}
+}
+
+func TestDefaultRetryPolicy(t *testing.T) {
+	var p = DefaultRetryPolicy()
+	var o = &Operation{Kind: OperationExec, Statement: "insert into t (a) values (1)"}
+
+	if ok, _ := p.Retry(o, &gocql.RequestErrWriteTimeout{WriteType: "SIMPLE"}, 1, 0); ok {
+		t.Error("Actual retry of a write, expected none")
+	}
+
+	if ok, _ := p.Retry(o, &gocql.RequestErrWriteTimeout{WriteType: "BATCH_LOG"}, 1, 0); !ok {
+		t.Error("Actual no retry of a batch log write, expected retry")
+	}
+
+	if ok, d := p.Retry(&Operation{Kind: OperationScan, Statement: " SELECT * from t"}, gocql.ErrTimeoutNoResponse, 2, 0); !ok {
+		t.Error("Actual no retry of a read, expected retry")
+	} else if d < 100*time.Millisecond || d > 200*time.Millisecond {
+		t.Errorf("Actual delay %v, expected 100ms to 200ms", d)
+	}
+
+	if ok, _ := p.Retry(o, &gocql.RequestErrUnavailable{}, 3, 0); ok {
+		t.Error("Actual retry after three attempts, expected none")
+	}
+}
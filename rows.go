@@ -0,0 +1,162 @@
+package gockle
+
+import (
+	"iter"
+	"reflect"
+
+	"github.com/gocql/gocql"
+)
+
+// Rows iterates the result rows of an Iterator as values of type T. T may be
+// map[string]interface{}, which scans like Iterator.ScanMap; a struct or a
+// pointer to a struct, which scans like Iterator.StructScan; or any other type,
+// which scans the only column like Iterator.Scan.
+//
+// Rows closes the Iterator when Next returns false, so a loop over Next need
+// not call Close. Call Close to stop early.
+type Rows[T any] struct {
+	i Iterator
+
+	row    T
+	err    error
+	closed bool
+}
+
+// NewRows returns a new Rows for i.
+func NewRows[T any](i Iterator) *Rows[T] {
+	return &Rows[T]{i: i}
+}
+
+// Next scans the next row and returns whether there is one. It closes the
+// Iterator if there is not.
+func (r *Rows[T]) Next() bool {
+	if r.closed {
+		return false
+	}
+
+	var row T
+
+	if !scanRowOf(r.i, &row) {
+		r.Close()
+
+		return false
+	}
+
+	r.row = row
+
+	return true
+}
+
+// Row returns the row scanned by the last call to Next.
+func (r *Rows[T]) Row() T {
+	return r.row
+}
+
+// Err returns the error of the Iterator after it is closed.
+func (r *Rows[T]) Err() error {
+	return r.err
+}
+
+// All returns the rows like Next and Row, followed by the error of the Iterator
+// if there is one. If the loop stops early, it closes the Iterator, and Close
+// returns the error.
+func (r *Rows[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		for r.Next() {
+			if !yield(r.Row(), nil) {
+				r.Close()
+
+				return
+			}
+		}
+
+		if err := r.Err(); err != nil {
+			var zero T
+
+			yield(zero, err)
+		}
+	}
+}
+
+// Close closes the Iterator and returns its error. Later calls return the same
+// error.
+func (r *Rows[T]) Close() error {
+	if !r.closed {
+		r.closed = true
+		r.err = r.i.Close()
+	}
+
+	return r.err
+}
+
+// ScanAll returns all the rows of i as values of type T, like Rows, and closes
+// i.
+func ScanAll[T any](i Iterator) ([]T, error) {
+	var r = NewRows[T](i)
+	var rows []T
+
+	for r.Next() {
+		rows = append(rows, r.Row())
+	}
+
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+
+	return rows, nil
+}
+
+// ScanOne returns the first row of i as a value of type T, like Rows, and
+// closes i. If there are no rows, it returns gocql.ErrNotFound.
+func ScanOne[T any](i Iterator) (T, error) {
+	var r = NewRows[T](i)
+	var more = r.Next()
+	var err = r.Close()
+	var row T
+
+	if err != nil {
+		return row, err
+	}
+
+	if !more {
+		return row, gocql.ErrNotFound
+	}
+
+	return r.Row(), nil
+}
+
+// scanRowOf scans the current row of i into row as described by Rows.
+func scanRowOf[T any](i Iterator, row *T) bool {
+	switch dest := interface{}(row).(type) {
+	case *map[string]interface{}:
+		var m = map[string]interface{}{}
+
+		if !i.ScanMap(m) {
+			return false
+		}
+
+		*dest = m
+
+		return true
+	}
+
+	var v = reflect.ValueOf(row).Elem()
+
+	switch t := v.Type(); {
+	case t.Kind() == reflect.Struct:
+		return i.StructScan(row)
+
+	case t.Kind() == reflect.Ptr && t.Elem().Kind() == reflect.Struct:
+		var p = reflect.New(t.Elem())
+
+		if !i.StructScan(p.Interface()) {
+			return false
+		}
+
+		v.Set(p)
+
+		return true
+	}
+
+	return i.Scan(row)
+}
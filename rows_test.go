@@ -0,0 +1,104 @@
+package gockle
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRows(t *testing.T) {
+	var s = newMemorySession(t, rowInsert, rowInsert2)
+
+	defer s.Close()
+
+	if a, err := ScanAll[map[string]interface{}](s.ScanIterator("select * from gockle_test.test")); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := []map[string]interface{}{{"id": 1, "n": 2}, {"id": 3, "n": 4}}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	type row struct {
+		ID int
+		N  int
+	}
+
+	if a, err := ScanAll[*row](s.ScanIterator("select * from gockle_test.test")); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(a) != 2 || *a[1] != (row{ID: 3, N: 4}) {
+		t.Errorf("Actual rows %v, expected 2 rows", a)
+	}
+
+	if a, err := ScanOne[row](s.ScanIterator("select * from gockle_test.test where id = 3")); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := (row{ID: 3, N: 4}); a != e {
+		t.Errorf("Actual row %v, expected %v", a, e)
+	}
+
+	if _, err := ScanOne[int](s.ScanIterator("select n from gockle_test.test where id = 5")); err != gocql.ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, gocql.ErrNotFound)
+	}
+
+	var r = NewRows[int](s.ScanIterator("select n from gockle_test.test"))
+	var ns []int
+
+	for r.Next() {
+		ns = append(ns, r.Row())
+	}
+
+	if err := r.Err(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if e := []int{2, 4}; !reflect.DeepEqual(ns, e) {
+		t.Errorf("Actual rows %v, expected %v", ns, e)
+	}
+
+	if r.Next() {
+		t.Error("Actual more true, expected false")
+	}
+}
+
+func TestRowsMock(t *testing.T) {
+	var i = &IteratorMock{}
+	var closed = fmt.Errorf("closed")
+
+	i.On("StructScan", mock.Anything).Run(func(args mock.Arguments) {
+		args.Get(0).(*struct{ ID int }).ID = 1
+	}).Return(true).Once()
+	i.On("StructScan", mock.Anything).Return(false).Once()
+	i.On("Close").Return(closed).Once()
+
+	if _, err := ScanAll[struct{ ID int }](i); err != closed {
+		t.Errorf("Actual error %v, expected %v", err, closed)
+	}
+
+	i.AssertExpectations(t)
+
+	i = &IteratorMock{}
+
+	i.On("Scan", mock.Anything).Run(func(args mock.Arguments) {
+		*args.Get(0).(*string) = "a"
+	}).Return(true).Once()
+	i.On("Close").Return(nil).Once()
+
+	var r = NewRows[string](i)
+
+	if !r.Next() {
+		t.Error("Actual more false, expected true")
+	} else if a := r.Row(); a != "a" {
+		t.Errorf("Actual row %v, expected a", a)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := r.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	i.AssertExpectations(t)
+}
@@ -0,0 +1,377 @@
+package gockle
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/bits"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Defaults of Scanner.
+const (
+	// DefaultScannerRanges is the default for Scanner.Ranges.
+	DefaultScannerRanges = 256
+
+	// DefaultScannerConcurrency is the default for Scanner.Concurrency.
+	DefaultScannerConcurrency = 4
+)
+
+// TokenRange is the range of Murmur3 tokens greater than Start and at most End.
+type TokenRange struct {
+	Start int64
+	End   int64
+}
+
+// SplitRing returns the Murmur3 ring split into n ranges of about the same
+// size, in order. Less than 1 is 1.
+func SplitRing(n int) []TokenRange {
+	if n < 1 {
+		n = 1
+	}
+
+	var rs = make([]TokenRange, n)
+	var start int64 = math.MinInt64
+
+	for i := range rs {
+		// The ring has 2^64 - 1 tokens, because math.MinInt64 is not one, so
+		// range i ends (i + 1) * (2^64 - 1) / n tokens after it.
+		var hi, lo = bits.Mul64(math.MaxUint64, uint64(i+1))
+		var q, _ = bits.Div64(hi, lo, uint64(n))
+		var end = int64(uint64(1)<<63 + q)
+
+		rs[i] = TokenRange{Start: start, End: end}
+		start = end
+	}
+
+	return rs
+}
+
+// Checkpoint records the token ranges that a Scanner completed, so that a
+// scan can resume where it stopped.
+type Checkpoint interface {
+	// Completed returns the completed ranges.
+	Completed(ctx context.Context) ([]TokenRange, error)
+
+	// Complete records that r is completed. A Scanner calls it from one
+	// goroutine at a time.
+	Complete(ctx context.Context, r TokenRange) error
+}
+
+// FileCheckpoint returns a Checkpoint that appends the completed ranges to the
+// file at path, one per line, and syncs it after each. The file is created
+// when the first range completes. Remove it to scan from the start.
+func FileCheckpoint(path string) Checkpoint {
+	return fileCheckpoint(path)
+}
+
+type fileCheckpoint string
+
+func (c fileCheckpoint) Completed(ctx context.Context) ([]TokenRange, error) {
+	var f, err = os.Open(string(c))
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var rs []TokenRange
+	var s = bufio.NewScanner(f)
+
+	for s.Scan() {
+		var r TokenRange
+
+		if _, err := fmt.Sscan(s.Text(), &r.Start, &r.End); err != nil {
+			return nil, fmt.Errorf("gockle: invalid checkpoint line %q: %v", s.Text(), err)
+		}
+
+		rs = append(rs, r)
+	}
+
+	return rs, s.Err()
+}
+
+func (c fileCheckpoint) Complete(ctx context.Context, r TokenRange) error {
+	var f, err = os.OpenFile(string(c), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err := fmt.Fprintln(f, r.Start, r.End); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+
+		return err
+	}
+
+	return f.Close()
+}
+
+// ScanProgress is the progress of a scan.
+type ScanProgress struct {
+	// Ranges is the number of token ranges.
+	Ranges int
+
+	// Completed is the number of completed ranges, including those completed
+	// before the scan resumed.
+	Completed int
+
+	// Rows is the number of rows delivered by the scan.
+	Rows int64
+}
+
+// Scanner scans a whole table by Murmur3 token ranges, concurrently. The
+// cluster must use the Murmur3 partitioner.
+type Scanner struct {
+	// Columns are the columns to select. If empty, all are.
+	Columns []string
+
+	// Ranges is the number of token ranges that the ring is split into. Do not
+	// change it between a scan and its resumption.
+	Ranges int
+
+	// Concurrency is the most ranges scanned at once. Less than 1 is 1.
+	Concurrency int
+
+	// PageSize is the page size of the queries, if not 0.
+	PageSize int
+
+	// Checkpoint, if not nil, records the completed ranges. The ranges it
+	// already has are skipped.
+	Checkpoint Checkpoint
+
+	// Progress, if not nil, is called after each range completes, from one
+	// goroutine at a time.
+	Progress func(p ScanProgress)
+
+	s        Session
+	keyspace string
+	table    string
+}
+
+// NewScanner returns a new Scanner for keyspace and table through s with the
+// default settings.
+func NewScanner(s Session, keyspace, table string) *Scanner {
+	return &Scanner{
+		Ranges:      DefaultScannerRanges,
+		Concurrency: DefaultScannerConcurrency,
+		s:           s,
+		keyspace:    keyspace,
+		table:       table,
+	}
+}
+
+// Scan calls f with each row. It is called from up to Concurrency goroutines
+// at once, so it must be safe for concurrent use. Each range is queried
+// through Session.Query with the statement
+//
+//	select <columns> from <keyspace>.<table> where token(<partition key>) > ? and token(<partition key>) <= ?
+//
+// If a query or f returns an error, Scan stops and returns it. The ranges
+// completed until then are in Checkpoint, and the rows of the others may have
+// been delivered in part, so they are delivered again when the scan resumes.
+func (sc *Scanner) Scan(ctx context.Context, f func(row map[string]interface{}) error) error {
+	var t, err = sc.s.TableContext(ctx, sc.keyspace, sc.table)
+
+	if err != nil {
+		return err
+	}
+
+	var statement = sc.statement(t)
+	var ranges = SplitRing(sc.Ranges)
+	var done = map[TokenRange]bool{}
+
+	if sc.Checkpoint != nil {
+		var rs, err = sc.Checkpoint.Completed(ctx)
+
+		if err != nil {
+			return err
+		}
+
+		for _, r := range rs {
+			done[r] = true
+		}
+	}
+
+	var p = ScanProgress{Ranges: len(ranges)}
+	var pending []TokenRange
+
+	for _, r := range ranges {
+		if done[r] {
+			p.Completed++
+		} else {
+			pending = append(pending, r)
+		}
+	}
+
+	var scanCtx, cancel = context.WithCancel(ctx)
+
+	defer cancel()
+
+	var mu sync.Mutex
+	var first error
+	var rows atomic.Int64
+
+	var fail = func(err error) {
+		mu.Lock()
+
+		if first == nil {
+			first = err
+		}
+
+		mu.Unlock()
+		cancel()
+	}
+
+	var work = make(chan TokenRange)
+	var wg sync.WaitGroup
+	var concurrency = sc.Concurrency
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for r := range work {
+				if err := sc.scanRange(scanCtx, statement, r, f, &rows); err != nil {
+					fail(err)
+
+					continue
+				}
+
+				mu.Lock()
+
+				if sc.Checkpoint != nil {
+					if err := sc.Checkpoint.Complete(ctx, r); err != nil {
+						mu.Unlock()
+						fail(err)
+
+						continue
+					}
+				}
+
+				p.Completed++
+				p.Rows = rows.Load()
+
+				if sc.Progress != nil {
+					sc.Progress(p)
+				}
+
+				mu.Unlock()
+			}
+		}()
+	}
+
+send:
+	for _, r := range pending {
+		select {
+		case work <- r:
+
+		case <-scanCtx.Done():
+			break send
+		}
+	}
+
+	close(work)
+	wg.Wait()
+
+	if first != nil {
+		return first
+	}
+
+	return ctx.Err()
+}
+
+// ScanChan is like Scan but sends the rows on rows, and closes it when done.
+// Receive from rows until it is closed, or cancel ctx.
+func (sc *Scanner) ScanChan(ctx context.Context, rows chan<- map[string]interface{}) error {
+	defer close(rows)
+
+	return sc.Scan(ctx, func(row map[string]interface{}) error {
+		select {
+		case rows <- row:
+			return nil
+
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+}
+
+// statement returns the statement that selects a range of t.
+func (sc *Scanner) statement(t TableInfo) string {
+	var columns = "*"
+
+	if len(sc.Columns) > 0 {
+		columns = strings.Join(identifiers(sc.Columns), ", ")
+	}
+
+	var key = strings.Join(identifiers(t.PartitionKey), ", ")
+
+	return fmt.Sprintf("select %v from %v.%v where token(%v) > ? and token(%v) <= ?", columns, identifier(t.Keyspace), identifier(t.Name), key, key)
+}
+
+// scanRange calls f with the rows of r and adds their number to rows.
+func (sc *Scanner) scanRange(ctx context.Context, statement string, r TokenRange, f func(row map[string]interface{}) error, rows *atomic.Int64) error {
+	var q = sc.s.Query(statement, r.Start, r.End).WithContext(ctx)
+
+	if sc.PageSize > 0 {
+		q = q.PageSize(sc.PageSize)
+	}
+
+	for row, err := range q.Iter().All() {
+		if err != nil {
+			return err
+		}
+
+		if err := f(row); err != nil {
+			return err
+		}
+
+		rows.Add(1)
+	}
+
+	return nil
+}
+
+// identifier returns name quoted if it is not a lowercase identifier.
+func identifier(name string) string {
+	for i, c := range name {
+		if !(c >= 'a' && c <= 'z' || c == '_' || i > 0 && c >= '0' && c <= '9') {
+			return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+		}
+	}
+
+	return name
+}
+
+func identifiers(names []string) []string {
+	var is = make([]string, len(names))
+
+	for i, n := range names {
+		is[i] = identifier(n)
+	}
+
+	return is
+}
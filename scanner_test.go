@@ -0,0 +1,263 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSplitRing(t *testing.T) {
+	if a, e := SplitRing(0), []TokenRange{{math.MinInt64, math.MaxInt64}}; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual ranges %v, expected %v", a, e)
+	}
+
+	var e = []TokenRange{
+		{math.MinInt64, -4611686018427387905},
+		{-4611686018427387905, -1},
+		{-1, 4611686018427387903},
+		{4611686018427387903, math.MaxInt64},
+	}
+
+	if a := SplitRing(4); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual ranges %v, expected %v", a, e)
+	}
+
+	var rs = SplitRing(7)
+
+	for i, r := range rs {
+		if r.Start >= r.End {
+			t.Errorf("Actual range %v empty, expected not empty", r)
+		}
+
+		if i > 0 && r.Start != rs[i-1].End {
+			t.Errorf("Actual start %v, expected %v", r.Start, rs[i-1].End)
+		}
+	}
+
+	if a, e := rs[len(rs)-1].End, int64(math.MaxInt64); a != e {
+		t.Errorf("Actual end %v, expected %v", a, e)
+	}
+}
+
+func TestFileCheckpoint(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "checkpoint")
+	var c = FileCheckpoint(path)
+	var ctx = context.Background()
+
+	if a, err := c.Completed(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if a != nil {
+		t.Errorf("Actual ranges %v, expected nil", a)
+	}
+
+	var e = []TokenRange{{math.MinInt64, -1}, {5, math.MaxInt64}}
+
+	for _, r := range e {
+		if err := c.Complete(ctx, r); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	if a, err := c.Completed(ctx); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual ranges %v, expected %v", a, e)
+	}
+
+	if err := os.WriteFile(path, []byte("1\n"), 0o644); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if _, err := c.Completed(ctx); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestSessionScanner(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	for _, q := range []string{ksDropIf, ksCreate, tabCreate} {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	defer s.Exec(ksDrop)
+
+	testScanner(t, s)
+}
+
+func TestMemorySessionScanner(t *testing.T) {
+	var s = newMemorySession(t)
+
+	defer s.Close()
+
+	testScanner(t, s)
+}
+
+func testScanner(t *testing.T, s Session) {
+	const rows = 100
+
+	for i := 0; i < rows; i++ {
+		if err := s.Exec("insert into gockle_test.test (id, n) values (?, ?)", i, i); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	var mu sync.Mutex
+	var ids map[int]int
+
+	var collect = func(row map[string]interface{}) error {
+		mu.Lock()
+		ids[row["id"].(int)]++
+		mu.Unlock()
+
+		return nil
+	}
+
+	var check = func() {
+		if a, e := len(ids), rows; a != e {
+			t.Errorf("Actual rows %v, expected %v", a, e)
+		}
+
+		for id, n := range ids {
+			if n != 1 {
+				t.Errorf("Actual count %v, expected 1 for %v", n, id)
+			}
+		}
+	}
+
+	// Scan
+	var ps []ScanProgress
+	var sc = NewScanner(s, "gockle_test", "test")
+
+	sc.Ranges = 16
+	sc.PageSize = 7
+	sc.Progress = func(p ScanProgress) {
+		ps = append(ps, p)
+	}
+
+	ids = map[int]int{}
+
+	if err := sc.Scan(context.Background(), collect); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	check()
+
+	if a, e := len(ps), 16; a != e {
+		t.Fatalf("Actual progress calls %v, expected %v", a, e)
+	}
+
+	if a, e := ps[15], (ScanProgress{Ranges: 16, Completed: 16, Rows: rows}); a != e {
+		t.Errorf("Actual progress %v, expected %v", a, e)
+	}
+
+	// ScanChan
+	var ch = make(chan map[string]interface{})
+	var errs = make(chan error, 1)
+
+	sc = NewScanner(s, "gockle_test", "test")
+	sc.Columns = []string{"id"}
+
+	go func() {
+		errs <- sc.ScanChan(context.Background(), ch)
+	}()
+
+	ids = map[int]int{}
+
+	for row := range ch {
+		if a, e := len(row), 1; a != e {
+			t.Errorf("Actual columns %v, expected %v", a, e)
+		}
+
+		collect(row)
+	}
+
+	if err := <-errs; err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	check()
+
+	// Resume
+	var stop = errors.New("stop")
+	var path = filepath.Join(t.TempDir(), "checkpoint")
+
+	sc = NewScanner(s, "gockle_test", "test")
+	sc.Ranges = 16
+	sc.Concurrency = 1
+	sc.Checkpoint = FileCheckpoint(path)
+
+	ids = map[int]int{}
+
+	if err := sc.Scan(context.Background(), func(row map[string]interface{}) error {
+		if len(ids) == rows/2 {
+			return stop
+		}
+
+		return collect(row)
+	}); err != stop {
+		t.Fatalf("Actual error %v, expected %v", err, stop)
+	}
+
+	var completed, err = sc.Checkpoint.Completed(context.Background())
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if len(completed) == 0 || len(completed) == 16 {
+		t.Fatalf("Actual completed ranges %v, expected some", len(completed))
+	}
+
+	// Forget the rows of the range that failed, which are delivered again.
+	var delivered = map[int]int{}
+
+	for id := range ids {
+		if v, err := s.Token("gockle_test", "test", id); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		} else {
+			for _, r := range completed {
+				if v.Int64() > r.Start && v.Int64() <= r.End {
+					delivered[id] = 1
+				}
+			}
+		}
+	}
+
+	ids = delivered
+	ps = nil
+	sc.Progress = func(p ScanProgress) {
+		ps = append(ps, p)
+	}
+
+	if err := sc.Scan(context.Background(), collect); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	check()
+
+	if a, e := ps[0].Completed, len(completed)+1; a != e {
+		t.Errorf("Actual completed %v, expected %v", a, e)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := NewScanner(s, "gockle_test", "test").Scan(ctx, collect); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+
+	if err := NewScanner(s, "gockle_test", "invalid").Scan(context.Background(), collect); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("Actual error %v, expected %v", err, ErrTableNotFound)
+	}
+}
@@ -0,0 +1,451 @@
+package gockle
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gocql/gocql"
+)
+
+// Schema change kinds and targets.
+const (
+	SchemaCreated = "CREATED"
+	SchemaUpdated = "UPDATED"
+	SchemaDropped = "DROPPED"
+
+	SchemaKeyspace = "KEYSPACE"
+	SchemaTable    = "TABLE"
+)
+
+// SchemaChange is a change to a keyspace or a table.
+type SchemaChange struct {
+	// Kind is SchemaCreated, SchemaUpdated, or SchemaDropped.
+	Kind string
+
+	// Target is SchemaKeyspace or SchemaTable.
+	Target string
+
+	Keyspace string
+
+	// Table is empty if Target is SchemaKeyspace.
+	Table string
+}
+
+func (c SchemaChange) String() string {
+	if c.Target == SchemaTable {
+		return fmt.Sprintf("%v %v %v.%v", c.Kind, c.Target, c.Keyspace, c.Table)
+	}
+
+	return fmt.Sprintf("%v %v %v", c.Kind, c.Target, c.Keyspace)
+}
+
+// schemaChangeBuffer is the capacity of the channels of SchemaChanges.
+const schemaChangeBuffer = 64
+
+// schemaPollInterval is how often a session looks for schema changes once
+// SchemaChanges is called, unless Open is given WithSchemaPollInterval.
+var schemaPollInterval = time.Second
+
+// schemaFeed sends schema changes to subscribers.
+type schemaFeed struct {
+	mu     sync.Mutex
+	subs   []chan SchemaChange
+	closed bool
+}
+
+func (f *schemaFeed) subscribe() <-chan SchemaChange {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var c = make(chan SchemaChange, schemaChangeBuffer)
+
+	if f.closed {
+		close(c)
+	} else {
+		f.subs = append(f.subs, c)
+	}
+
+	return c
+}
+
+// publish sends c to the subscribers with room for it. The others miss it.
+func (f *schemaFeed) publish(c SchemaChange) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, s := range f.subs {
+		select {
+		case s <- c:
+		default:
+		}
+	}
+}
+
+func (f *schemaFeed) close() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.closed {
+		return
+	}
+
+	f.closed = true
+
+	for _, s := range f.subs {
+		close(s)
+	}
+
+	f.subs = nil
+}
+
+// schema caches the keyspace metadata of a session, and watches for schema
+// changes. gocql does not pass server events on, but it drops its
+// KeyspaceMetadata of a keyspace on them, so the cache is kept with the
+// KeyspaceMetadata it was converted from and converted again for a new one.
+// The watcher compares the KeyspaceMetadata of each keyspace every interval,
+// and only lists the keyspace names for created and dropped ones.
+type schema struct {
+	s        *gocql.Session
+	feed     schemaFeed
+	interval time.Duration
+
+	mu          sync.Mutex
+	keyspaces   map[string]cachedKeyspace
+	partitioner string
+	watching    bool
+
+	// ready is closed once the watcher has its baseline.
+	ready chan struct{}
+
+	done chan struct{}
+	once sync.Once
+}
+
+type cachedKeyspace struct {
+	meta *gocql.KeyspaceMetadata
+	info KeyspaceInfo
+}
+
+func newSchema(s *gocql.Session, interval time.Duration) *schema {
+	return &schema{s: s, interval: interval, keyspaces: map[string]cachedKeyspace{}, ready: make(chan struct{}), done: make(chan struct{})}
+}
+
+// metadata returns the gocql KeyspaceMetadata of keyspace. gocql reads it, not
+// with ctx, only when it has none since the last change of keyspace.
+func (s *schema) metadata(keyspace string) (*gocql.KeyspaceMetadata, error) {
+	var m, err = s.s.KeyspaceMetadata(keyspace)
+
+	if err == gocql.ErrKeyspaceDoesNotExist {
+		return nil, keyspaceNotFound(keyspace)
+	}
+
+	return m, err
+}
+
+// keyspace returns the metadata for keyspace. It fails with the error of ctx
+// if ctx is done, even if the metadata is cached.
+func (s *schema) keyspace(ctx context.Context, keyspace string) (KeyspaceInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return KeyspaceInfo{}, err
+	}
+
+	var m, err = s.metadata(keyspace)
+
+	if err != nil {
+		s.forget(keyspace)
+
+		return KeyspaceInfo{}, err
+	}
+
+	return s.info(keyspace, m), nil
+}
+
+// partitionerName returns the partitioner of the coordinator, read once.
+func (s *schema) partitionerName(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	var p = s.partitioner
+	s.mu.Unlock()
+
+	if p != "" {
+		return p, nil
+	}
+
+	if err := s.s.Query("select partitioner from system.local").WithContext(ctx).Scan(&p); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	s.partitioner = p
+	s.mu.Unlock()
+
+	return p, nil
+}
+
+// refresh caches the metadata for keyspace.
+func (s *schema) refresh(ctx context.Context, keyspace string) error {
+	var _, err = s.keyspace(ctx, keyspace)
+
+	return err
+}
+
+// info returns the KeyspaceInfo for m, the metadata of keyspace, converted
+// once for each m.
+func (s *schema) info(keyspace string, m *gocql.KeyspaceMetadata) KeyspaceInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if c, ok := s.keyspaces[keyspace]; ok && c.meta == m {
+		return c.info
+	}
+
+	var k = keyspaceInfo(m)
+
+	s.keyspaces[keyspace] = cachedKeyspace{meta: m, info: k}
+
+	return k
+}
+
+// forget drops the cached metadata for keyspace, which changed.
+func (s *schema) forget(keyspace string) {
+	s.mu.Lock()
+	delete(s.keyspaces, keyspace)
+	s.mu.Unlock()
+}
+
+// changes subscribes to the schema changes. The first call starts watching
+// them in the background.
+func (s *schema) changes() <-chan SchemaChange {
+	var c = s.feed.subscribe()
+
+	s.mu.Lock()
+	var start = !s.watching
+	s.watching = true
+	s.mu.Unlock()
+
+	if start {
+		go s.watch()
+	}
+
+	return c
+}
+
+// watch publishes the schema changes until the session closes. It reads the
+// baseline snapshot, closes ready, and then every interval reads the next one.
+func (s *schema) watch() {
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	defer cancel()
+
+	go func() {
+		<-s.done
+		cancel()
+	}()
+
+	var snap, _ = s.snapshot(ctx, nil)
+
+	close(s.ready)
+
+	var t = time.NewTicker(s.interval)
+
+	defer t.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+
+		case <-t.C:
+		}
+
+		var next, err = s.snapshot(ctx, snap)
+
+		if err != nil {
+			continue
+		}
+
+		if snap != nil {
+			for _, c := range snap.diff(next) {
+				s.feed.publish(c)
+			}
+		}
+
+		snap = next
+	}
+}
+
+func (s *schema) close() {
+	s.once.Do(func() {
+		close(s.done)
+		s.feed.close()
+	})
+}
+
+// schemaSnapshot maps keyspace names to the signatures of the keyspaces and
+// their tables, which change when their gocql KeyspaceMetadata does.
+type schemaSnapshot map[string]keyspaceSignature
+
+type keyspaceSignature struct {
+	signature string
+	tables    map[string]string
+
+	// meta is the gocql KeyspaceMetadata of the signatures.
+	meta *gocql.KeyspaceMetadata
+}
+
+// snapshot returns the signatures of the keyspaces of prev and the listed ones
+// from their gocql KeyspaceMetadata, so that a keyspace is dropped once gocql
+// has no KeyspaceMetadata for it. The keyspaces with the same KeyspaceMetadata
+// as in prev keep their signatures.
+func (s *schema) snapshot(ctx context.Context, prev schemaSnapshot) (schemaSnapshot, error) {
+	var names, err = s.keyspaceNames(ctx)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for k := range prev {
+		names = append(names, k)
+	}
+
+	var snap = schemaSnapshot{}
+
+	for _, k := range names {
+		if _, ok := snap[k]; ok {
+			continue
+		}
+
+		var m, err = s.s.KeyspaceMetadata(k)
+
+		if err == gocql.ErrKeyspaceDoesNotExist {
+			continue
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if p, ok := prev[k]; ok && p.meta == m {
+			snap[k] = p
+		} else {
+			snap[k] = signature(m)
+		}
+	}
+
+	return snap, nil
+}
+
+// keyspaceNames returns the names of the keyspaces, from
+// system.schema_keyspaces before Cassandra 3.0.
+func (s *schema) keyspaceNames(ctx context.Context) ([]string, error) {
+	var err error
+
+	for _, t := range []string{"system_schema.keyspaces", "system.schema_keyspaces"} {
+		var names []string
+		var i = s.s.Query("select keyspace_name from " + t).WithContext(ctx).Iter()
+		var n string
+
+		for i.Scan(&n) {
+			names = append(names, n)
+		}
+
+		if err = i.Close(); err == nil {
+			return names, nil
+		}
+	}
+
+	return nil, err
+}
+
+// signature returns the signatures of the keyspace of m and its tables.
+func signature(m *gocql.KeyspaceMetadata) keyspaceSignature {
+	var k = keyspaceSignature{signature: fmt.Sprint(m.DurableWrites, m.StrategyClass, m.StrategyOptions), tables: map[string]string{}, meta: m}
+
+	for n, t := range m.Tables {
+		var columns []string
+
+		for _, c := range t.Columns {
+			columns = append(columns, fmt.Sprint(c.Name, c.Kind, c.ComponentIndex, c.Validator, c.Order, c.Index.Name, c.Index.Type, c.Index.Options))
+		}
+
+		sort.Strings(columns)
+
+		k.tables[n] = strings.Join(columns, "\n")
+	}
+
+	return k
+}
+
+// diff returns the changes from snap to next, by keyspace name. A created
+// keyspace precedes its tables. A dropped keyspace stands for its tables, as in
+// the server events.
+func (snap schemaSnapshot) diff(next schemaSnapshot) []SchemaChange {
+	var names []string
+
+	for k := range snap {
+		names = append(names, k)
+	}
+
+	for k := range next {
+		if _, ok := snap[k]; !ok {
+			names = append(names, k)
+		}
+	}
+
+	sort.Strings(names)
+
+	var cs []SchemaChange
+
+	for _, k := range names {
+		var before, inBefore = snap[k]
+		var after, inAfter = next[k]
+
+		switch {
+		case !inBefore:
+			cs = append(cs, SchemaChange{Kind: SchemaCreated, Target: SchemaKeyspace, Keyspace: k})
+
+		case !inAfter:
+			cs = append(cs, SchemaChange{Kind: SchemaDropped, Target: SchemaKeyspace, Keyspace: k})
+
+			continue
+
+		case before.signature != after.signature:
+			cs = append(cs, SchemaChange{Kind: SchemaUpdated, Target: SchemaKeyspace, Keyspace: k})
+		}
+
+		var tables []string
+
+		for t := range before.tables {
+			tables = append(tables, t)
+		}
+
+		for t := range after.tables {
+			if _, ok := before.tables[t]; !ok {
+				tables = append(tables, t)
+			}
+		}
+
+		sort.Strings(tables)
+
+		for _, t := range tables {
+			var b, inB = before.tables[t]
+			var a, inA = after.tables[t]
+
+			switch {
+			case !inB:
+				cs = append(cs, SchemaChange{Kind: SchemaCreated, Target: SchemaTable, Keyspace: k, Table: t})
+
+			case !inA:
+				cs = append(cs, SchemaChange{Kind: SchemaDropped, Target: SchemaTable, Keyspace: k, Table: t})
+
+			case a != b:
+				cs = append(cs, SchemaChange{Kind: SchemaUpdated, Target: SchemaTable, Keyspace: k, Table: t})
+			}
+		}
+	}
+
+	return cs
+}
@@ -0,0 +1,142 @@
+package gockle
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestSessionSchemaChanges(t *testing.T) {
+	var interval = schemaPollInterval
+
+	schemaPollInterval = 10 * time.Millisecond
+
+	defer func() {
+		schemaPollInterval = interval
+	}()
+
+	var s = newSession(t)
+
+	if err := s.Exec(ksDropIf); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	// The baseline must not miss the changes of the test.
+	s.SchemaChanges()
+	<-s.(session).schema.ready
+
+	testSchemaChanges(t, s)
+}
+
+func TestMemorySessionSchemaChanges(t *testing.T) {
+	testSchemaChanges(t, NewMemorySession())
+}
+
+func testSchemaChanges(t *testing.T, s Session) {
+	var c = s.SchemaChanges()
+
+	var exec = func(q string, e SchemaChange) {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		select {
+		case a := <-c:
+			if a != e {
+				t.Errorf("Actual change %v, expected %v", a, e)
+			}
+
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Actual no change, expected %v", e)
+		}
+	}
+
+	var columns = func(e ...string) {
+		var m, err = s.Columns("gockle_test", "test")
+
+		if err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+
+		var a []string
+
+		for n := range m {
+			a = append(a, n)
+		}
+
+		sort.Strings(a)
+
+		if !reflect.DeepEqual(a, e) {
+			t.Errorf("Actual columns %v, expected %v", a, e)
+		}
+	}
+
+	exec(ksCreate, SchemaChange{Kind: SchemaCreated, Target: SchemaKeyspace, Keyspace: "gockle_test"})
+
+	if err := s.RefreshSchema("gockle_test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	exec(tabCreate, SchemaChange{Kind: SchemaCreated, Target: SchemaTable, Keyspace: "gockle_test", Table: "test"})
+	columns("id", "n")
+
+	exec(tabDrop, SchemaChange{Kind: SchemaDropped, Target: SchemaTable, Keyspace: "gockle_test", Table: "test"})
+
+	if a, err := s.Tables("gockle_test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(a) != 0 {
+		t.Errorf("Actual tables %v, expected none", a)
+	}
+
+	exec("create table gockle_test.test (id int primary key, m text)", SchemaChange{Kind: SchemaCreated, Target: SchemaTable, Keyspace: "gockle_test", Table: "test"})
+	columns("id", "m")
+
+	exec(ksDrop, SchemaChange{Kind: SchemaDropped, Target: SchemaKeyspace, Keyspace: "gockle_test"})
+
+	if err := s.RefreshSchema("gockle_test"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	var other = s.SchemaChanges()
+
+	s.Close()
+
+	for _, ch := range []<-chan SchemaChange{c, other} {
+		if a, ok := <-ch; ok {
+			t.Errorf("Actual change %v, expected closed channel", a)
+		}
+	}
+
+	if _, ok := <-s.SchemaChanges(); ok {
+		t.Error("Actual open channel, expected closed channel")
+	}
+}
+
+func TestSchemaSnapshotDiff(t *testing.T) {
+	var before = schemaSnapshot{
+		"a": {signature: "a", tables: map[string]string{"t": "t", "u": "u"}},
+		"b": {signature: "b", tables: map[string]string{"t": "t"}},
+		"c": {signature: "c", tables: map[string]string{"t": "t"}},
+	}
+
+	var after = schemaSnapshot{
+		"a": {signature: "a", tables: map[string]string{"t": "t2", "v": "v"}},
+		"c": {signature: "c2", tables: map[string]string{"t": "t"}},
+		"d": {signature: "d", tables: map[string]string{"t": "t"}},
+	}
+
+	var e = []SchemaChange{
+		{Kind: SchemaUpdated, Target: SchemaTable, Keyspace: "a", Table: "t"},
+		{Kind: SchemaDropped, Target: SchemaTable, Keyspace: "a", Table: "u"},
+		{Kind: SchemaCreated, Target: SchemaTable, Keyspace: "a", Table: "v"},
+		{Kind: SchemaDropped, Target: SchemaKeyspace, Keyspace: "b"},
+		{Kind: SchemaUpdated, Target: SchemaKeyspace, Keyspace: "c"},
+		{Kind: SchemaCreated, Target: SchemaKeyspace, Keyspace: "d"},
+		{Kind: SchemaCreated, Target: SchemaTable, Keyspace: "d", Table: "t"},
+	}
+
+	if a := before.diff(after); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual changes %v, expected %v", a, e)
+	}
+}
@@ -1,28 +1,19 @@
 package gockle
 
 import (
-	"fmt"
+	"context"
+	"math/big"
 
 	"github.com/gocql/gocql"
 )
 
-func metadata(s *gocql.Session, keyspace string) (*gocql.KeyspaceMetadata, error) {
-	var m, err = s.KeyspaceMetadata(keyspace)
-
-	if err != nil {
-		return nil, err
-	}
-
-	if !m.DurableWrites && m.Name == keyspace && m.StrategyClass == "" && len(m.StrategyOptions) == 0 && len(m.Tables) == 0 {
-		return nil, fmt.Errorf("gockle: keyspace %v invalid", keyspace)
-	}
-
-	return m, nil
-}
-
 // Session is a Cassandra connection. The Query methods run CQL queries. The
 // Columns and Tables methods provide simple metadata.
 type Session interface {
+	// AwaitSchemaAgreement waits until the nodes agree on the schema version or
+	// ctx is done.
+	AwaitSchemaAgreement(ctx context.Context) error
+
 	// Batch returns a new Batch for the Session.
 	Batch(kind BatchKind) Batch
 
@@ -30,38 +21,132 @@ type Session interface {
 	Close()
 
 	// Columns returns a map from column names to types for keyspace and table.
-	// Schema changes during a session are not reflected; you must open a new
-	// Session to observe them.
+	// Schema changes are reflected once the Session is notified of them.
 	Columns(keyspace, table string) (map[string]gocql.TypeInfo, error)
 
-	// Exec executes the query for statement and arguments.
+	// ColumnsContext is like Columns but uses ctx for the queries.
+	ColumnsContext(ctx context.Context, keyspace, table string) (map[string]gocql.TypeInfo, error)
+
+	// Exec executes the query for statement and arguments. If the statement
+	// is conditional and not applied, the error matches ErrNotApplied with a
+	// context from ReportNotApplied.
 	Exec(statement string, arguments ...interface{}) error
 
+	// ExecContext is like Exec but uses ctx for the query.
+	ExecContext(ctx context.Context, statement string, arguments ...interface{}) error
+
+	// ExecAsync is like Exec but runs in the background and returns a Future.
+	// At most DefaultAsyncLimit asynchronous calls run at once through the
+	// Session, and later calls wait to start.
+	ExecAsync(statement string, arguments ...interface{}) *Future
+
+	// ExecAsyncContext is like ExecAsync but uses ctx for the query and to
+	// wait to start.
+	ExecAsyncContext(ctx context.Context, statement string, arguments ...interface{}) *Future
+
 	// Scan executes the query for statement and arguments and puts the first
 	// result row in results.
 	Scan(statement string, results []interface{}, arguments ...interface{}) error
 
+	// ScanContext is like Scan but uses ctx for the query.
+	ScanContext(ctx context.Context, statement string, results []interface{}, arguments ...interface{}) error
+
 	// ScanIterator executes the query for statement and arguments and returns an
 	// Iterator for the results.
 	ScanIterator(statement string, arguments ...interface{}) Iterator
 
+	// ScanIteratorContext is like ScanIterator but uses ctx for the query,
+	// including the queries for later pages.
+	ScanIteratorContext(ctx context.Context, statement string, arguments ...interface{}) Iterator
+
 	// ScanMap executes the query for statement and arguments and puts the first
 	// result row in results.
 	ScanMap(statement string, results map[string]interface{}, arguments ...interface{}) error
 
+	// ScanMapContext is like ScanMap but uses ctx for the query.
+	ScanMapContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) error
+
+	// ScanMapAsync is like ScanMap but runs in the background like ExecAsync.
+	// Do not use results until the Future is done.
+	ScanMapAsync(statement string, results map[string]interface{}, arguments ...interface{}) *Future
+
+	// ScanMapAsyncContext is like ScanMapAsync but uses ctx for the query and
+	// to wait to start.
+	ScanMapAsyncContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) *Future
+
 	// ScanMapSlice executes the query for statement and arguments and returns all
 	// the result rows.
 	ScanMapSlice(statement string, arguments ...interface{}) ([]map[string]interface{}, error)
 
+	// ScanMapSliceContext is like ScanMapSlice but uses ctx for the query.
+	ScanMapSliceContext(ctx context.Context, statement string, arguments ...interface{}) ([]map[string]interface{}, error)
+
 	// ScanMapTx executes the query for statement and arguments as a lightweight
 	// transaction. If the query is not applied, it puts the current values for the
 	// conditional columns in results. It returns whether the query is applied.
 	ScanMapTx(statement string, results map[string]interface{}, arguments ...interface{}) (bool, error)
 
-	// Tables returns the table names for keyspace. Schema changes during a session
-	// are not reflected; you must open a new Session to observe them.
+	// ScanMapTxContext is like ScanMapTx but uses ctx for the query.
+	ScanMapTxContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) (bool, error)
+
+	// ScanStruct executes the query for statement and arguments and puts the
+	// first result row in the struct that dest points to, like
+	// Iterator.StructScan.
+	ScanStruct(statement string, dest interface{}, arguments ...interface{}) error
+
+	// ScanStructContext is like ScanStruct but uses ctx for the query.
+	ScanStructContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error
+
+	// ScanStructSlice executes the query for statement and arguments and puts
+	// all the result rows in the slice that dest points to, like
+	// Iterator.StructScan. The slice elements may be structs or pointers to
+	// structs.
+	ScanStructSlice(statement string, dest interface{}, arguments ...interface{}) error
+
+	// ScanStructSliceContext is like ScanStructSlice but uses ctx for the query.
+	ScanStructSliceContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error
+
+	// Tables returns the table names for keyspace in order. Schema changes are
+	// reflected once the Session is notified of them.
 	Tables(keyspace string) ([]string, error)
 
+	// TablesContext is like Tables but uses ctx for the queries.
+	TablesContext(ctx context.Context, keyspace string) ([]string, error)
+
+	// Keyspace returns the metadata for keyspace. The Session may cache it, so
+	// do not modify it.
+	Keyspace(keyspace string) (KeyspaceInfo, error)
+
+	// KeyspaceContext is like Keyspace but uses ctx for the queries.
+	KeyspaceContext(ctx context.Context, keyspace string) (KeyspaceInfo, error)
+
+	// Table returns the metadata for keyspace and table.
+	Table(keyspace, table string) (TableInfo, error)
+
+	// TableContext is like Table but uses ctx for the queries.
+	TableContext(ctx context.Context, keyspace, table string) (TableInfo, error)
+
+	// RefreshSchema caches the metadata for keyspace for the metadata
+	// methods. Sessions of gocql cache it as gocql has it, which gocql reads
+	// again once notified of a schema change.
+	RefreshSchema(keyspace string) error
+
+	// RefreshSchemaContext is like RefreshSchema but uses ctx for the queries.
+	RefreshSchemaContext(ctx context.Context, keyspace string) error
+
+	// SchemaChanges returns a new channel of the changes to keyspaces and
+	// tables, closed by Close. Changes are sent as they are noticed, in order,
+	// and missed while the channel is full, so receive from it promptly.
+	SchemaChanges() <-chan SchemaChange
+
+	// Token returns the token of the partition of keyspace and table with the
+	// partition key values keyValues, in order, as the partitioner of the
+	// cluster computes it. Murmur3Partitioner tokens are int64 values.
+	Token(keyspace, table string, keyValues ...interface{}) (*big.Int, error)
+
+	// TokenContext is like Token but uses ctx for the queries.
+	TokenContext(ctx context.Context, keyspace, table string, keyValues ...interface{}) (*big.Int, error)
+
 	// Query generates a new query object for interacting with the database.
 	// Further details of the query may be tweaked using the resulting query
 	// value before the query is executed. Query is automatically prepared if
@@ -76,85 +161,150 @@ var (
 
 // NewSession returns a new Session for s.
 func NewSession(s *gocql.Session) Session {
-	return session{s: s}
+	return session{s: s, schema: newSchema(s, schemaPollInterval), limit: newLimiter(DefaultAsyncLimit)}
 }
 
 // NewSimpleSession returns a new Session for hosts. It uses native protocol
-// version 4.
+// version 4. Use Open for other settings.
 func NewSimpleSession(hosts ...string) (Session, error) {
-	var c = gocql.NewCluster(hosts...)
-
-	c.ProtoVersion = 4
-
-	var s, err = c.CreateSession()
+	return Open(hosts, WithProtoVersion(4))
+}
 
-	if err != nil {
-		return nil, err
-	}
+// session caches metadata in schema, which is read again after gocql notices
+// a schema change, and watches system_schema for SchemaChanges.
+type session struct {
+	s      *gocql.Session
+	schema *schema
+	limit  limiter
+}
 
-	return session{s: s}, nil
+func (s session) asyncLimiter() limiter {
+	return s.limit
 }
 
-type session struct {
-	s *gocql.Session
+func (s session) AwaitSchemaAgreement(ctx context.Context) error {
+	return s.s.AwaitSchemaAgreement(ctx)
 }
 
 func (s session) Batch(kind BatchKind) Batch {
-	return batch{b: s.s.NewBatch(gocql.BatchType(kind)), s: s.s}
+	return &batch{b: s.s.NewBatch(gocql.BatchType(kind)), s: s.s}
 }
 
 func (s session) Close() {
+	s.schema.close()
 	s.s.Close()
 }
 
 func (s session) Columns(keyspace, table string) (map[string]gocql.TypeInfo, error) {
-	var m, err = metadata(s.s, keyspace)
+	return s.ColumnsContext(context.Background(), keyspace, table)
+}
+
+func (s session) ColumnsContext(ctx context.Context, keyspace, table string) (map[string]gocql.TypeInfo, error) {
+	var t, err = s.TableContext(ctx, keyspace, table)
 
 	if err != nil {
 		return nil, err
 	}
 
-	var t, ok = m.Tables[table]
-
-	if !ok {
-		return nil, fmt.Errorf("gockle: table %v.%v invalid", keyspace, table)
-	}
-
 	var types = map[string]gocql.TypeInfo{}
 
-	for n, c := range t.Columns {
-		types[n] = c.Type
+	for _, c := range t.Columns {
+		types[c.Name] = c.Type
 	}
 
 	return types, nil
 }
 
 func (s session) Exec(statement string, arguments ...interface{}) error {
-	return s.s.Query(statement, arguments...).Exec()
+	return s.ExecContext(context.Background(), statement, arguments...)
+}
+
+func (s session) ExecContext(ctx context.Context, statement string, arguments ...interface{}) error {
+	return s.query(ctx, statement, arguments).Exec()
+}
+
+func (s session) ExecAsync(statement string, arguments ...interface{}) *Future {
+	return s.ExecAsyncContext(context.Background(), statement, arguments...)
+}
+
+func (s session) ExecAsyncContext(ctx context.Context, statement string, arguments ...interface{}) *Future {
+	return s.limit.run(ctx, func(*Future) error {
+		return s.ExecContext(ctx, statement, arguments...)
+	})
 }
 
 func (s session) Scan(statement string, results []interface{}, arguments ...interface{}) error {
-	return s.s.Query(statement, arguments...).Scan(results...)
+	return s.ScanContext(context.Background(), statement, results, arguments...)
+}
+
+func (s session) ScanContext(ctx context.Context, statement string, results []interface{}, arguments ...interface{}) error {
+	return s.query(ctx, statement, arguments).Scan(results...)
 }
 
 func (s session) ScanIterator(statement string, arguments ...interface{}) Iterator {
-	return iterator{i: s.s.Query(statement, arguments...).Iter()}
+	return s.ScanIteratorContext(context.Background(), statement, arguments...)
+}
+
+func (s session) ScanIteratorContext(ctx context.Context, statement string, arguments ...interface{}) Iterator {
+	return s.query(ctx, statement, arguments).Iter()
 }
 
 func (s session) ScanMap(statement string, results map[string]interface{}, arguments ...interface{}) error {
-	return s.s.Query(statement, arguments...).MapScan(results)
+	return s.ScanMapContext(context.Background(), statement, results, arguments...)
+}
+
+func (s session) ScanMapContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) error {
+	return s.query(ctx, statement, arguments).MapScan(results)
+}
+
+func (s session) ScanMapAsync(statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	return s.ScanMapAsyncContext(context.Background(), statement, results, arguments...)
+}
+
+func (s session) ScanMapAsyncContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	return s.limit.run(ctx, func(*Future) error {
+		return s.ScanMapContext(ctx, statement, results, arguments...)
+	})
 }
 
 func (s session) ScanMapSlice(statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
-	return s.s.Query(statement, arguments...).Iter().SliceMap()
+	return s.ScanMapSliceContext(context.Background(), statement, arguments...)
+}
+
+func (s session) ScanMapSliceContext(ctx context.Context, statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
+	return s.query(ctx, statement, arguments).Iter().SliceMap()
 }
 
 func (s session) ScanMapTx(statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
-	return s.s.Query(statement, arguments...).MapScanCAS(results)
+	return s.ScanMapTxContext(context.Background(), statement, results, arguments...)
+}
+
+func (s session) ScanMapTxContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
+	return s.query(ctx, statement, arguments).mapScanCAS(results)
+}
+
+func (s session) ScanStruct(statement string, dest interface{}, arguments ...interface{}) error {
+	return s.ScanStructContext(context.Background(), statement, dest, arguments...)
+}
+
+func (s session) ScanStructContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	return scanStruct(s.ScanIteratorContext(ctx, statement, arguments...), dest)
+}
+
+func (s session) ScanStructSlice(statement string, dest interface{}, arguments ...interface{}) error {
+	return s.ScanStructSliceContext(context.Background(), statement, dest, arguments...)
+}
+
+func (s session) ScanStructSliceContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	return scanStructSlice(s.ScanIteratorContext(ctx, statement, arguments...), dest)
 }
 
 func (s session) Tables(keyspace string) ([]string, error) {
-	var m, err = metadata(s.s, keyspace)
+	return s.TablesContext(context.Background(), keyspace)
+}
+
+func (s session) TablesContext(ctx context.Context, keyspace string) ([]string, error) {
+	var k, err = s.schema.keyspace(ctx, keyspace)
 
 	if err != nil {
 		return nil, err
@@ -162,13 +312,77 @@ func (s session) Tables(keyspace string) ([]string, error) {
 
 	var ts []string
 
-	for t := range m.Tables {
-		ts = append(ts, t)
+	for _, t := range k.Tables {
+		ts = append(ts, t.Name)
 	}
 
 	return ts, nil
 }
 
+func (s session) Keyspace(keyspace string) (KeyspaceInfo, error) {
+	return s.KeyspaceContext(context.Background(), keyspace)
+}
+
+func (s session) KeyspaceContext(ctx context.Context, keyspace string) (KeyspaceInfo, error) {
+	return s.schema.keyspace(ctx, keyspace)
+}
+
+func (s session) Table(keyspace, table string) (TableInfo, error) {
+	return s.TableContext(context.Background(), keyspace, table)
+}
+
+func (s session) TableContext(ctx context.Context, keyspace, table string) (TableInfo, error) {
+	var k, err = s.KeyspaceContext(ctx, keyspace)
+
+	if err != nil {
+		return TableInfo{}, err
+	}
+
+	var t, ok = k.Table(table)
+
+	if !ok {
+		return TableInfo{}, tableNotFound(keyspace, table)
+	}
+
+	return t, nil
+}
+
+func (s session) RefreshSchema(keyspace string) error {
+	return s.RefreshSchemaContext(context.Background(), keyspace)
+}
+
+func (s session) RefreshSchemaContext(ctx context.Context, keyspace string) error {
+	return s.schema.refresh(ctx, keyspace)
+}
+
+func (s session) SchemaChanges() <-chan SchemaChange {
+	return s.schema.changes()
+}
+
+func (s session) Token(keyspace, table string, keyValues ...interface{}) (*big.Int, error) {
+	return s.TokenContext(context.Background(), keyspace, table, keyValues...)
+}
+
+func (s session) TokenContext(ctx context.Context, keyspace, table string, keyValues ...interface{}) (*big.Int, error) {
+	var t, err = s.TableContext(ctx, keyspace, table)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := s.schema.partitionerName(ctx)
+
+	if err != nil {
+		return nil, newError(err, "", "")
+	}
+
+	return token(p, t, keyValues)
+}
+
+func (s session) query(ctx context.Context, statement string, arguments []interface{}) query {
+	return query{q: s.s.Query(statement, arguments...).WithContext(ctx), limit: s.limit}
+}
+
 func (s session) Query(statement string, arguments ...interface{}) Query {
-	return query{q: s.s.Query(statement, arguments...)}
+	return query{q: s.s.Query(statement, arguments...), limit: s.limit}
 }
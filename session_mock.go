@@ -1,6 +1,9 @@
 package gockle
 
 import (
+	"context"
+	"math/big"
+
 	"github.com/gocql/gocql"
 	"github.com/stretchr/testify/mock"
 )
@@ -10,6 +13,20 @@ type SessionMock struct {
 	mock.Mock
 }
 
+// AwaitSchemaAgreement provides a mock function with given fields: ctx
+func (_m *SessionMock) AwaitSchemaAgreement(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // BatchMock provides a mock function with given fields: kind
 func (_m *SessionMock) Batch(kind BatchKind) Batch {
 	ret := _m.Called(kind)
@@ -54,6 +71,29 @@ func (_m *SessionMock) Columns(keyspace string, table string) (map[string]gocql.
 	return r0, r1
 }
 
+// ColumnsContext provides a mock function with given fields: ctx, keyspace, table
+func (_m *SessionMock) ColumnsContext(ctx context.Context, keyspace string, table string) (map[string]gocql.TypeInfo, error) {
+	ret := _m.Called(ctx, keyspace, table)
+
+	var r0 map[string]gocql.TypeInfo
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) map[string]gocql.TypeInfo); ok {
+		r0 = rf(ctx, keyspace, table)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]gocql.TypeInfo)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, keyspace, table)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Exec provides a mock function with given fields: statement, arguments
 func (_m *SessionMock) Exec(statement string, arguments ...interface{}) error {
 	var _ca []interface{}
@@ -71,6 +111,103 @@ func (_m *SessionMock) Exec(statement string, arguments ...interface{}) error {
 	return r0
 }
 
+// ExecAsync provides a mock function with given fields: statement, arguments
+func (_m *SessionMock) ExecAsync(statement string, arguments ...interface{}) *Future {
+	var _ca []interface{}
+	_ca = append(_ca, statement)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 *Future
+	if rf, ok := ret.Get(0).(func(string, ...interface{}) *Future); ok {
+		r0 = rf(statement, arguments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Future)
+		}
+	}
+
+	return r0
+}
+
+// ExecAsyncContext provides a mock function with given fields: ctx, statement, arguments
+func (_m *SessionMock) ExecAsyncContext(ctx context.Context, statement string, arguments ...interface{}) *Future {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 *Future
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) *Future); ok {
+		r0 = rf(ctx, statement, arguments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Future)
+		}
+	}
+
+	return r0
+}
+
+// ExecContext provides a mock function with given fields: ctx, statement, arguments
+func (_m *SessionMock) ExecContext(ctx context.Context, statement string, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) error); ok {
+		r0 = rf(ctx, statement, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Keyspace provides a mock function with given fields: keyspace
+func (_m *SessionMock) Keyspace(keyspace string) (KeyspaceInfo, error) {
+	ret := _m.Called(keyspace)
+
+	var r0 KeyspaceInfo
+	if rf, ok := ret.Get(0).(func(string) KeyspaceInfo); ok {
+		r0 = rf(keyspace)
+	} else {
+		r0 = ret.Get(0).(KeyspaceInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string) error); ok {
+		r1 = rf(keyspace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// KeyspaceContext provides a mock function with given fields: ctx, keyspace
+func (_m *SessionMock) KeyspaceContext(ctx context.Context, keyspace string) (KeyspaceInfo, error) {
+	ret := _m.Called(ctx, keyspace)
+
+	var r0 KeyspaceInfo
+	if rf, ok := ret.Get(0).(func(context.Context, string) KeyspaceInfo); ok {
+		r0 = rf(ctx, keyspace)
+	} else {
+		r0 = ret.Get(0).(KeyspaceInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyspace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Query provides a mock function with given fields: statement, arguments
 func (_m *SessionMock) Query(statement string, arguments ...interface{}) Query {
 	var _ca []interface{}
@@ -90,6 +227,34 @@ func (_m *SessionMock) Query(statement string, arguments ...interface{}) Query {
 	return r0
 }
 
+// RefreshSchema provides a mock function with given fields: keyspace
+func (_m *SessionMock) RefreshSchema(keyspace string) error {
+	ret := _m.Called(keyspace)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(keyspace)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RefreshSchemaContext provides a mock function with given fields: ctx, keyspace
+func (_m *SessionMock) RefreshSchemaContext(ctx context.Context, keyspace string) error {
+	ret := _m.Called(ctx, keyspace)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, keyspace)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // Scan provides a mock function with given fields: statement, results, arguments
 func (_m *SessionMock) Scan(statement string, results []interface{}, arguments ...interface{}) error {
 	var _ca []interface{}
@@ -107,6 +272,23 @@ func (_m *SessionMock) Scan(statement string, results []interface{}, arguments .
 	return r0
 }
 
+// ScanContext provides a mock function with given fields: ctx, statement, results, arguments
+func (_m *SessionMock) ScanContext(ctx context.Context, statement string, results []interface{}, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement, results)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []interface{}, ...interface{}) error); ok {
+		r0 = rf(ctx, statement, results, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ScanIterator provides a mock function with given fields: statement, arguments
 func (_m *SessionMock) ScanIterator(statement string, arguments ...interface{}) Iterator {
 	var _ca []interface{}
@@ -126,6 +308,25 @@ func (_m *SessionMock) ScanIterator(statement string, arguments ...interface{})
 	return r0
 }
 
+// ScanIteratorContext provides a mock function with given fields: ctx, statement, arguments
+func (_m *SessionMock) ScanIteratorContext(ctx context.Context, statement string, arguments ...interface{}) Iterator {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 Iterator
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) Iterator); ok {
+		r0 = rf(ctx, statement, arguments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(Iterator)
+		}
+	}
+
+	return r0
+}
+
 // ScanMap provides a mock function with given fields: statement, results, arguments
 func (_m *SessionMock) ScanMap(statement string, results map[string]interface{}, arguments ...interface{}) error {
 	var _ca []interface{}
@@ -143,6 +344,61 @@ func (_m *SessionMock) ScanMap(statement string, results map[string]interface{},
 	return r0
 }
 
+// ScanMapAsync provides a mock function with given fields: statement, results, arguments
+func (_m *SessionMock) ScanMapAsync(statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	var _ca []interface{}
+	_ca = append(_ca, statement, results)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 *Future
+	if rf, ok := ret.Get(0).(func(string, map[string]interface{}, ...interface{}) *Future); ok {
+		r0 = rf(statement, results, arguments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Future)
+		}
+	}
+
+	return r0
+}
+
+// ScanMapAsyncContext provides a mock function with given fields: ctx, statement, results, arguments
+func (_m *SessionMock) ScanMapAsyncContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) *Future {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement, results)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 *Future
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}, ...interface{}) *Future); ok {
+		r0 = rf(ctx, statement, results, arguments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*Future)
+		}
+	}
+
+	return r0
+}
+
+// ScanMapContext provides a mock function with given fields: ctx, statement, results, arguments
+func (_m *SessionMock) ScanMapContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement, results)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}, ...interface{}) error); ok {
+		r0 = rf(ctx, statement, results, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
 // ScanMapSlice provides a mock function with given fields: statement, arguments
 func (_m *SessionMock) ScanMapSlice(statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
 	var _ca []interface{}
@@ -169,6 +425,32 @@ func (_m *SessionMock) ScanMapSlice(statement string, arguments ...interface{})
 	return r0, r1
 }
 
+// ScanMapSliceContext provides a mock function with given fields: ctx, statement, arguments
+func (_m *SessionMock) ScanMapSliceContext(ctx context.Context, statement string, arguments ...interface{}) ([]map[string]interface{}, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 []map[string]interface{}
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...interface{}) []map[string]interface{}); ok {
+		r0 = rf(ctx, statement, arguments...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]map[string]interface{})
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, ...interface{}) error); ok {
+		r1 = rf(ctx, statement, arguments...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ScanMapTx provides a mock function with given fields: statement, results, arguments
 func (_m *SessionMock) ScanMapTx(statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
 	var _ca []interface{}
@@ -193,6 +475,156 @@ func (_m *SessionMock) ScanMapTx(statement string, results map[string]interface{
 	return r0, r1
 }
 
+// ScanMapTxContext provides a mock function with given fields: ctx, statement, results, arguments
+func (_m *SessionMock) ScanMapTxContext(ctx context.Context, statement string, results map[string]interface{}, arguments ...interface{}) (bool, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement, results)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 bool
+	if rf, ok := ret.Get(0).(func(context.Context, string, map[string]interface{}, ...interface{}) bool); ok {
+		r0 = rf(ctx, statement, results, arguments...)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, map[string]interface{}, ...interface{}) error); ok {
+		r1 = rf(ctx, statement, results, arguments...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ScanStruct provides a mock function with given fields: statement, dest, arguments
+func (_m *SessionMock) ScanStruct(statement string, dest interface{}, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, statement, dest)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}, ...interface{}) error); ok {
+		r0 = rf(statement, dest, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScanStructContext provides a mock function with given fields: ctx, statement, dest, arguments
+func (_m *SessionMock) ScanStructContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement, dest)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, ...interface{}) error); ok {
+		r0 = rf(ctx, statement, dest, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScanStructSlice provides a mock function with given fields: statement, dest, arguments
+func (_m *SessionMock) ScanStructSlice(statement string, dest interface{}, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, statement, dest)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}, ...interface{}) error); ok {
+		r0 = rf(statement, dest, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ScanStructSliceContext provides a mock function with given fields: ctx, statement, dest, arguments
+func (_m *SessionMock) ScanStructSliceContext(ctx context.Context, statement string, dest interface{}, arguments ...interface{}) error {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, statement, dest)
+	_ca = append(_ca, arguments...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, ...interface{}) error); ok {
+		r0 = rf(ctx, statement, dest, arguments...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// SchemaChanges provides a mock function with given fields:
+func (_m *SessionMock) SchemaChanges() <-chan SchemaChange {
+	ret := _m.Called()
+
+	var r0 <-chan SchemaChange
+	if rf, ok := ret.Get(0).(func() <-chan SchemaChange); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(<-chan SchemaChange)
+		}
+	}
+
+	return r0
+}
+
+// Table provides a mock function with given fields: keyspace, table
+func (_m *SessionMock) Table(keyspace string, table string) (TableInfo, error) {
+	ret := _m.Called(keyspace, table)
+
+	var r0 TableInfo
+	if rf, ok := ret.Get(0).(func(string, string) TableInfo); ok {
+		r0 = rf(keyspace, table)
+	} else {
+		r0 = ret.Get(0).(TableInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string) error); ok {
+		r1 = rf(keyspace, table)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TableContext provides a mock function with given fields: ctx, keyspace, table
+func (_m *SessionMock) TableContext(ctx context.Context, keyspace string, table string) (TableInfo, error) {
+	ret := _m.Called(ctx, keyspace, table)
+
+	var r0 TableInfo
+	if rf, ok := ret.Get(0).(func(context.Context, string, string) TableInfo); ok {
+		r0 = rf(ctx, keyspace, table)
+	} else {
+		r0 = ret.Get(0).(TableInfo)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string) error); ok {
+		r1 = rf(ctx, keyspace, table)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Tables provides a mock function with given fields: keyspace
 func (_m *SessionMock) Tables(keyspace string) ([]string, error) {
 	ret := _m.Called(keyspace)
@@ -215,3 +647,78 @@ func (_m *SessionMock) Tables(keyspace string) ([]string, error) {
 
 	return r0, r1
 }
+
+// TablesContext provides a mock function with given fields: ctx, keyspace
+func (_m *SessionMock) TablesContext(ctx context.Context, keyspace string) ([]string, error) {
+	ret := _m.Called(ctx, keyspace)
+
+	var r0 []string
+	if rf, ok := ret.Get(0).(func(context.Context, string) []string); ok {
+		r0 = rf(ctx, keyspace)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]string)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, keyspace)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Token provides a mock function with given fields: keyspace, table, keyValues
+func (_m *SessionMock) Token(keyspace string, table string, keyValues ...interface{}) (*big.Int, error) {
+	var _ca []interface{}
+	_ca = append(_ca, keyspace, table)
+	_ca = append(_ca, keyValues...)
+	ret := _m.Called(_ca...)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(string, string, ...interface{}) *big.Int); ok {
+		r0 = rf(keyspace, table, keyValues...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, ...interface{}) error); ok {
+		r1 = rf(keyspace, table, keyValues...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// TokenContext provides a mock function with given fields: ctx, keyspace, table, keyValues
+func (_m *SessionMock) TokenContext(ctx context.Context, keyspace string, table string, keyValues ...interface{}) (*big.Int, error) {
+	var _ca []interface{}
+	_ca = append(_ca, ctx, keyspace, table)
+	_ca = append(_ca, keyValues...)
+	ret := _m.Called(_ca...)
+
+	var r0 *big.Int
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, ...interface{}) *big.Int); ok {
+		r0 = rf(ctx, keyspace, table, keyValues...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*big.Int)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, ...interface{}) error); ok {
+		r1 = rf(ctx, keyspace, table, keyValues...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
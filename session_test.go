@@ -1,11 +1,15 @@
 package gockle
 
 import (
+	"context"
+	"errors"
+	"os"
 	"reflect"
 	"testing"
 	"time"
 
 	"github.com/gocql/gocql"
+	"github.com/kerkerj/gockle/gockletest"
 )
 
 const version = 4
@@ -20,22 +24,35 @@ const (
 	tabDrop    = "drop table gockle_test.test"
 )
 
+var server *gockletest.Server
+
+func TestMain(m *testing.M) {
+	server = gockletest.NewServer()
+
+	var code = m.Run()
+
+	server.Close()
+	os.Exit(code)
+}
+
 func TestNewSession(t *testing.T) {
-	if a, e := NewSession(nil), (session{}); a != e {
-		t.Errorf("Actual session %v, expected %v", a, e)
+	if a := NewSession(nil).(session); a.s != nil || a.schema == nil {
+		t.Errorf("Actual session %v, expected no gocql session and a schema cache", a)
 	}
 
-	var c = gocql.NewCluster("localhost")
+	var c = gocql.NewCluster(server.Addr)
 
 	c.ProtoVersion = version
 
 	var s, err = c.CreateSession()
 
 	if err != nil {
-		t.Skip(err)
+		t.Fatalf("Actual error %v, expected no error", err)
 	}
 
-	if a, e := NewSession(s), (session{s: s}); a != e {
+	defer s.Close()
+
+	if a, e := NewSession(s).(session).s, s; a != e {
 		t.Errorf("Actual session %v, expected %v", a, e)
 	}
 }
@@ -48,11 +65,15 @@ func TestNewSimpleSession(t *testing.T) {
 		s.Close()
 	}
 
-	if a, err := NewSimpleSession("localhost"); err != nil {
-		t.Skip(err)
+	if a, err := NewSimpleSession(server.Addr); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
 	} else if a == nil {
 		t.Errorf("Actual session nil, expected not nil")
 	} else {
+		if err := a.AwaitSchemaAgreement(context.Background()); err != nil {
+			t.Errorf("Actual error %v, expected no error", err)
+		}
+
 		a.Close()
 	}
 }
@@ -214,7 +235,7 @@ func TestSessionQuery(t *testing.T) {
 }
 
 func newSession(t *testing.T) Session {
-	var c = gocql.NewCluster("localhost")
+	var c = gocql.NewCluster(server.Addr)
 
 	c.ProtoVersion = version
 	c.Timeout = 5 * time.Second
@@ -222,8 +243,70 @@ func newSession(t *testing.T) Session {
 	var s, err = c.CreateSession()
 
 	if err != nil {
-		t.Skip(err)
+		t.Fatalf("Actual error %v, expected no error", err)
 	}
 
 	return NewSession(s)
 }
+
+func TestSessionContext(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	var exec = func(q string) {
+		if err := s.ExecContext(context.Background(), q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	exec(ksDropIf)
+	exec(ksCreate)
+
+	defer exec(ksDrop)
+
+	exec(tabCreate)
+
+	defer exec(tabDrop)
+
+	exec(rowInsert)
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	if a, err := s.ScanMapSliceContext(ctx, "select * from gockle_test.test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := ([]map[string]interface{}{{"id": 1, "n": 2}}); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	if a, err := s.TablesContext(ctx, "gockle_test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := ([]string{"test"}); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual tables %v, expected %v", a, e)
+	}
+
+	cancel()
+
+	var id, n int
+	var m = map[string]interface{}{}
+
+	for _, err := range []error{
+		s.ExecContext(ctx, rowInsert2),
+		s.ScanContext(ctx, "select id, n from gockle_test.test", []interface{}{&id, &n}),
+		s.ScanIteratorContext(ctx, "select * from gockle_test.test").Close(),
+		s.ScanMapContext(ctx, "select * from gockle_test.test", m),
+		errorOf(s.ScanMapSliceContext(ctx, "select * from gockle_test.test")),
+		errorOf(s.ScanMapTxContext(ctx, "update gockle_test.test set n = 3 where id = 1 if n = 2", m)),
+		errorOf(s.ColumnsContext(ctx, "gockle_test", "test")),
+		errorOf(s.TablesContext(ctx, "gockle_test")),
+	} {
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+		}
+	}
+}
+
+// errorOf returns the error of a call that returns a value and an error.
+func errorOf(_ interface{}, err error) error {
+	return err
+}
@@ -0,0 +1,333 @@
+package gockle
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Defaults of SmartBatch.
+const (
+	// DefaultSmartBatchMaxStatements is the default for
+	// SmartBatch.MaxStatements.
+	DefaultSmartBatchMaxStatements = 100
+
+	// DefaultSmartBatchMaxBytes is the default for SmartBatch.MaxBytes. It is
+	// the default batch_size_warn_threshold of Cassandra.
+	DefaultSmartBatchMaxBytes = 5 * 1024
+
+	// DefaultSmartBatchConcurrency is the default for SmartBatch.Concurrency.
+	DefaultSmartBatchConcurrency = 4
+)
+
+// SmartBatch is a batch of any number of INSERT, UPDATE, and DELETE
+// statements that runs as Batches of one partition each, within limits.
+//
+// Statements are grouped by table and partition key, found with the table
+// metadata from the terms of an INSERT or the equality relations of a WHERE
+// clause. Statements whose partition is not found, like those with IN
+// relations, are grouped together. Each group runs as Batches of at most
+// MaxStatements statements and MaxBytes bytes, one after another in the order
+// the statements were added, but groups run concurrently. Only a group that
+// fits in one Batch is atomic, so limit conditional statements to one Batch per
+// partition.
+type SmartBatch struct {
+	// Keyspace is the keyspace of statements that do not name one. If empty,
+	// their partitions are not found.
+	Keyspace string
+
+	// MaxStatements is the most statements in a Batch, or 0 for no limit.
+	MaxStatements int
+
+	// MaxBytes is the most bytes in a Batch, or 0 for no limit. The size of a
+	// statement is estimated as the length of its CQL and the sizes of its
+	// arguments. A statement larger than MaxBytes runs alone.
+	MaxBytes int
+
+	// Concurrency is the most Batches that run at once. Less than 1 is 1.
+	Concurrency int
+
+	s       Session
+	kind    BatchKind
+	entries []BatchEntry
+}
+
+// NewSmartBatch returns a new SmartBatch of kind through s with the default
+// limits.
+func NewSmartBatch(s Session, kind BatchKind) *SmartBatch {
+	return &SmartBatch{
+		MaxStatements: DefaultSmartBatchMaxStatements,
+		MaxBytes:      DefaultSmartBatchMaxBytes,
+		Concurrency:   DefaultSmartBatchConcurrency,
+		s:             s,
+		kind:          kind,
+	}
+}
+
+// Add adds the query for statement and arguments.
+func (b *SmartBatch) Add(statement string, arguments ...interface{}) {
+	b.entries = append(b.entries, BatchEntry{Statement: statement, Arguments: arguments})
+}
+
+// Exec executes the statements. If any Batch fails, it returns a
+// *SmartBatchError after the others finish.
+func (b *SmartBatch) Exec() error {
+	return b.ExecContext(context.Background())
+}
+
+// ExecContext is like Exec but uses ctx for the queries. Batches that have not
+// started when ctx is done fail with its error.
+func (b *SmartBatch) ExecContext(ctx context.Context) error {
+	var gs = b.groups(ctx)
+	var bs = flatten(gs)
+	var errs = make([]error, len(bs))
+	var concurrency = b.Concurrency
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	var sem = make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	var first int
+
+	for _, g := range gs {
+		wg.Add(1)
+
+		go func(first int) {
+			defer wg.Done()
+
+			for i, es := range g {
+				if err := ctx.Err(); err != nil {
+					errs[first+i] = err
+
+					continue
+				}
+
+				sem <- struct{}{}
+
+				var bt = b.s.Batch(b.kind)
+
+				for _, e := range es {
+					bt.Add(e.Statement, e.Arguments...)
+				}
+
+				errs[first+i] = bt.ExecContext(ctx)
+				<-sem
+			}
+		}(first)
+
+		first += len(g)
+	}
+
+	wg.Wait()
+
+	var e = &SmartBatchError{Batches: len(bs)}
+
+	for i, err := range errs {
+		if err != nil {
+			e.Errors = append(e.Errors, &BatchError{Statements: bs[i], Err: err})
+		}
+	}
+
+	if len(e.Errors) == 0 {
+		return nil
+	}
+
+	return e
+}
+
+// split returns the statements grouped and split into Batches.
+func (b *SmartBatch) split(ctx context.Context) [][]BatchEntry {
+	return flatten(b.groups(ctx))
+}
+
+// groups returns the Batches of each group, in order.
+func (b *SmartBatch) groups(ctx context.Context) [][][]BatchEntry {
+	var keys []string
+	var groups = map[string][]BatchEntry{}
+	var tables = map[string]*TableInfo{}
+
+	for _, e := range b.entries {
+		var k = b.partition(ctx, tables, e)
+
+		if _, ok := groups[k]; !ok {
+			keys = append(keys, k)
+		}
+
+		groups[k] = append(groups[k], e)
+	}
+
+	var gs [][][]BatchEntry
+
+	for _, k := range keys {
+		var bs [][]BatchEntry
+		var current []BatchEntry
+		var bytes int
+
+		for _, e := range groups[k] {
+			var n = entrySize(e)
+
+			if len(current) > 0 && (b.MaxStatements > 0 && len(current) >= b.MaxStatements || b.MaxBytes > 0 && bytes+n > b.MaxBytes) {
+				bs = append(bs, current)
+				current, bytes = nil, 0
+			}
+
+			current = append(current, e)
+			bytes += n
+		}
+
+		gs = append(gs, append(bs, current))
+	}
+
+	return gs
+}
+
+// flatten returns the Batches of gs in order.
+func flatten(gs [][][]BatchEntry) [][]BatchEntry {
+	var bs [][]BatchEntry
+
+	for _, g := range gs {
+		bs = append(bs, g...)
+	}
+
+	return bs
+}
+
+// partition returns the group key of e: its table and routing key, or empty if
+// they are not found. It caches table metadata in tables.
+func (b *SmartBatch) partition(ctx context.Context, tables map[string]*TableInfo, e BatchEntry) string {
+	var keyspace, table, terms, ok = writeTarget(e.Statement)
+
+	if !ok {
+		return ""
+	}
+
+	if keyspace == "" {
+		if keyspace = b.Keyspace; keyspace == "" {
+			return ""
+		}
+	}
+
+	var name = keyspace + "." + table
+	var t, cached = tables[name]
+
+	if !cached {
+		if info, err := b.s.TableContext(ctx, keyspace, table); err == nil {
+			t = &info
+		}
+
+		tables[name] = t
+	}
+
+	if t == nil {
+		return ""
+	}
+
+	vs, ok := partitionValues(*t, terms, e.Arguments)
+
+	if !ok {
+		return ""
+	}
+
+	k, err := routingKey(*t, vs)
+
+	if err != nil {
+		return ""
+	}
+
+	return name + "\x00" + string(k)
+}
+
+// entrySize returns the estimated size of e in bytes.
+func entrySize(e BatchEntry) int {
+	var n = len(e.Statement)
+
+	for _, a := range e.Arguments {
+		n += size(reflect.ValueOf(a))
+	}
+
+	return n
+}
+
+// size returns the estimated size of v in bytes.
+func size(v reflect.Value) int {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return 0
+
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+
+		return size(v.Elem())
+
+	case reflect.String:
+		return v.Len()
+
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Len()
+		}
+
+		var n int
+
+		for i := 0; i < v.Len(); i++ {
+			n += size(v.Index(i))
+		}
+
+		return n
+
+	case reflect.Map:
+		var n int
+		var i = v.MapRange()
+
+		for i.Next() {
+			n += size(i.Key()) + size(i.Value())
+		}
+
+		return n
+	}
+
+	return int(v.Type().Size())
+}
+
+// BatchError is the error of a Batch of a SmartBatch.
+type BatchError struct {
+	Statements []BatchEntry
+	Err        error
+}
+
+func (e *BatchError) Error() string {
+	return fmt.Sprintf("gockle: batch of %v statements failed: %v", len(e.Statements), e.Err)
+}
+
+func (e *BatchError) Unwrap() error {
+	return e.Err
+}
+
+// SmartBatchError is the error of a SmartBatch. It matches the errors of its
+// Batches with errors.Is and errors.As.
+type SmartBatchError struct {
+	// Batches is the number of Batches.
+	Batches int
+
+	// Errors are the errors of the Batches that failed, in order.
+	Errors []*BatchError
+}
+
+func (e *SmartBatchError) Error() string {
+	return fmt.Sprintf("gockle: %v of %v batches failed, the first: %v", len(e.Errors), e.Batches, e.Errors[0].Err)
+}
+
+func (e *SmartBatchError) Unwrap() []error {
+	var errs = make([]error, len(e.Errors))
+
+	for i, err := range e.Errors {
+		errs[i] = err
+	}
+
+	return errs
+}
@@ -0,0 +1,230 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const pairCreate = "create table gockle_test.pair(a int, b text, c int, primary key ((a, b), c))"
+
+func TestSmartBatchSplit(t *testing.T) {
+	var s = newMemorySession(t, pairCreate)
+
+	defer s.Close()
+
+	var statements = func(bs [][]BatchEntry) [][]string {
+		var ss [][]string
+
+		for _, b := range bs {
+			var s []string
+
+			for _, e := range b {
+				s = append(s, e.Statement)
+			}
+
+			ss = append(ss, s)
+		}
+
+		return ss
+	}
+
+	var b = NewSmartBatch(s, BatchUnlogged)
+
+	b.MaxStatements = 2
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 1, 1)
+	b.Add("insert into gockle_test.pair (a, b, c) values (1, 'x', 1)")
+	b.Add("update gockle_test.test set n = 2 where id = 1")
+	b.Add("delete from gockle_test.test where id = ?", 1)
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 2, 1)
+	b.Add("update gockle_test.pair set c = 2 where a = ? and b = ?", 1, "x")
+	b.Add("insert into gockle_test.pair (a, b, c) values (1, 'y', 1)")
+	b.Add("delete from gockle_test.test where id in (1, 2)")
+	b.Add("insert into test (id, n) values (2, 2)")
+
+	var e = [][]string{
+		{"insert into gockle_test.test (id, n) values (?, ?)", "update gockle_test.test set n = 2 where id = 1"},
+		{"delete from gockle_test.test where id = ?"},
+		{"insert into gockle_test.pair (a, b, c) values (1, 'x', 1)", "update gockle_test.pair set c = 2 where a = ? and b = ?"},
+		{"insert into gockle_test.test (id, n) values (?, ?)"},
+		{"insert into gockle_test.pair (a, b, c) values (1, 'y', 1)"},
+		{"delete from gockle_test.test where id in (1, 2)", "insert into test (id, n) values (2, 2)"},
+	}
+
+	if a := statements(b.split(context.Background())); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual batches %v, expected %v", a, e)
+	}
+
+	b.Keyspace = "gockle_test"
+
+	e[3] = append(e[3], "insert into test (id, n) values (2, 2)")
+	e[5] = e[5][:1]
+
+	if a := statements(b.split(context.Background())); !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual batches %v, expected %v", a, e)
+	}
+
+	b = NewSmartBatch(s, BatchUnlogged)
+	b.MaxBytes = 140
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 1, 1)
+	b.Add("update gockle_test.test set n = ? where id = ?", 2, 1)
+	b.Add("update gockle_test.test set n = ? where id = ?", 3, 1)
+
+	if a, e := len(b.split(context.Background())), 2; a != e {
+		t.Errorf("Actual batches %v, expected %v", a, e)
+	}
+
+	b.MaxBytes = 10
+
+	if a, e := len(b.split(context.Background())), 3; a != e {
+		t.Errorf("Actual batches %v, expected %v", a, e)
+	}
+}
+
+func TestSmartBatchExec(t *testing.T) {
+	var mu sync.Mutex
+	var running, max int
+
+	var s = Chain(newMemorySession(t), func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			if o.Kind != OperationBatchExec {
+				return next(ctx, o)
+			}
+
+			mu.Lock()
+			running++
+
+			if running > max {
+				max = running
+			}
+
+			mu.Unlock()
+
+			time.Sleep(10 * time.Millisecond)
+
+			var err = next(ctx, o)
+
+			mu.Lock()
+			running--
+			mu.Unlock()
+
+			return err
+		}
+	})
+
+	defer s.Close()
+
+	var b = NewSmartBatch(s, BatchLogged)
+
+	b.Concurrency = 2
+
+	for i := 0; i < 10; i++ {
+		b.Add("insert into gockle_test.test (id, n) values (?, ?)", i, i)
+	}
+
+	if err := b.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if a, e := max, 2; a != e {
+		t.Errorf("Actual concurrency %v, expected %v", a, e)
+	}
+
+	if rs, err := s.ScanMapSlice("select * from gockle_test.test"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if a, e := len(rs), 10; a != e {
+		t.Errorf("Actual rows %v, expected %v", a, e)
+	}
+
+	b = NewSmartBatch(s, BatchLogged)
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 10, 10)
+	b.Add("insert into gockle_test.invalid (id, n) values (?, ?)", 11, 11)
+
+	var err = b.Exec()
+	var be *SmartBatchError
+
+	if !errors.As(err, &be) {
+		t.Fatalf("Actual error %v, expected *SmartBatchError", err)
+	}
+
+	if a, e := be.Batches, 2; a != e {
+		t.Errorf("Actual batches %v, expected %v", a, e)
+	}
+
+	if a, e := len(be.Errors), 1; a != e {
+		t.Fatalf("Actual errors %v, expected %v", a, e)
+	}
+
+	if a, e := be.Errors[0].Statements, b.entries[1:]; !reflect.DeepEqual(a, e) {
+		t.Errorf("Actual statements %v, expected %v", a, e)
+	}
+
+	if !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("Actual error %v, expected %v", err, ErrTableNotFound)
+	}
+
+	if a := err.Error(); !strings.HasPrefix(a, "gockle: 1 of 2 batches failed") {
+		t.Errorf("Actual message %v, expected 1 of 2 batches failed", a)
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	cancel()
+
+	if err := b.ExecContext(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Actual error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestSmartBatchOrder(t *testing.T) {
+	var mu sync.Mutex
+	var ns []interface{}
+
+	var s = Chain(newMemorySession(t), func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			if o.Kind == OperationBatchExec {
+				mu.Lock()
+				ns = append(ns, o.Batch[0].Arguments[1])
+				mu.Unlock()
+			}
+
+			return next(ctx, o)
+		}
+	})
+
+	defer s.Close()
+
+	var b = NewSmartBatch(s, BatchUnlogged)
+	var e []interface{}
+
+	b.MaxStatements = 1
+	b.Concurrency = 8
+
+	for n := 0; n < 8; n++ {
+		b.Add("insert into gockle_test.test (id, n) values (?, ?)", 1, n)
+		e = append(e, n)
+	}
+
+	if err := b.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if !reflect.DeepEqual(ns, e) {
+		t.Errorf("Actual order %v, expected %v", ns, e)
+	}
+
+	var n int
+
+	if err := s.Scan("select n from gockle_test.test where id = 1", []interface{}{&n}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if n != 7 {
+		t.Errorf("Actual n %v, expected 7", n)
+	}
+}
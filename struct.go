@@ -0,0 +1,307 @@
+package gockle
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/gocql/gocql"
+)
+
+// structField is a struct field that a column binds to.
+type structField struct {
+	name  string
+	index []int
+	t     reflect.Type
+}
+
+// structPlan maps column names to the fields of a struct type. Exported fields
+// bind to the column named by their cql tag, or to the snake case of their
+// name. The tag "-" skips a field. The fields of untagged embedded structs bind
+// as if they were fields of the outer struct, which wins for a name found in
+// both.
+type structPlan struct {
+	t      reflect.Type
+	fields map[string]*structField
+
+	// checks maps a column name and type to the error for its field.
+	checks sync.Map
+}
+
+var structPlans sync.Map
+
+// planOf returns the cached structPlan for struct type t.
+func planOf(t reflect.Type) *structPlan {
+	if p, ok := structPlans.Load(t); ok {
+		return p.(*structPlan)
+	}
+
+	var p = &structPlan{t: t, fields: map[string]*structField{}}
+
+	p.add(t, nil, "")
+
+	var actual, _ = structPlans.LoadOrStore(t, p)
+
+	return actual.(*structPlan)
+}
+
+func (p *structPlan) add(t reflect.Type, index []int, prefix string) {
+	var embedded []reflect.StructField
+
+	for i := 0; i < t.NumField(); i++ {
+		var f = t.Field(i)
+		var tag, tagged = f.Tag.Lookup("cql")
+
+		if tag = strings.Split(tag, ",")[0]; tag == "-" {
+			continue
+		}
+
+		if f.Anonymous && tag == "" {
+			var et = f.Type
+
+			if et.Kind() == reflect.Ptr {
+				et = et.Elem()
+			}
+
+			if et.Kind() == reflect.Struct {
+				embedded = append(embedded, f)
+
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+
+		var name = tag
+
+		if !tagged || name == "" {
+			name = snakeCase(f.Name)
+		}
+
+		if _, ok := p.fields[name]; !ok {
+			p.fields[name] = &structField{name: prefix + f.Name, index: fieldIndex(index, i), t: f.Type}
+		}
+	}
+
+	for _, f := range embedded {
+		var et = f.Type
+
+		if et.Kind() == reflect.Ptr {
+			et = et.Elem()
+		}
+
+		p.add(et, fieldIndex(index, f.Index[0]), prefix+f.Name+".")
+	}
+}
+
+func fieldIndex(index []int, i int) []int {
+	return append(index[:len(index):len(index)], i)
+}
+
+// bind returns the addresses of the fields of struct value v for columns. It
+// allocates nil embedded struct pointers on the way.
+func (p *structPlan) bind(columns []gocql.ColumnInfo, v reflect.Value) ([]interface{}, error) {
+	var dest = make([]interface{}, len(columns))
+
+	for i, c := range columns {
+		var f, ok = p.fields[c.Name]
+
+		if !ok {
+			return nil, fmt.Errorf("gockle: column %v of type %v has no field in %v", columnName(c), c.TypeInfo, p.t)
+		}
+
+		if err := p.check(c, f); err != nil {
+			return nil, err
+		}
+
+		var fv = v
+
+		for _, j := range f.index {
+			if fv.Kind() == reflect.Ptr {
+				if fv.IsNil() {
+					if !fv.CanSet() {
+						return nil, fmt.Errorf("gockle: field %v.%v is behind a nil pointer to an unexported struct", p.t, f.name)
+					}
+
+					fv.Set(reflect.New(fv.Type().Elem()))
+				}
+
+				fv = fv.Elem()
+			}
+
+			fv = fv.Field(j)
+		}
+
+		dest[i] = fv.Addr().Interface()
+	}
+
+	return dest, nil
+}
+
+// check returns an error if column c does not fit field f.
+func (p *structPlan) check(c gocql.ColumnInfo, f *structField) error {
+	var key = c.Name + " " + fmt.Sprint(c.TypeInfo)
+
+	if err, ok := p.checks.Load(key); ok {
+		return err.(*structError).err
+	}
+
+	var err error
+
+	if !fits(c.TypeInfo, f.t) {
+		err = fmt.Errorf("gockle: column %v of type %v does not fit field %v.%v of type %v", columnName(c), c.TypeInfo, p.t, f.name, f.t)
+	}
+
+	p.checks.Store(key, &structError{err: err})
+
+	return err
+}
+
+// fits returns whether the zero value of t unmarshals into a value of type ft.
+// Types it cannot tell about fit, and scanning reports their errors.
+func fits(t gocql.TypeInfo, ft reflect.Type) (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = true
+		}
+	}()
+
+	var b, err = gocql.Marshal(t, reflect.ValueOf(t.New()).Elem().Interface())
+
+	if err != nil || b == nil {
+		return true
+	}
+
+	return gocql.Unmarshal(t, b, reflect.New(ft).Interface()) == nil
+}
+
+// structError holds a possibly nil error in a sync.Map.
+type structError struct {
+	err error
+}
+
+func columnName(c gocql.ColumnInfo) string {
+	if c.Keyspace == "" || c.Table == "" {
+		return c.Name
+	}
+
+	return c.Keyspace + "." + c.Table + "." + c.Name
+}
+
+// structValue returns the struct that dest points to.
+func structValue(dest interface{}) (reflect.Value, error) {
+	var v = reflect.ValueOf(dest)
+
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, fmt.Errorf("gockle: destination %T is not a pointer to a struct", dest)
+	}
+
+	return v.Elem(), nil
+}
+
+// structScan puts the row that scan scans into dest, whose fields are bound to
+// columns.
+func structScan(columns []gocql.ColumnInfo, dest interface{}, scan func(dest []interface{}) bool) (bool, error) {
+	var v, err = structValue(dest)
+
+	if err != nil {
+		return false, err
+	}
+
+	var fields []interface{}
+
+	if fields, err = planOf(v.Type()).bind(columns, v); err != nil {
+		return false, err
+	}
+
+	return scan(fields), nil
+}
+
+// scanStruct puts the first row of i in dest and closes i.
+func scanStruct(i Iterator, dest interface{}) error {
+	var ok = i.StructScan(dest)
+
+	if err := i.Close(); err != nil {
+		return err
+	}
+
+	if !ok {
+		return gocql.ErrNotFound
+	}
+
+	return nil
+}
+
+// scanStructSlice puts the rows of i in the slice that dest points to and
+// closes i. The slice elements are structs or pointers to structs.
+func scanStructSlice(i Iterator, dest interface{}) error {
+	var v = reflect.ValueOf(dest)
+
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Slice {
+		i.Close()
+
+		return fmt.Errorf("gockle: destination %T is not a pointer to a slice", dest)
+	}
+
+	var st = v.Elem().Type()
+	var et, pointers = st.Elem(), st.Elem().Kind() == reflect.Ptr
+
+	if pointers {
+		et = et.Elem()
+	}
+
+	if et.Kind() != reflect.Struct {
+		i.Close()
+
+		return fmt.Errorf("gockle: destination %T is not a pointer to a slice of structs", dest)
+	}
+
+	var s = reflect.MakeSlice(st, 0, 0)
+
+	for {
+		var e = reflect.New(et)
+
+		if !i.StructScan(e.Interface()) {
+			break
+		}
+
+		if pointers {
+			s = reflect.Append(s, e)
+		} else {
+			s = reflect.Append(s, e.Elem())
+		}
+	}
+
+	if err := i.Close(); err != nil {
+		return err
+	}
+
+	v.Elem().Set(s)
+
+	return nil
+}
+
+// snakeCase returns name in snake case. Acronyms stay together, so UserID is
+// user_id and HTTPServer is http_server.
+func snakeCase(name string) string {
+	var rs = []rune(name)
+	var b strings.Builder
+
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) || (i+1 < len(rs) && unicode.IsLower(rs[i+1]) && unicode.IsUpper(rs[i-1]))) {
+				b.WriteByte('_')
+			}
+
+			r = unicode.ToLower(r)
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package gockle
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+type structBase struct {
+	ID int
+}
+
+type structPerson struct {
+	structBase
+
+	Name    string `cql:"user_name"`
+	Age     *int
+	ZipCode string
+	Ignored int `cql:"-"`
+}
+
+func TestStruct(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	var exec = func(q string) {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	exec(ksDropIf)
+	exec(ksCreate)
+
+	defer exec(ksDrop)
+
+	testStruct(t, s)
+}
+
+func TestMemorySessionStruct(t *testing.T) {
+	testStruct(t, newMemorySession(t))
+}
+
+func testStruct(t *testing.T, s Session) {
+	for _, st := range []string{
+		"create table gockle_test.people(id int primary key, user_name text, age int, zip_code text)",
+		"insert into gockle_test.people (id, user_name, age, zip_code) values (1, 'a', 30, '100')",
+		"insert into gockle_test.people (id, user_name) values (2, 'b')",
+	} {
+		if err := s.Exec(st); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	var age = 30
+	var e = []structPerson{
+		{structBase: structBase{ID: 1}, Name: "a", Age: &age, ZipCode: "100"},
+		{structBase: structBase{ID: 2}, Name: "b"},
+	}
+
+	var p structPerson
+
+	if err := s.ScanStruct("select * from gockle_test.people where id = ?", &p, 1); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if !reflect.DeepEqual(p, e[0]) {
+		t.Errorf("Actual struct %+v, expected %+v", p, e[0])
+	}
+
+	if err := s.Query("select * from gockle_test.people where id = ?", 3).ScanStruct(&p); err != gocql.ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, gocql.ErrNotFound)
+	}
+
+	var ps []structPerson
+
+	if err := s.ScanStructSlice("select * from gockle_test.people where id in (1, 2)", &ps); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if !reflect.DeepEqual(ps, e) {
+		t.Errorf("Actual structs %+v, expected %+v", ps, e)
+	}
+
+	var pps []*structPerson
+
+	if err := s.Query("select id, user_name from gockle_test.people where id = 2").ScanStructSlice(&pps); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if len(pps) != 1 || !reflect.DeepEqual(*pps[0], e[1]) {
+		t.Errorf("Actual structs %v, expected [%+v]", pps, e[1])
+	}
+
+	var i = s.ScanIterator("select id from gockle_test.people where id = 1")
+	var id struct{ ID int64 }
+
+	if !i.StructScan(&id) {
+		t.Error("Actual more false, expected true")
+	} else if id.ID != 1 {
+		t.Errorf("Actual id %v, expected 1", id.ID)
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	var unmapped struct{ ID int }
+
+	if err := s.ScanStruct("select id, user_name from gockle_test.people where id = 1", &unmapped); err == nil || !strings.Contains(err.Error(), "column gockle_test.people.user_name of type varchar has no field") {
+		t.Errorf("Actual error %v, expected unmapped column", err)
+	}
+
+	var mismatched struct {
+		UserName int
+	}
+
+	if err := s.ScanStruct("select user_name from gockle_test.people where id = 1", &mismatched); err == nil || !strings.Contains(err.Error(), "does not fit field struct { UserName int }.UserName of type int") {
+		t.Errorf("Actual error %v, expected mismatched column", err)
+	}
+
+	if err := s.ScanStruct("select * from gockle_test.people", p); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	if err := s.ScanStructSlice("select * from gockle_test.people", &p); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	for n, e := range map[string]string{
+		"ID":         "id",
+		"Name":       "name",
+		"UserID":     "user_id",
+		"HTTPServer": "http_server",
+		"Address2":   "address2",
+		"ZipCode":    "zip_code",
+	} {
+		if a := snakeCase(n); a != e {
+			t.Errorf("Actual snake case %v, expected %v", a, e)
+		}
+	}
+}
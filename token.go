@@ -0,0 +1,38 @@
+package gockle
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/kerkerj/gockle/internal/partitioner"
+)
+
+// Partitioners that Session.Token supports, as system.local names them.
+const (
+	// PartitionerMurmur3 has int64 tokens. It is the default.
+	PartitionerMurmur3 = "org.apache.cassandra.dht.Murmur3Partitioner"
+
+	// PartitionerRandom has tokens from 0 to 2^127.
+	PartitionerRandom = "org.apache.cassandra.dht.RandomPartitioner"
+)
+
+// token returns the token by the partitioner name of the partition of t with
+// the partition key values.
+func token(name string, t TableInfo, values []interface{}) (*big.Int, error) {
+	var k, err = routingKey(t, values)
+
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case strings.HasSuffix(name, "Murmur3Partitioner"):
+		return big.NewInt(partitioner.Murmur3(k)), nil
+
+	case strings.HasSuffix(name, "RandomPartitioner"):
+		return partitioner.Random(k), nil
+	}
+
+	return nil, fmt.Errorf("gockle: partitioner %v not supported", name)
+}
@@ -0,0 +1,73 @@
+package gockle
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/kerkerj/gockle/internal/partitioner"
+)
+
+func TestSessionToken(t *testing.T) {
+	var s = newSession(t)
+
+	defer s.Close()
+
+	for _, q := range []string{ksDropIf, ksCreate, tabCreate, pairCreate} {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	defer s.Exec(ksDrop)
+
+	testToken(t, s)
+}
+
+func TestMemorySessionToken(t *testing.T) {
+	var s = newMemorySession(t, pairCreate)
+
+	defer s.Close()
+
+	testToken(t, s)
+}
+
+func testToken(t *testing.T, s Session) {
+	if a, err := s.Token("gockle_test", "test", 1); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := big.NewInt(-4069959284402364209); a.Cmp(e) != 0 {
+		t.Errorf("Actual token %v, expected %v", a, e)
+	}
+
+	if a, err := s.Token("gockle_test", "pair", 1, "x"); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := big.NewInt(partitioner.Murmur3([]byte{0, 4, 0, 0, 0, 1, 0, 0, 1, 'x', 0})); a.Cmp(e) != 0 {
+		t.Errorf("Actual token %v, expected %v", a, e)
+	}
+
+	for _, vs := range [][]interface{}{{}, {1}, {"x", 1}, {1, nil}} {
+		if _, err := s.Token("gockle_test", "pair", vs...); err == nil {
+			t.Errorf("Actual no error, expected error for %v", vs)
+		}
+	}
+
+	if _, err := s.Token("gockle_test", "invalid", 1); !errors.Is(err, ErrTableNotFound) {
+		t.Errorf("Actual error %v, expected %v", err, ErrTableNotFound)
+	}
+
+	var tb, err = s.Table("gockle_test", "test")
+
+	if err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if a, err := token(PartitionerRandom, tb, []interface{}{1}); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	} else if e := partitioner.Random([]byte{0, 0, 0, 1}); a.Cmp(e) != 0 {
+		t.Errorf("Actual token %v, expected %v", a, e)
+	}
+
+	if _, err := token("org.apache.cassandra.dht.ByteOrderedPartitioner", tb, []interface{}{1}); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+}
@@ -0,0 +1,440 @@
+package gockle
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/kerkerj/gockle/internal/cql"
+)
+
+// Attributes of the spans of Trace, after the OpenTelemetry semantic
+// conventions for databases.
+const (
+	// AttributeSystem is "cassandra".
+	AttributeSystem = "db.system"
+
+	// AttributeOperation is the OperationKind, like "Exec".
+	AttributeOperation = "db.operation"
+
+	// AttributeStatement is the statement, or the first statement of a batch,
+	// with literals replaced by bind markers.
+	AttributeStatement = "db.statement"
+
+	// AttributeKeyspace and AttributeTable are the keyspace and table of the
+	// statement or metadata, if known. AttributeKeyspace is not set for
+	// statements with unqualified tables, even if the session has a keyspace.
+	AttributeKeyspace = "db.cassandra.keyspace"
+	AttributeTable    = "db.cassandra.table"
+
+	// AttributeConsistency is the consistency level set through Query or
+	// Batch, like "QUORUM".
+	AttributeConsistency = "db.cassandra.consistency_level"
+
+	// AttributePageSize is the page size set through Query.
+	AttributePageSize = "db.cassandra.page_size"
+
+	// AttributePages is the number of pages an Iterator read, for
+	// OperationIterClose.
+	AttributePages = "db.cassandra.pages"
+
+	// AttributeRows is the number of rows returned.
+	AttributeRows = "db.cassandra.rows"
+
+	// AttributeBatchSize is the number of statements of a batch.
+	AttributeBatchSize = "db.cassandra.batch_size"
+
+	// AttributeCoordinator is the host and port of the coordinator of the
+	// statement, if known.
+	AttributeCoordinator = "db.cassandra.coordinator"
+
+	// AttributeErrorType is the kind of the error, like "timeout" for
+	// ErrTimeout, "not_found" for ErrNotFound, "canceled", "cassandra" for other
+	// errors from Cassandra, or "other".
+	AttributeErrorType = "error.type"
+)
+
+// Tracer starts spans, like the Tracer of OpenTelemetry. Adapt the tracer of a
+// tracing library to use it with Trace.
+type Tracer interface {
+	// Start starts a span named name, the child of the span of ctx if there is
+	// one, and returns a context with the new span.
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is a span started by a Tracer.
+type Span interface {
+	// SetAttribute sets the attribute key to value, a string or an int.
+	SetAttribute(key string, value interface{})
+
+	// RecordError records that the span failed with err.
+	RecordError(err error)
+
+	// End ends the span.
+	End()
+}
+
+// Trace returns Middleware that makes a span with t for each Operation, named
+// after the Operation and its table, like "Exec ks.t". The spans are children
+// of the span of the context of the Operation. That of Iterator.Close, which
+// has no context, is a sibling of the span of the Query that made the Iterator.
+func Trace(t Tracer) Middleware {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, o *Operation) error {
+			var parent = ctx
+			var counted, _ = o.Iterator.(*countingIterator)
+
+			if o.Kind == OperationIterClose && counted != nil {
+				parent = counted.ctx
+			}
+
+			var keyspace, table = operationTarget(o)
+			var name = o.Kind.String()
+
+			if table != "" {
+				name += " " + strings.TrimPrefix(keyspace+"."+table, ".")
+			}
+
+			var spanCtx, span = t.Start(parent, name)
+			var sink *coordinatorSink
+
+			spanCtx, sink = withCoordinatorSink(spanCtx)
+
+			span.SetAttribute(AttributeSystem, "cassandra")
+			span.SetAttribute(AttributeOperation, o.Kind.String())
+
+			if s, ok := sanitize(operationStatement(o)); ok {
+				span.SetAttribute(AttributeStatement, s)
+			}
+
+			if keyspace != "" {
+				span.SetAttribute(AttributeKeyspace, keyspace)
+			}
+
+			if table != "" {
+				span.SetAttribute(AttributeTable, table)
+			}
+
+			if o.HasConsistency {
+				span.SetAttribute(AttributeConsistency, o.Consistency.String())
+			}
+
+			if o.PageSize > 0 {
+				span.SetAttribute(AttributePageSize, o.PageSize)
+			}
+
+			if o.Kind == OperationBatchExec || o.Kind == OperationBatchExecTx {
+				span.SetAttribute(AttributeBatchSize, len(o.Batch))
+			}
+
+			var err = next(spanCtx, o)
+
+			if o.Kind == OperationIter && err == nil && o.Iterator != nil {
+				o.Iterator = &countingIterator{Iterator: o.Iterator, ctx: ctx, pages: 1}
+			}
+
+			if o.Kind == OperationIterClose && counted != nil {
+				span.SetAttribute(AttributePages, counted.pages)
+			}
+
+			if rows, ok := operationRows(o, counted, err); ok {
+				span.SetAttribute(AttributeRows, rows)
+			}
+
+			var c = errorCoordinator(err)
+
+			if c == "" {
+				c = sink.get()
+			}
+
+			if c != "" {
+				span.SetAttribute(AttributeCoordinator, c)
+			}
+
+			if err != nil {
+				span.RecordError(err)
+				span.SetAttribute(AttributeErrorType, errorType(err))
+			}
+
+			span.End()
+
+			return err
+		}
+	}
+}
+
+// operationStatement returns the statement of o, or the first of its batch.
+func operationStatement(o *Operation) string {
+	if len(o.Batch) > 0 {
+		return o.Batch[0].Statement
+	}
+
+	return o.Statement
+}
+
+// operationTarget returns the keyspace and table of o, if known. The table of
+// a statement is the name after FROM, INTO, UPDATE, or TRUNCATE. The keyspace
+// is empty for statements with unqualified tables.
+func operationTarget(o *Operation) (keyspace, table string) {
+	if o.Keyspace != "" || o.Table != "" {
+		return o.Keyspace, o.Table
+	}
+
+	var ts, err = cql.Lex(operationStatement(o))
+
+	if err != nil {
+		return "", ""
+	}
+
+	var name = func(t cql.Token) bool {
+		return t.Kind == cql.Ident || t.Kind == cql.QuotedIdent
+	}
+
+	var keyword string
+
+	switch {
+	case ts[0].Is("select") || ts[0].Is("delete"):
+		keyword = "from"
+
+	case ts[0].Is("insert"):
+		keyword = "into"
+
+	case ts[0].Is("update") || ts[0].Is("truncate"):
+		keyword = ts[0].Text
+
+	default:
+		return "", ""
+	}
+
+	for _, i := range cql.TopLevel(ts) {
+		if !ts[i].Is(keyword) {
+			continue
+		}
+
+		i++
+
+		if keyword == "truncate" && ts[i].Is("table") {
+			i++
+		}
+
+		if !name(ts[i]) {
+			return "", ""
+		}
+
+		if ts[i+1].Is(".") && name(ts[i+2]) {
+			return ts[i].Text, ts[i+2].Text
+		}
+
+		return "", ts[i].Text
+	}
+
+	return "", ""
+}
+
+// operationRows returns the number of rows returned by o, for the Operations
+// that return rows, if it succeeded. counted is the Iterator of
+// OperationIterClose.
+func operationRows(o *Operation, counted *countingIterator, err error) (int, bool) {
+	if err != nil && o.Kind != OperationIterClose {
+		return 0, false
+	}
+
+	switch o.Kind {
+	case OperationScan, OperationScanMap, OperationScanStruct:
+		return 1, true
+
+	case OperationScanMapSlice, OperationBatchExecTx:
+		return len(o.Rows), true
+
+	case OperationScanStructSlice:
+		var v = reflect.ValueOf(o.Struct)
+
+		if v.Kind() == reflect.Pointer && v.Elem().Kind() == reflect.Slice {
+			return v.Elem().Len(), true
+		}
+
+	case OperationIterClose:
+		if counted != nil {
+			return counted.rows, true
+		}
+	}
+
+	return 0, false
+}
+
+// sanitize returns statement with its literals replaced by bind markers, and
+// whether it lexes.
+func sanitize(statement string) (string, bool) {
+	if statement == "" {
+		return "", false
+	}
+
+	var ts, err = cql.Lex(statement)
+
+	if err != nil {
+		return "", false
+	}
+
+	var b strings.Builder
+	var last = 0
+
+	for _, t := range ts {
+		switch t.Kind {
+		case cql.String, cql.Integer, cql.Float, cql.Blob, cql.UUID:
+			b.WriteString(statement[last:t.Pos])
+			b.WriteString("?")
+			last = t.End
+		}
+	}
+
+	b.WriteString(statement[last:])
+
+	return b.String(), true
+}
+
+// countingIterator counts the rows and pages that an Iterator returned by an
+// OperationIter reads. ctx is the context of the OperationIter.
+type countingIterator struct {
+	Iterator
+
+	ctx   context.Context
+	rows  int
+	pages int
+}
+
+// count counts a row if more, and a page if the Iterator is about to switch.
+func (i *countingIterator) count(scan func() bool) bool {
+	if i.Iterator.WillSwitchPage() {
+		i.pages++
+	}
+
+	var more = scan()
+
+	if more {
+		i.rows++
+	}
+
+	return more
+}
+
+func (i *countingIterator) Scan(results ...interface{}) bool {
+	return i.count(func() bool {
+		return i.Iterator.Scan(results...)
+	})
+}
+
+func (i *countingIterator) ScanMap(results map[string]interface{}) bool {
+	return i.count(func() bool {
+		return i.Iterator.ScanMap(results)
+	})
+}
+
+func (i *countingIterator) StructScan(dest interface{}) bool {
+	return i.count(func() bool {
+		return i.Iterator.StructScan(dest)
+	})
+}
+
+func (i *countingIterator) SliceMap() ([]map[string]interface{}, error) {
+	var ms, err = i.Iterator.SliceMap()
+
+	i.rows += len(ms)
+
+	return ms, err
+}
+
+// SpanRecorder is a Tracer that records spans in memory, for tests.
+type SpanRecorder struct {
+	mu    sync.Mutex
+	spans []*RecordedSpan
+}
+
+// RecordedSpan is a span recorded by a SpanRecorder.
+type RecordedSpan struct {
+	// ID is the position of the span in the order spans started, from 1.
+	ID int
+
+	// ParentID is the ID of the parent span, or 0 if there is none.
+	ParentID int
+
+	Name       string
+	Attributes map[string]interface{}
+
+	// Err is the last error recorded.
+	Err error
+
+	Ended bool
+}
+
+var _ Tracer = &SpanRecorder{}
+
+type spanRecorderKey struct{}
+
+// Start starts a span that is a child of the span of r in ctx, if any.
+func (r *SpanRecorder) Start(ctx context.Context, name string) (context.Context, Span) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var s = &RecordedSpan{ID: len(r.spans) + 1, Name: name, Attributes: map[string]interface{}{}}
+
+	if p, ok := ctx.Value(spanRecorderKey{}).(recordedSpan); ok && p.r == r {
+		s.ParentID = p.s.ID
+	}
+
+	r.spans = append(r.spans, s)
+
+	var rs = recordedSpan{r: r, s: s}
+
+	return context.WithValue(ctx, spanRecorderKey{}, rs), rs
+}
+
+// Spans returns copies of the spans in the order they started.
+func (r *SpanRecorder) Spans() []RecordedSpan {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var ss = make([]RecordedSpan, len(r.spans))
+
+	for i, s := range r.spans {
+		ss[i] = *s
+		ss[i].Attributes = map[string]interface{}{}
+
+		for k, v := range s.Attributes {
+			ss[i].Attributes[k] = v
+		}
+	}
+
+	return ss
+}
+
+// Reset forgets the spans.
+func (r *SpanRecorder) Reset() {
+	r.mu.Lock()
+	r.spans = nil
+	r.mu.Unlock()
+}
+
+// recordedSpan is the Span of s.
+type recordedSpan struct {
+	r *SpanRecorder
+	s *RecordedSpan
+}
+
+func (s recordedSpan) SetAttribute(key string, value interface{}) {
+	s.r.mu.Lock()
+	s.s.Attributes[key] = value
+	s.r.mu.Unlock()
+}
+
+func (s recordedSpan) RecordError(err error) {
+	s.r.mu.Lock()
+	s.s.Err = err
+	s.r.mu.Unlock()
+}
+
+func (s recordedSpan) End() {
+	s.r.mu.Lock()
+	s.s.Ended = true
+	s.r.mu.Unlock()
+}
@@ -0,0 +1,206 @@
+package gockle
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/gocql/gocql"
+)
+
+func TestSanitize(t *testing.T) {
+	for _, c := range []struct {
+		statement, expected string
+	}{
+		{"select * from t where a = 'secret' and b = -1.5 and c = ?", "select * from t where a = ? and b = ? and c = ?"},
+		{"insert into t (a, b) values (0x01, 5a5b1b1c-9b59-11e8-98d0-529269fb1459)", "insert into t (a, b) values (?, ?)"},
+		{"select * from t", "select * from t"},
+	} {
+		if a, ok := sanitize(c.statement); !ok {
+			t.Errorf("Actual not ok, expected ok for %q", c.statement)
+		} else if a != c.expected {
+			t.Errorf("Actual statement %q, expected %q", a, c.expected)
+		}
+	}
+
+	for _, s := range []string{"", "select 'unterminated"} {
+		if _, ok := sanitize(s); ok {
+			t.Errorf("Actual ok, expected not ok for %q", s)
+		}
+	}
+}
+
+func TestOperationTarget(t *testing.T) {
+	for _, c := range []struct {
+		statement, keyspace, table string
+	}{
+		{"select * from ks.t where id = 1", "ks", "t"},
+		{"select json * from ks.t", "ks", "t"},
+		{"select cast(n as text) from ks.t", "ks", "t"},
+		{`insert into "Tab" (id) values (1)`, "", "Tab"},
+		{"update ks.t using ttl 10 set n = 1 where id = 1", "ks", "t"},
+		{"delete m['from'] from t where id = 1", "", "t"},
+		{"truncate table ks.t", "ks", "t"},
+		{"truncate t", "", "t"},
+		{"create table t (id int primary key)", "", ""},
+	} {
+		var k, tb = operationTarget(&Operation{Kind: OperationExec, Statement: c.statement})
+
+		if k != c.keyspace || tb != c.table {
+			t.Errorf("Actual target %q %q of %q, expected %q %q", k, tb, c.statement, c.keyspace, c.table)
+		}
+	}
+}
+
+func TestErrorType(t *testing.T) {
+	for _, c := range []struct {
+		err      error
+		expected string
+	}{
+		{ErrNotFound, "not_found"},
+		{context.Canceled, "canceled"},
+		{context.DeadlineExceeded, "deadline_exceeded"},
+		{tableNotFound("k", "t"), "table_not_found"},
+		{notApplied("s", ""), "not_applied"},
+		{&Error{Err: errors.New("e")}, "cassandra"},
+		{errors.New("e"), "other"},
+	} {
+		if a := errorType(c.err); a != c.expected {
+			t.Errorf("Actual type %v, expected %v for %v", a, c.expected, c.err)
+		}
+	}
+}
+
+func TestTrace(t *testing.T) {
+	var r = &SpanRecorder{}
+	var s = Chain(newMemorySession(t), Trace(r))
+
+	defer s.Close()
+
+	var ctx, root = r.Start(context.Background(), "request")
+
+	if err := s.ExecContext(ctx, "insert into gockle_test.test (id, n) values (1, 2)"); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var b = s.Batch(BatchUnlogged)
+
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 2, 3)
+	b.Add("insert into gockle_test.test (id, n) values (?, ?)", 3, 4)
+
+	if err := b.ExecContext(ctx); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var i = s.Query("select * from gockle_test.test").WithContext(ctx).Consistency(gocql.Quorum).PageSize(2).Iter()
+	var id, n int
+
+	for i.Scan(&id, &n) {
+	}
+
+	if err := i.Close(); err != nil {
+		t.Errorf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.ScanMap("select * from gockle_test.test where id = 9", map[string]interface{}{}); err != ErrNotFound {
+		t.Errorf("Actual error %v, expected %v", err, ErrNotFound)
+	}
+
+	if _, err := s.Table("gockle_test", "invalid"); err == nil {
+		t.Error("Actual no error, expected error")
+	}
+
+	root.End()
+
+	var base = map[string]interface{}{AttributeSystem: "cassandra", AttributeKeyspace: "gockle_test", AttributeTable: "test"}
+
+	var attributes = func(kind OperationKind, statement string, m map[string]interface{}) map[string]interface{} {
+		var a = map[string]interface{}{AttributeOperation: kind.String()}
+
+		for k, v := range base {
+			a[k] = v
+		}
+
+		if statement != "" {
+			a[AttributeStatement] = statement
+		}
+
+		for k, v := range m {
+			a[k] = v
+		}
+
+		return a
+	}
+
+	var tableNotFound = tableNotFound("gockle_test", "invalid")
+
+	var e = []RecordedSpan{
+		{ID: 1, Name: "request", Attributes: map[string]interface{}{}, Ended: true},
+		{ID: 2, ParentID: 1, Name: "Exec gockle_test.test", Attributes: attributes(OperationExec, "insert into gockle_test.test (id, n) values (?, ?)", nil), Ended: true},
+		{ID: 3, ParentID: 1, Name: "BatchExec gockle_test.test", Attributes: attributes(OperationBatchExec, "insert into gockle_test.test (id, n) values (?, ?)", map[string]interface{}{AttributeBatchSize: 2}), Ended: true},
+		{ID: 4, ParentID: 1, Name: "Iter gockle_test.test", Attributes: attributes(OperationIter, "select * from gockle_test.test", map[string]interface{}{AttributeConsistency: "QUORUM", AttributePageSize: 2}), Ended: true},
+		{ID: 5, ParentID: 1, Name: "IterClose gockle_test.test", Attributes: attributes(OperationIterClose, "select * from gockle_test.test", map[string]interface{}{AttributeConsistency: "QUORUM", AttributePageSize: 2, AttributeRows: 3, AttributePages: 2}), Ended: true},
+		{ID: 6, Name: "ScanMap gockle_test.test", Attributes: attributes(OperationScanMap, "select * from gockle_test.test where id = ?", map[string]interface{}{AttributeErrorType: "not_found"}), Err: ErrNotFound, Ended: true},
+		{ID: 7, Name: "Table gockle_test.invalid", Attributes: attributes(OperationTable, "", map[string]interface{}{AttributeTable: "invalid", AttributeErrorType: "table_not_found"}), Err: tableNotFound, Ended: true},
+	}
+
+	var a = r.Spans()
+
+	if len(a) != len(e) {
+		t.Fatalf("Actual spans %v, expected %v", a, e)
+	}
+
+	for i := range e {
+		if !reflect.DeepEqual(a[i], e[i]) {
+			t.Errorf("Actual span %+v, expected %+v", a[i], e[i])
+		}
+	}
+
+	r.Reset()
+
+	if a := r.Spans(); len(a) != 0 {
+		t.Errorf("Actual spans %v, expected none", a)
+	}
+}
+
+func TestTraceCoordinator(t *testing.T) {
+	var r = &SpanRecorder{}
+	var s = Chain(newSession(t), Trace(r))
+
+	defer s.Close()
+
+	for _, q := range []string{ksDropIf, ksCreate, tabCreate} {
+		if err := s.Exec(q); err != nil {
+			t.Fatalf("Actual error %v, expected no error", err)
+		}
+	}
+
+	if err := s.Query(rowInsert).Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var b = s.Batch(BatchUnlogged)
+
+	b.Add(rowInsert)
+
+	if err := b.Exec(); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	if err := s.Exec(ksDrop); err != nil {
+		t.Fatalf("Actual error %v, expected no error", err)
+	}
+
+	var spans = r.Spans()
+
+	if len(spans) != 6 {
+		t.Fatalf("Actual spans %v, expected 6", spans)
+	}
+
+	for _, span := range spans {
+		if a, e := span.Attributes[AttributeCoordinator], server.Addr; a != e {
+			t.Errorf("Actual coordinator %v of %v, expected %v", a, span.Name, e)
+		}
+	}
+}